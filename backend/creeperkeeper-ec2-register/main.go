@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmTypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/hnucamendi/jwt-go/jwt"
 )
@@ -31,10 +33,31 @@ type Server struct {
 	Name        *string `json:"serverName" dynamodbav:"ServerName"`
 	LastUpdated *string `json:"lastUpdated" dynamodbav:"LastUpdated"`
 	IsRunning   *bool   `json:"isRunning" dynamodbav:"IsRunning"`
+	State       *string `json:"state" dynamodbav:"State"`
+}
+
+// ContainerSpec is the part of the container the backend renders from the
+// server profile that the boot needs.
+type ContainerSpec struct {
+	Start []string `json:"start"`
+}
+
+type ServerPing struct {
+	State  *string          `json:"state"`
+	Status *json.RawMessage `json:"status"`
 }
 
 const (
-	baseURL string = "https://api.creeperkeeper.com"
+	baseURL      string        = "https://api.creeperkeeper.com"
+	worldBucket  string        = "creeperkeeper-world-data"
+	pollInterval time.Duration = 2 * time.Second
+
+	readyPollInterval time.Duration = 10 * time.Second
+	readyTimeout      time.Duration = 10 * time.Minute
+
+	stateStarting   string = "STARTING"
+	stateReady      string = "READY"
+	stateBootFailed string = "BOOT_FAILED"
 )
 
 var (
@@ -142,9 +165,99 @@ func handleRunningState(ctx context.Context, detail *Detail, clients *Clients) e
 		return fmt.Errorf("failed to register server %w", err)
 	}
 
+	err = restoreWorld(ctx, clients, &detail.InstanceID, detail.ServerName)
+	if err != nil {
+		return bootFailed(clients, &detail.InstanceID, fmt.Errorf("failed to restore world %w", err))
+	}
+
 	err = startServer(ctx, clients, &detail.InstanceID, detail.ServerName)
 	if err != nil {
-		return fmt.Errorf("failed to start minecraft server %w", err)
+		return bootFailed(clients, &detail.InstanceID, fmt.Errorf("failed to start minecraft server %w", err))
+	}
+
+	err = waitForReady(ctx, clients, &detail.InstanceID)
+	if err != nil {
+		return bootFailed(clients, &detail.InstanceID, fmt.Errorf("minecraft server failed to boot %w", err))
+	}
+
+	err = updateServerState(clients, &detail.InstanceID, stateReady)
+	if err != nil {
+		return fmt.Errorf("failed to mark server ready %w", err)
+	}
+
+	return nil
+}
+
+// bootFailed records the failed boot on the server, so anything waiting on it
+// such as an upgrade can act on it, and returns the failure.
+func bootFailed(c *Clients, serverID *string, cause error) error {
+	err := updateServerState(c, serverID, stateBootFailed)
+	if err != nil {
+		return fmt.Errorf("%w, and failed to record it %v", cause, err)
+	}
+	return cause
+}
+
+// waitForReady polls the server through the ping endpoint until Minecraft
+// answers the status protocol, which only happens once it accepts players.
+func waitForReady(ctx context.Context, c *Clients, serverID *string) error {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("server not ready after %v", readyTimeout)
+		case <-time.After(readyPollInterval):
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/server/ping/"+*serverID, nil)
+		if err != nil {
+			return err
+		}
+
+		req.Header.Add("Authorization", "Bearer "+c.jwtClient.AuthToken)
+
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			continue
+		}
+
+		var ping ServerPing
+		err = json.NewDecoder(res.Body).Decode(&ping)
+		res.Body.Close()
+		if err != nil || res.StatusCode != 200 {
+			continue
+		}
+
+		if ping.Status != nil {
+			return nil
+		}
+	}
+}
+
+func updateServerState(c *Clients, serverID *string, state string) error {
+	jbody, err := json.Marshal(&Server{State: &state})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", baseURL+"/servers/"+*serverID+"/state", bytes.NewBuffer(jbody))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Authorization", "Bearer "+c.jwtClient.AuthToken)
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return fmt.Errorf("failed to update server state %v", res.Status)
 	}
 
 	return nil
@@ -216,7 +329,28 @@ func getParameters(ctx context.Context, c *Clients) (*string, *string, *string,
 	return clientID, clientSecret, audience, tenantURL, nil
 }
 
+// startServer boots the container from the spec rendered from the server
+// profile, which recreates it when the profile changed. Servers without a
+// profile start the container terraform created.
 func startServer(ctx context.Context, clients *Clients, serverID *string, serverName *string) error {
+	spec, err := getContainerSpec(ctx, clients, serverID)
+	if err != nil {
+		return err
+	}
+
+	if spec != nil {
+		code, err := sendAndWait(ctx, clients, serverID, spec.Start)
+		if err != nil {
+			return err
+		}
+
+		if code != 0 {
+			return fmt.Errorf("container start exited with code %d", code)
+		}
+
+		return nil
+	}
+
 	cmds := []string{"sudo docker start " + *serverName}
 	input := &ssm.SendCommandInput{
 		DocumentName: aws.String("AWS-RunShellScript"),
@@ -227,7 +361,7 @@ func startServer(ctx context.Context, clients *Clients, serverID *string, server
 		},
 	}
 
-	_, err := clients.ssmClient.SendCommand(ctx, input)
+	_, err = clients.ssmClient.SendCommand(ctx, input)
 	if err != nil {
 		return fmt.Errorf("ERROR TAMO %v", err)
 	}
@@ -235,6 +369,114 @@ func startServer(ctx context.Context, clients *Clients, serverID *string, server
 	return nil
 }
 
+// getContainerSpec fetches the container the server should run, or nil when
+// the server has no profile.
+func getContainerSpec(ctx context.Context, c *Clients, serverID *string) (*ContainerSpec, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/servers/"+*serverID+"/container", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Authorization", "Bearer "+c.jwtClient.AuthToken)
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return nil, nil
+	}
+
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("failed to get container spec %v", res.Status)
+	}
+
+	var spec ContainerSpec
+	err = json.NewDecoder(res.Body).Decode(&spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(spec.Start) == 0 {
+		return nil, fmt.Errorf("container spec has no start script")
+	}
+
+	return &spec, nil
+}
+
+// restoreWorld pulls the latest world for the server from S3 into the data
+// directory before the container boots, so the bucket is the source of truth.
+// A server without a world in the bucket keeps whatever is on disk.
+func restoreWorld(ctx context.Context, clients *Clients, serverID *string, serverName *string) error {
+	prefix := *serverName + "/"
+	cmds := []string{
+		"count=$(sudo aws s3api list-objects-v2 --bucket " + worldBucket + " --prefix " + prefix + " --max-keys 1 --query KeyCount --output text) || exit 1",
+		"if [ \"$count\" = \"0\" ]; then echo \"no world stored for " + *serverName + "\"; exit 0; fi",
+		"sudo aws s3 sync --delete s3://" + worldBucket + "/" + prefix + " data",
+	}
+
+	code, err := sendAndWait(ctx, clients, serverID, cmds)
+	if err != nil {
+		return err
+	}
+
+	if code != 0 {
+		return fmt.Errorf("world sync exited with code %d", code)
+	}
+
+	return nil
+}
+
+// sendAndWait runs the commands on the instance and blocks until the
+// invocation reaches a terminal status, returning the exit code of the script.
+func sendAndWait(ctx context.Context, clients *Clients, serverID *string, cmds []string) (int32, error) {
+	input := &ssm.SendCommandInput{
+		DocumentName: aws.String("AWS-RunShellScript"),
+		InstanceIds:  []string{*serverID},
+		Parameters: map[string][]string{
+			"commands":         cmds,
+			"workingDirectory": {"/home/ec2-user"},
+		},
+	}
+
+	out, err := clients.ssmClient.SendCommand(ctx, input)
+	if err != nil {
+		return -1, err
+	}
+
+	invocationInput := &ssm.GetCommandInvocationInput{
+		CommandId:  out.Command.CommandId,
+		InstanceId: serverID,
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+
+		invocation, err := clients.ssmClient.GetCommandInvocation(ctx, invocationInput)
+		if err != nil {
+			var notFound *ssmTypes.InvocationDoesNotExist
+			if errors.As(err, &notFound) {
+				continue
+			}
+			return -1, err
+		}
+
+		switch invocation.Status {
+		case ssmTypes.CommandInvocationStatusSuccess:
+			return invocation.ResponseCode, nil
+		case ssmTypes.CommandInvocationStatusFailed, ssmTypes.CommandInvocationStatusCancelled, ssmTypes.CommandInvocationStatusTimedOut:
+			return invocation.ResponseCode, fmt.Errorf("command finished with status %s and exit code %d", invocation.Status, invocation.ResponseCode)
+		}
+	}
+}
+
 func registerServerDetails(c *Clients, serverID *string, serverIP *string, serverName *string) error {
 	zone, err := time.LoadLocation("America/New_York")
 	if err != nil {
@@ -244,6 +486,7 @@ func registerServerDetails(c *Clients, serverID *string, serverIP *string, serve
 	lastUpdated := time.Now().In(zone).Format(time.DateTime)
 	sk := "serverdetails"
 	isRunning := true
+	state := stateStarting
 
 	body := &Server{
 		ID:          serverID,
@@ -252,6 +495,7 @@ func registerServerDetails(c *Clients, serverID *string, serverIP *string, serve
 		Name:        serverName,
 		LastUpdated: &lastUpdated,
 		IsRunning:   &isRunning,
+		State:       &state,
 	}
 
 	jbody, err := json.Marshal(body)
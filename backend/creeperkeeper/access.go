@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/minecraft/access"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const syncAccessJobRoute string = "/creeperkeeper/jobs/sync-access"
+
+// GetAccessList returns the server's stored access list, or what is in the
+// server's list file when none has been stored yet.
+func (h *Handler) GetAccessList(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	list := r.PathValue("list")
+	if serverID == "" || !access.Valid(list) {
+		writeResponse(w, r, http.StatusBadRequest, "serverID and one of "+strings.Join(access.Lists, ", ")+" must be provided")
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	accessList, err := h.accessList(r.Context(), server, list)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, accessList)
+}
+
+// UpdateAccessList changes a server's access list and stores the result.
+// A ready server is changed through console commands, a stopped one has the
+// list file in its stored world replaced. Otherwise the list is applied when
+// the server next becomes ready.
+func (h *Handler) UpdateAccessList(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	list := r.PathValue("list")
+	if serverID == "" || !access.Valid(list) {
+		writeResponse(w, r, http.StatusBadRequest, "serverID and one of "+strings.Join(access.Lists, ", ")+" must be provided")
+		return
+	}
+
+	update := &types.AccessListUpdate{}
+	err := update.UnmarshallRequest(r.Body)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(update.Add) == 0 && len(update.Remove) == 0 {
+		writeResponse(w, r, http.StatusBadRequest, "entries to add or remove must be provided")
+		return
+	}
+	for i := range update.Add {
+		err = access.Validate(list, &update.Add[i])
+		if err != nil {
+			writeResponse(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	accessList, err := h.accessList(r.Context(), server, list)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = h.resolveUUIDs(r.Context(), server, list, update.Add)
+	if errors.Is(err, access.ErrInvalidEntry) {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	accessList.Entries = updateEntries(list, accessList.Entries, update)
+	accessList.UpdatedAt = utils.String(time.Now().UTC().Format(time.RFC3339))
+	err = h.Client.db.Client.PutAccessList(r.Context(), utils.ToString(h.Client.db.Table), accessList)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	accessList.Stored = true
+
+	accessList.Applied, err = h.applyAccessList(r.Context(), server, accessList)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, fmt.Errorf("access list saved, but failed to apply: %w", err).Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, accessList)
+}
+
+// SyncAccessJob applies every stored access list to a server that has just
+// become ready, so lists survive the instance being replaced.
+func (h *Handler) SyncAccessJob(w http.ResponseWriter, r *http.Request) {
+	job := &types.OperationJob{}
+	err := job.UnmarshallRequest(r.Body)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), utils.ToString(job.ServerID))
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+utils.ToString(job.ServerID))
+		return
+	}
+
+	results := map[string]string{}
+	for _, list := range access.Lists {
+		accessList, err := h.Client.db.Client.GetAccessList(r.Context(), utils.ToString(h.Client.db.Table), utils.ToString(server.ID), list)
+		if err != nil {
+			results[list] = err.Error()
+			continue
+		}
+		if accessList == nil {
+			continue
+		}
+
+		applied, err := h.applyAccessList(r.Context(), server, accessList)
+		if err != nil {
+			results[list] = err.Error()
+			continue
+		}
+		results[list] = utils.ToString(applied)
+	}
+
+	writeResponse(w, r, http.StatusOK, results)
+}
+
+// syncAccessLists queues applying the server's stored access lists.
+func (h *Handler) syncAccessLists(ctx context.Context, serverID string) {
+	err := h.Client.jobs.Client.Enqueue(ctx, syncAccessJobRoute, &types.OperationJob{ServerID: utils.String(serverID)})
+	if err != nil {
+		log.Println("failed to enqueue access list sync for", serverID, err)
+	}
+}
+
+// accessList returns the stored list, or one read from the server's file
+// when none is stored.
+func (h *Handler) accessList(ctx context.Context, server *types.Server, list string) (*types.AccessList, error) {
+	accessList, err := h.Client.db.Client.GetAccessList(ctx, utils.ToString(h.Client.db.Table), utils.ToString(server.ID), list)
+	if err != nil {
+		return nil, err
+	}
+	if accessList != nil {
+		if accessList.Entries == nil {
+			accessList.Entries = []access.Entry{}
+		}
+		return accessList, nil
+	}
+
+	b, _, err := h.readDataFile(ctx, server, access.FileName(list))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", access.FileName(list), err)
+	}
+
+	entries, err := access.Read(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.AccessList{
+		ServerID: server.ID,
+		List:     utils.String(list),
+		Entries:  entries,
+	}, nil
+}
+
+// applyAccessList brings the server in line with the list. A ready server
+// is sent commands for the differences from its list file, which it then
+// writes itself. A stopped server with a stored world has the file
+// replaced. A server that is starting, or has nothing stored, is left for
+// the sync when it next becomes ready.
+func (h *Handler) applyAccessList(ctx context.Context, server *types.Server, accessList *types.AccessList) (*string, error) {
+	list := utils.ToString(accessList.List)
+
+	if !utils.ToBool(server.IsRunning) {
+		b, err := access.Write(list, accessList.Entries)
+		if err != nil {
+			return nil, err
+		}
+
+		err = h.writeDataFile(ctx, server, access.FileName(list), b, false)
+		if errors.Is(err, errNoStoredWorld) {
+			return utils.String(types.AccessAppliedPending), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return utils.String(types.AccessAppliedStored), nil
+	}
+
+	if utils.ToString(server.State) != types.StateReady {
+		return utils.String(types.AccessAppliedPending), nil
+	}
+
+	b, _, err := h.readDataFile(ctx, server, access.FileName(list))
+	if err != nil {
+		return nil, err
+	}
+	current, err := access.Read(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	add, remove := access.Diff(list, current, accessList.Entries)
+	for _, e := range remove {
+		_, err = h.Client.console.Client.Exec(ctx, server, access.RemoveCommand(list, e))
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range add {
+		_, err = h.Client.console.Client.Exec(ctx, server, access.AddCommand(list, e))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return utils.String(types.AccessAppliedLive), nil
+}
+
+// updateEntries removes the entries named in the update and adds its new
+// ones, replacing any with the same key.
+func updateEntries(list string, entries []access.Entry, update *types.AccessListUpdate) []access.Entry {
+	drop := map[string]bool{}
+	for _, key := range update.Remove {
+		drop[access.NormalizeKey(list, key)] = true
+	}
+	for _, e := range update.Add {
+		drop[access.Key(list, e)] = true
+	}
+
+	out := []access.Entry{}
+	for _, e := range entries {
+		if drop[access.Key(list, e)] || (e.UUID != "" && drop[access.NormalizeKey(list, e.UUID)]) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return append(out, update.Add...)
+}
+
+// resolveUUIDs fills in the UUIDs of player entries, which the list files
+// need. Players the server has seen are found in its usercache. Others are
+// looked up by name in online mode, or given their offline UUID.
+func (h *Handler) resolveUUIDs(ctx context.Context, server *types.Server, list string, entries []access.Entry) error {
+	if list == access.BannedIPs || len(entries) == 0 {
+		return nil
+	}
+
+	names, err := h.userCache(ctx, utils.ToString(h.Client.storage.Bucket), backup.WorldPrefix(utils.ToString(server.Name))+userCacheFile)
+	if err != nil {
+		return err
+	}
+	uuids := map[string]string{}
+	for uuid, name := range names {
+		uuids[strings.ToLower(name)] = uuid
+	}
+
+	online, checked := true, false
+	for i := range entries {
+		e := &entries[i]
+		if e.UUID != "" {
+			continue
+		}
+		if uuid, ok := uuids[strings.ToLower(e.Name)]; ok {
+			e.UUID = uuid
+			continue
+		}
+
+		if !checked {
+			online, err = h.onlineMode(ctx, server)
+			if err != nil {
+				return err
+			}
+			checked = true
+		}
+		if !online {
+			e.UUID = access.OfflineUUID(e.Name)
+			continue
+		}
+
+		e.UUID, err = access.LookupUUID(ctx, e.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// onlineMode reports whether the server checks player accounts, which it
+// does unless server.properties turns online-mode off.
+func (h *Handler) onlineMode(ctx context.Context, server *types.Server) (bool, error) {
+	f, _, err := h.readProperties(ctx, server)
+	if err != nil {
+		return false, err
+	}
+	v, _ := f.Get("online-mode")
+	return v != "false", nil
+}
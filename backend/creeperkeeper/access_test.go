@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/access"
+	"github.com/hnucamendi/creeper-keeper/types"
+)
+
+func TestUpdateEntries(t *testing.T) {
+	steve := access.Entry{UUID: "8667ba71-b85a-4004-af54-457a9734eed7", Name: "Steve"}
+	alex := access.Entry{UUID: "ec561538-f3fd-461d-aff5-086b22154bce", Name: "Alex"}
+
+	tests := []struct {
+		name    string
+		list    string
+		entries []access.Entry
+		update  types.AccessListUpdate
+		want    []access.Entry
+	}{
+		{
+			name:    "add",
+			list:    access.Whitelist,
+			entries: []access.Entry{steve},
+			update:  types.AccessListUpdate{Add: []access.Entry{alex}},
+			want:    []access.Entry{steve, alex},
+		},
+		{
+			name:    "add replaces the same name",
+			list:    access.Ops,
+			entries: []access.Entry{{UUID: steve.UUID, Name: "steve", Level: 4}, alex},
+			update:  types.AccessListUpdate{Add: []access.Entry{{Name: "Steve", Level: 2}}},
+			want:    []access.Entry{alex, {Name: "Steve", Level: 2}},
+		},
+		{
+			name:    "remove by name",
+			list:    access.Whitelist,
+			entries: []access.Entry{steve, alex},
+			update:  types.AccessListUpdate{Remove: []string{"STEVE"}},
+			want:    []access.Entry{alex},
+		},
+		{
+			name:    "remove by uuid",
+			list:    access.BannedPlayers,
+			entries: []access.Entry{steve, alex},
+			update:  types.AccessListUpdate{Remove: []string{"EC561538-F3FD-461D-AFF5-086B22154BCE"}},
+			want:    []access.Entry{steve},
+		},
+		{
+			name:    "remove ip written differently",
+			list:    access.BannedIPs,
+			entries: []access.Entry{{IP: "2001:db8::1"}, {IP: "10.0.0.1"}},
+			update:  types.AccessListUpdate{Remove: []string{"2001:DB8:0:0:0:0:0:1"}},
+			want:    []access.Entry{{IP: "10.0.0.1"}},
+		},
+		{
+			name:    "remove missing entry",
+			list:    access.Whitelist,
+			entries: []access.Entry{steve},
+			update:  types.AccessListUpdate{Remove: []string{"Notch"}},
+			want:    []access.Entry{steve},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := updateEntries(tt.list, tt.entries, &tt.update)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("updateEntries = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,620 @@
+// Package backup creates and manages versioned, compressed world archives.
+// Each backup is a tar.gz of the server's world prefix stored next to a JSON
+// manifest describing its contents.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/properties"
+	"github.com/hnucamendi/creeper-keeper/service/storage"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const (
+	TriggerManual      string = "manual"
+	TriggerPreRestore  string = "pre-restore"
+	TriggerScheduled   string = "scheduled"
+	TriggerDownload    string = "download"
+	TriggerPreImport   string = "pre-import"
+	TriggerChunkPrune  string = "chunk-prune"
+	TriggerPreRollback string = "pre-rollback"
+	TriggerPreUpgrade  string = "pre-upgrade"
+
+	prefix          string = "backups/"
+	exportPrefix    string = "downloads/"
+	archiveSuffix   string = ".tar.gz"
+	manifestSuffix  string = ".json"
+	backupIDFormat  string = "20060102T150405Z"
+	archiveFileMode int64  = 0o644
+)
+
+var (
+	ErrNotFound = errors.New("backup not found")
+	ErrNoWorld  = errors.New("no world stored")
+)
+
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+type Manifest struct {
+	ID         string `json:"backupID"`
+	ServerID   string `json:"serverID"`
+	ServerName string `json:"serverName"`
+	MCVersion  string `json:"mcVersion,omitempty"`
+	// LevelName is the world directory in the archive, read from the
+	// server's server.properties when the backup was taken.
+	LevelName string `json:"levelName,omitempty"`
+	Trigger   string `json:"trigger"`
+	// SourceID is the backup this one was derived from, if any.
+	SourceID         string    `json:"sourceID,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+	Archive          string    `json:"archive"`
+	Size             int64     `json:"size"`
+	UncompressedSize int64     `json:"uncompressedSize"`
+	SHA256           string    `json:"sha256"`
+	Files            []File    `json:"files"`
+}
+
+// Level is the world directory in the archive. Backups from before it was
+// recorded used the default.
+func (m *Manifest) Level() string {
+	if m.LevelName == "" {
+		return DefaultLevelName
+	}
+	return m.LevelName
+}
+
+type Client struct {
+	Storage storage.Storage
+	Bucket  string
+}
+
+func NewBackup(s *storage.Client) *Client {
+	return &Client{
+		Storage: s.Client,
+		Bucket:  utils.ToString(s.Bucket),
+	}
+}
+
+// WorldPrefix is where a server's live world is synced in the bucket.
+func WorldPrefix(serverName string) string {
+	return serverName + "/"
+}
+
+// StoredProperties reads the server.properties stored with the server's
+// world, which the server writes on every start. A server without one reads
+// as an empty file.
+func (c *Client) StoredProperties(ctx context.Context, serverName string) (*properties.File, error) {
+	body, err := c.Storage.Get(ctx, c.Bucket, WorldPrefix(serverName)+properties.ServerFile)
+	if errors.Is(err, types.ErrObjectNotFound) {
+		return &properties.File{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	f, err := properties.Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", properties.ServerFile, err)
+	}
+	return f, nil
+}
+
+// LevelName reads the world directory from the level-name in the server's
+// stored server.properties. A server without one uses DefaultLevelName.
+func (c *Client) LevelName(ctx context.Context, serverName string) (string, error) {
+	f, err := c.StoredProperties(ctx, serverName)
+	if err != nil {
+		return "", err
+	}
+
+	name, ok := f.Get("level-name")
+	if !ok || name == "" {
+		return DefaultLevelName, nil
+	}
+	return name, nil
+}
+
+func ArchiveKey(serverID string, backupID string) string {
+	return prefix + serverID + "/" + backupID + archiveSuffix
+}
+
+// ExportKey is where a world archive made for download is stored. Exports
+// are expired by a lifecycle rule on the bucket.
+func ExportKey(serverID string, exportID string) string {
+	return exportPrefix + serverID + "/" + exportID + archiveSuffix
+}
+
+func ManifestKey(serverID string, backupID string) string {
+	return prefix + serverID + "/" + backupID + manifestSuffix
+}
+
+// Create archives the server's world and stores it with its manifest. The
+// caller fills in the server and trigger fields of m.
+func (c *Client) Create(ctx context.Context, m *Manifest) (*Manifest, error) {
+	err := c.assignID(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.writeArchive(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.putManifest(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// assignID names a new backup after its creation time. IDs have one second
+// resolution, so the time is moved on until it names no existing backup.
+func (c *Client) assignID(ctx context.Context, m *Manifest) error {
+	m.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	for {
+		m.ID = m.CreatedAt.Format(backupIDFormat)
+		_, err := c.Get(ctx, m.ServerID, m.ID)
+		if errors.Is(err, ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		m.CreatedAt = m.CreatedAt.Add(time.Second)
+	}
+
+	m.Archive = ArchiveKey(m.ServerID, m.ID)
+	return nil
+}
+
+// Export archives the server's world for download. Exports are not backups:
+// they have no stored manifest and are not listed with the server's backups.
+func (c *Client) Export(ctx context.Context, m *Manifest) (*Manifest, error) {
+	m.CreatedAt = time.Now().UTC()
+	m.ID = m.CreatedAt.Format(backupIDFormat)
+	m.Archive = ExportKey(m.ServerID, m.ID)
+
+	err := c.writeArchive(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// writeArchive builds a tar.gz of the server's world, fills in the
+// archive fields of m and uploads it to m.Archive.
+func (c *Client) writeArchive(ctx context.Context, m *Manifest) error {
+	objects, err := c.Storage.List(ctx, c.Bucket, WorldPrefix(m.ServerName))
+	if err != nil {
+		return fmt.Errorf("failed to list world: %w", err)
+	}
+
+	if len(objects) == 0 {
+		return fmt.Errorf("%w for %s", ErrNoWorld, m.ServerName)
+	}
+
+	m.LevelName, err = c.LevelName(ctx, m.ServerName)
+	if err != nil {
+		return err
+	}
+
+	aw, err := newArchiveWriter(m)
+	if err != nil {
+		return err
+	}
+	defer aw.close()
+
+	for _, o := range objects {
+		err = c.addObject(ctx, aw, o, WorldPrefix(m.ServerName))
+		if err != nil {
+			return err
+		}
+	}
+
+	return aw.upload(ctx, c)
+}
+
+func (c *Client) addObject(ctx context.Context, aw *archiveWriter, o types.Object, worldPrefix string) error {
+	key := utils.ToString(o.Key)
+	body, err := c.Storage.Get(ctx, c.Bucket, key)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	defer body.Close()
+
+	err = aw.add(strings.TrimPrefix(key, worldPrefix), o.Size, o.LastModified, body)
+	if err != nil {
+		return fmt.Errorf("failed to archive %s: %w", key, err)
+	}
+	return nil
+}
+
+// archiveWriter builds a tar.gz in a temporary file, recording each file
+// and the archive's checksum on the manifest.
+type archiveWriter struct {
+	m    *Manifest
+	tmp  *os.File
+	hash hash.Hash
+	gz   *gzip.Writer
+	tw   *tar.Writer
+}
+
+func newArchiveWriter(m *Manifest) (*archiveWriter, error) {
+	tmp, err := os.CreateTemp("", "backup-*"+archiveSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Files = []File{}
+	m.UncompressedSize = 0
+	aw := &archiveWriter{
+		m:    m,
+		tmp:  tmp,
+		hash: sha256.New(),
+	}
+	aw.gz = gzip.NewWriter(io.MultiWriter(tmp, aw.hash))
+	aw.tw = tar.NewWriter(aw.gz)
+	return aw, nil
+}
+
+func (aw *archiveWriter) add(path string, size int64, modTime time.Time, r io.Reader) error {
+	err := aw.tw.WriteHeader(&tar.Header{
+		Name:    path,
+		Size:    size,
+		Mode:    archiveFileMode,
+		ModTime: modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(aw.tw, h), r)
+	if err != nil {
+		return err
+	}
+
+	aw.m.Files = append(aw.m.Files, File{
+		Path:   path,
+		Size:   n,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	})
+	aw.m.UncompressedSize += n
+	return nil
+}
+
+// upload finishes the archive and stores it at the manifest's archive key.
+func (aw *archiveWriter) upload(ctx context.Context, c *Client) error {
+	err := aw.tw.Close()
+	if err != nil {
+		return err
+	}
+	err = aw.gz.Close()
+	if err != nil {
+		return err
+	}
+
+	aw.m.Size, err = aw.tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	aw.m.SHA256 = hex.EncodeToString(aw.hash.Sum(nil))
+
+	_, err = aw.tmp.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	err = c.Storage.Put(ctx, c.Bucket, aw.m.Archive, aw.tmp)
+	if err != nil {
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+	return nil
+}
+
+func (aw *archiveWriter) close() {
+	aw.tmp.Close()
+	os.Remove(aw.tmp.Name())
+}
+
+// List returns the server's backups, newest first.
+func (c *Client) List(ctx context.Context, serverID string) ([]Manifest, error) {
+	serverPrefix := prefix + serverID + "/"
+	objects, err := c.Storage.List(ctx, c.Bucket, serverPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := []Manifest{}
+	for _, o := range objects {
+		key := utils.ToString(o.Key)
+		// Anything in a subdirectory, like map tiles, belongs to a backup
+		if !strings.HasSuffix(key, manifestSuffix) || strings.Contains(strings.TrimPrefix(key, serverPrefix), "/") {
+			continue
+		}
+
+		m, err := c.getManifest(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, *m)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].CreatedAt.After(manifests[j].CreatedAt)
+	})
+	return manifests, nil
+}
+
+func (c *Client) Get(ctx context.Context, serverID string, backupID string) (*Manifest, error) {
+	return c.getManifest(ctx, ManifestKey(serverID, backupID))
+}
+
+// Open returns a reader over the backup's tar.gz archive.
+func (c *Client) Open(ctx context.Context, m *Manifest) (io.ReadCloser, error) {
+	body, err := c.Storage.Get(ctx, c.Bucket, m.Archive)
+	if errors.Is(err, types.ErrObjectNotFound) {
+		return nil, ErrNotFound
+	}
+	return body, err
+}
+
+// Restore replaces the server's world with the contents of an archive. When
+// m is set the archive and every file in it are checked against the manifest
+// before the world is touched. Keys in the world that are not in the archive
+// are removed.
+func (c *Client) Restore(ctx context.Context, serverName string, archive string, versionID string, m *Manifest) error {
+	tmp, err := c.download(ctx, archive, versionID, m)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	files, err := verifyArchive(tmp, m)
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	gz, err := gzip.NewReader(tmp)
+	if err != nil {
+		return fmt.Errorf("invalid archive %s: %w", archive, err)
+	}
+	defer gz.Close()
+
+	worldPrefix := WorldPrefix(serverName)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid archive %s: %w", archive, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		err = c.putFile(ctx, worldPrefix+hdr.Name, tr)
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", hdr.Name, err)
+		}
+	}
+
+	objects, err := c.Storage.List(ctx, c.Bucket, worldPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list world: %w", err)
+	}
+
+	for _, o := range objects {
+		key := utils.ToString(o.Key)
+		if _, ok := files[strings.TrimPrefix(key, worldPrefix)]; ok {
+			continue
+		}
+
+		err = c.Storage.Delete(ctx, c.Bucket, key)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// download copies an archive to a temporary file, checking it against the
+// manifest's checksum when there is one.
+func (c *Client) download(ctx context.Context, archive string, versionID string, m *Manifest) (*os.File, error) {
+	body, err := c.Storage.GetVersion(ctx, c.Bucket, archive, versionID)
+	if errors.Is(err, types.ErrObjectNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp("", "restore-*"+archiveSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), body)
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to download %s: %w", archive, err)
+	}
+
+	if m != nil && m.SHA256 != hex.EncodeToString(h.Sum(nil)) {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("archive %s does not match its manifest checksum", archive)
+	}
+	return tmp, nil
+}
+
+// verifyArchive reads the whole archive and returns the paths of the files
+// in it. With a manifest, each file must be listed with a matching checksum
+// and no listed file may be missing.
+func verifyArchive(r io.ReadSeeker, m *Manifest) (map[string]struct{}, error) {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid archive: %w", err)
+	}
+	defer gz.Close()
+
+	expected := map[string]string{}
+	if m != nil {
+		for _, f := range m.Files {
+			expected[f.Path] = f.SHA256
+		}
+	}
+
+	files := map[string]struct{}{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !fs.ValidPath(hdr.Name) {
+			return nil, fmt.Errorf("invalid path in archive: %s", hdr.Name)
+		}
+
+		h := sha256.New()
+		_, err = io.Copy(h, tr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive: %w", err)
+		}
+		files[hdr.Name] = struct{}{}
+
+		if m == nil {
+			continue
+		}
+
+		sum, ok := expected[hdr.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not in the manifest", hdr.Name)
+		}
+		if sum != hex.EncodeToString(h.Sum(nil)) {
+			return nil, fmt.Errorf("%s does not match its manifest checksum", hdr.Name)
+		}
+	}
+
+	if m != nil && len(files) != len(expected) {
+		return nil, errors.New("archive is missing files listed in the manifest")
+	}
+	return files, nil
+}
+
+// putFile uploads r under key, spooling it to disk first since uploads need
+// a seekable body.
+func (c *Client) putFile(ctx context.Context, key string, r io.Reader) error {
+	tmp, err := os.CreateTemp("", "restore-file-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	_, err = io.Copy(tmp, r)
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	return c.Storage.Put(ctx, c.Bucket, key, tmp)
+}
+
+func (c *Client) Delete(ctx context.Context, serverID string, backupID string) error {
+	_, err := c.Get(ctx, serverID, backupID)
+	if err != nil {
+		return err
+	}
+
+	err = c.deleteMap(ctx, serverID, backupID)
+	if err != nil {
+		return fmt.Errorf("failed to delete map: %w", err)
+	}
+
+	err = c.Storage.Delete(ctx, c.Bucket, ArchiveKey(serverID, backupID))
+	if err != nil {
+		return err
+	}
+	return c.Storage.Delete(ctx, c.Bucket, ManifestKey(serverID, backupID))
+}
+
+func (c *Client) getManifest(ctx context.Context, key string) (*Manifest, error) {
+	body, err := c.Storage.Get(ctx, c.Bucket, key)
+	if errors.Is(err, types.ErrObjectNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var m Manifest
+	err = json.NewDecoder(body).Decode(&m)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", key, err)
+	}
+	return &m, nil
+}
+
+func (c *Client) putManifest(ctx context.Context, m *Manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	err = c.Storage.Put(ctx, c.Bucket, ManifestKey(m.ServerID, m.ID), bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to upload manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnucamendi/creeper-keeper/service/storage/local"
+	"github.com/hnucamendi/creeper-keeper/types"
+)
+
+const testServer string = "RedCraft"
+
+var testWorld = map[string]string{
+	"server.properties":         "level-name=survival\n",
+	"survival/level.dat":        "level",
+	"survival/region/r.0.0.mca": "region",
+}
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	return &Client{Storage: local.NewStorage(t.TempDir()), Bucket: "worlds"}
+}
+
+func putWorld(t *testing.T, c *Client, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		err := c.Storage.Put(context.Background(), c.Bucket, WorldPrefix(testServer)+path, strings.NewReader(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readWorld returns every file in the server's world by its path in the
+// world.
+func readWorld(t *testing.T, c *Client) map[string]string {
+	t.Helper()
+	ctx := context.Background()
+	objects, err := c.Storage.List(ctx, c.Bucket, WorldPrefix(testServer))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{}
+	for _, o := range objects {
+		body, err := c.Storage.Get(ctx, c.Bucket, *o.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[strings.TrimPrefix(*o.Key, WorldPrefix(testServer))] = string(b)
+	}
+	return files
+}
+
+func createBackup(t *testing.T, c *Client) *Manifest {
+	t.Helper()
+	m, err := c.Create(context.Background(), &Manifest{ServerID: "i-123", ServerName: testServer, Trigger: TriggerManual})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// buildArchive writes a tar.gz holding files in order.
+func buildArchive(t *testing.T, files ...[2]string) []byte {
+	t.Helper()
+	b := &bytes.Buffer{}
+	gz := gzip.NewWriter(b)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		err := tw.WriteHeader(&tar.Header{Name: f[0], Size: int64(len(f[1])), Mode: archiveFileMode, Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(f[1]))
+	}
+	tw.Close()
+	gz.Close()
+	return b.Bytes()
+}
+
+func sum(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+func TestCreate(t *testing.T) {
+	c := newTestClient(t)
+	putWorld(t, c, testWorld)
+
+	m := createBackup(t, c)
+	if m.LevelName != "survival" {
+		t.Errorf("LevelName = %q, want survival", m.LevelName)
+	}
+	if m.Archive != ArchiveKey("i-123", m.ID) {
+		t.Errorf("Archive = %q, want %q", m.Archive, ArchiveKey("i-123", m.ID))
+	}
+
+	wantFiles := []File{
+		{Path: "server.properties", Size: 20, SHA256: sum("level-name=survival\n")},
+		{Path: "survival/level.dat", Size: 5, SHA256: sum("level")},
+		{Path: "survival/region/r.0.0.mca", Size: 6, SHA256: sum("region")},
+	}
+	if !reflect.DeepEqual(m.Files, wantFiles) {
+		t.Errorf("Files = %+v, want %+v", m.Files, wantFiles)
+	}
+	if m.UncompressedSize != 31 {
+		t.Errorf("UncompressedSize = %d, want 31", m.UncompressedSize)
+	}
+
+	body, err := c.Storage.Get(context.Background(), c.Bucket, m.Archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(archive)) != m.Size || sum(string(archive)) != m.SHA256 {
+		t.Errorf("archive is %d bytes with checksum %s, manifest says %d and %s", len(archive), sum(string(archive)), m.Size, m.SHA256)
+	}
+
+	stored, err := c.Get(context.Background(), "i-123", m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.SHA256 != m.SHA256 || len(stored.Files) != len(m.Files) {
+		t.Errorf("stored manifest = %+v, want %+v", stored, m)
+	}
+
+	// Backups within the same second still get their own ID.
+	second := createBackup(t, c)
+	if second.ID == m.ID {
+		t.Errorf("second backup reused ID %s", m.ID)
+	}
+}
+
+func TestCreateNoWorld(t *testing.T) {
+	c := newTestClient(t)
+
+	_, err := c.Create(context.Background(), &Manifest{ServerID: "i-123", ServerName: testServer, Trigger: TriggerManual})
+	if !errors.Is(err, ErrNoWorld) {
+		t.Errorf("Create = %v, want ErrNoWorld", err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     func(m *Manifest)
+		noManifest bool
+		wantErr    bool
+	}{
+		{name: "matching manifest"},
+		{name: "without manifest", noManifest: true},
+		{name: "archive checksum mismatch", tamper: func(m *Manifest) { m.SHA256 = sum("other") }, wantErr: true},
+		{name: "file checksum mismatch", tamper: func(m *Manifest) { m.Files[0].SHA256 = sum("other") }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t)
+			putWorld(t, c, testWorld)
+			m := createBackup(t, c)
+
+			changed := map[string]string{
+				"survival/level.dat": "changed",
+				"survival/extra.dat": "extra",
+			}
+			putWorld(t, c, changed)
+
+			if tt.tamper != nil {
+				tt.tamper(m)
+			}
+			manifest := m
+			if tt.noManifest {
+				manifest = nil
+			}
+
+			err := c.Restore(context.Background(), testServer, m.Archive, "", manifest)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if got := readWorld(t, c); got["survival/level.dat"] != "changed" || got["survival/extra.dat"] != "extra" {
+					t.Errorf("world = %v, want it left alone", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := readWorld(t, c); !reflect.DeepEqual(got, testWorld) {
+				t.Errorf("world = %v, want %v", got, testWorld)
+			}
+		})
+	}
+}
+
+func TestRestoreMissingArchive(t *testing.T) {
+	c := newTestClient(t)
+
+	err := c.Restore(context.Background(), testServer, ArchiveKey("i-123", "missing"), "", nil)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Restore = %v, want ErrNotFound", err)
+	}
+}
+
+func TestVerifyArchive(t *testing.T) {
+	archive := buildArchive(t, [2]string{"level.dat", "level"}, [2]string{"region/r.0.0.mca", "region"})
+	manifest := &Manifest{Files: []File{
+		{Path: "level.dat", SHA256: sum("level")},
+		{Path: "region/r.0.0.mca", SHA256: sum("region")},
+	}}
+
+	tests := []struct {
+		name      string
+		archive   []byte
+		m         *Manifest
+		wantFiles []string
+		wantErr   string
+	}{
+		{name: "without manifest", archive: archive, wantFiles: []string{"level.dat", "region/r.0.0.mca"}},
+		{name: "matching manifest", archive: archive, m: manifest, wantFiles: []string{"level.dat", "region/r.0.0.mca"}},
+		{name: "file not in manifest", archive: buildArchive(t, [2]string{"level.dat", "level"}, [2]string{"extra.dat", "extra"}), m: manifest, wantErr: "extra.dat is not in the manifest"},
+		{name: "checksum mismatch", archive: buildArchive(t, [2]string{"level.dat", "other"}), m: manifest, wantErr: "level.dat does not match its manifest checksum"},
+		{name: "missing file", archive: buildArchive(t, [2]string{"level.dat", "level"}), m: manifest, wantErr: "archive is missing files listed in the manifest"},
+		{name: "path outside world", archive: buildArchive(t, [2]string{"../level.dat", "level"}), wantErr: "invalid path in archive: ../level.dat"},
+		{name: "absolute path", archive: buildArchive(t, [2]string{"/level.dat", "level"}), wantErr: "invalid path in archive: /level.dat"},
+		{name: "not gzip", archive: []byte("not an archive"), wantErr: "invalid archive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := verifyArchive(bytes.NewReader(tt.archive), tt.m)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("verifyArchive = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{}
+			for _, path := range tt.wantFiles {
+				if _, ok := files[path]; ok {
+					got = append(got, path)
+				}
+			}
+			if len(files) != len(tt.wantFiles) || !reflect.DeepEqual(got, tt.wantFiles) {
+				t.Errorf("verifyArchive = %v, want %v", files, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	putWorld(t, c, testWorld)
+	m := createBackup(t, c)
+	kept := createBackup(t, c)
+
+	err := c.Delete(ctx, "i-123", m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Get(ctx, "i-123", m.ID)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	_, err = c.Storage.Get(ctx, c.Bucket, m.Archive)
+	if !errors.Is(err, types.ErrObjectNotFound) {
+		t.Errorf("archive after Delete = %v, want it removed", err)
+	}
+
+	backups, err := c.List(ctx, "i-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || backups[0].ID != kept.ID {
+		t.Errorf("List after Delete = %v, want only %s", ids(backups), kept.ID)
+	}
+
+	err = c.Delete(ctx, "i-123", m.ID)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,350 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/anvil"
+	"github.com/hnucamendi/creeper-keeper/minecraft/level"
+	"github.com/hnucamendi/creeper-keeper/minecraft/nbt"
+	"github.com/hnucamendi/creeper-keeper/types"
+)
+
+// ChunkPruneReport compares a pruned backup with its source.
+type ChunkPruneReport struct {
+	SourceID      string `json:"sourceID"`
+	BackupID      string `json:"backupID"`
+	ChunksBefore  int    `json:"chunksBefore"`
+	ChunksRemoved int    `json:"chunksRemoved"`
+	SizeBefore    int64  `json:"sizeBefore"`
+	SizeAfter     int64  `json:"sizeAfter"`
+}
+
+// chunkInfo is what the filter needs to know about a chunk.
+type chunkInfo struct {
+	index     int
+	x         int
+	z         int
+	inhabited int64
+}
+
+// PruneChunks writes a new backup from src without the chunks the filter
+// selects, dropping their entities and points of interest with them. The
+// source backup is not changed. The new archive is read back and every
+// region in it parsed before its manifest is stored, so a backup that
+// exists is one that loads.
+func (c *Client) PruneChunks(ctx context.Context, src *Manifest, filter *types.ChunkFilter) (*Manifest, *ChunkPruneReport, error) {
+	tmp, err := c.download(ctx, src.Archive, "", src)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	regions := map[string][]chunkInfo{}
+	var spawn *level.Spawn
+	err = eachFile(tmp, func(hdr *tar.Header, r io.Reader) error {
+		if hdr.Name == src.Level()+"/level.dat" {
+			info, err := level.Read(r)
+			if err != nil {
+				return err
+			}
+			spawn = &info.Spawn
+			return nil
+		}
+
+		f, ok := regionFile(src.Level(), hdr.Name)
+		if !ok || f.Folder != anvil.FolderRegion {
+			return nil
+		}
+
+		chunks, err := readChunkInfo(r, hdr.Size, f)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+		}
+		regions[regionKey(f)] = chunks
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if filter.Radius > 0 && spawn == nil {
+		return nil, nil, errors.New("backup has no level.dat to find spawn from")
+	}
+
+	report := &ChunkPruneReport{SourceID: src.ID, SizeBefore: src.Size}
+	drop := map[string]map[int]bool{}
+	for key, chunks := range regions {
+		overworld := strings.HasPrefix(key, anvil.Overworld+"/")
+		report.ChunksBefore += len(chunks)
+		for _, ch := range chunks {
+			if !pruneChunk(ch, filter, spawn, overworld) {
+				continue
+			}
+			if drop[key] == nil {
+				drop[key] = map[int]bool{}
+			}
+			drop[key][ch.index] = true
+			report.ChunksRemoved++
+		}
+	}
+
+	m := &Manifest{
+		ServerID:   src.ServerID,
+		ServerName: src.ServerName,
+		MCVersion:  src.MCVersion,
+		LevelName:  src.LevelName,
+		Trigger:    TriggerChunkPrune,
+		SourceID:   src.ID,
+	}
+	err = c.assignID(ctx, m)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aw, err := newArchiveWriter(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer aw.close()
+
+	err = eachFile(tmp, func(hdr *tar.Header, r io.Reader) error {
+		f, ok := regionFile(src.Level(), hdr.Name)
+		if !ok || len(drop[regionKey(f)]) == 0 {
+			return aw.add(hdr.Name, hdr.Size, hdr.ModTime, r)
+		}
+
+		b, err := rewriteRegion(r, hdr.Size, drop[regionKey(f)])
+		if err != nil {
+			return fmt.Errorf("failed to prune %s: %w", hdr.Name, err)
+		}
+		if len(b) == 0 {
+			// Every chunk is gone, the game regenerates the region
+			return nil
+		}
+		return aw.add(hdr.Name, int64(len(b)), hdr.ModTime, bytes.NewReader(b))
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = aw.upload(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = c.verifyPruned(ctx, m, report.ChunksBefore-report.ChunksRemoved)
+	if err != nil {
+		if derr := c.Storage.Delete(ctx, c.Bucket, m.Archive); derr != nil {
+			err = fmt.Errorf("%w, and failed to remove the archive: %v", err, derr)
+		}
+		return nil, nil, fmt.Errorf("pruned backup failed verification: %w", err)
+	}
+
+	err = c.putManifest(ctx, m)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report.BackupID = m.ID
+	report.SizeAfter = m.Size
+	return m, report, nil
+}
+
+// verifyPruned downloads the pruned archive, checks it against its manifest
+// and parses every region and chunk in it.
+func (c *Client) verifyPruned(ctx context.Context, m *Manifest, wantChunks int) error {
+	tmp, err := c.download(ctx, m.Archive, "", m)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	_, err = verifyArchive(tmp, m)
+	if err != nil {
+		return err
+	}
+
+	chunks := 0
+	err = eachFile(tmp, func(hdr *tar.Header, r io.Reader) error {
+		if hdr.Name == m.Level()+"/level.dat" {
+			_, err := level.Read(r)
+			return err
+		}
+
+		f, ok := regionFile(m.Level(), hdr.Name)
+		if !ok {
+			return nil
+		}
+
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		region, err := anvil.ReadRegion(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			return fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+
+		for _, ch := range region.Chunks {
+			if !decodable(ch) {
+				continue
+			}
+			_, err = anvil.ReadChunk(bytes.NewReader(b), ch)
+			if err != nil {
+				return fmt.Errorf("%s: chunk %d,%d: %w", hdr.Name, ch.X, ch.Z, err)
+			}
+		}
+
+		if f.Folder == anvil.FolderRegion {
+			chunks += len(region.Chunks)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if chunks != wantChunks {
+		return fmt.Errorf("archive has %d chunks, expected %d", chunks, wantChunks)
+	}
+	return nil
+}
+
+func readChunkInfo(r io.Reader, size int64, f *anvil.File) ([]chunkInfo, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := anvil.ReadRegion(bytes.NewReader(b), size)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := []chunkInfo{}
+	for _, ch := range region.Chunks {
+		info := chunkInfo{
+			index: ch.Index(),
+			x:     f.X*anvil.RegionChunks + ch.X,
+			z:     f.Z*anvil.RegionChunks + ch.Z,
+			// Chunks that cannot be read are never pruned
+			inhabited: math.MaxInt64,
+		}
+
+		if decodable(ch) {
+			root, err := anvil.ReadChunk(bytes.NewReader(b), ch)
+			if err != nil {
+				return nil, fmt.Errorf("chunk %d,%d: %w", ch.X, ch.Z, err)
+			}
+			info.inhabited = inhabitedTime(root)
+		}
+		chunks = append(chunks, info)
+	}
+	return chunks, nil
+}
+
+// inhabitedTime reads InhabitedTime, which moved from the Level compound to
+// the chunk root in 1.18.
+func inhabitedTime(root nbt.Compound) int64 {
+	if root.Has("InhabitedTime") {
+		return root.Int("InhabitedTime")
+	}
+	return root.Compound("Level").Int("InhabitedTime")
+}
+
+func pruneChunk(ch chunkInfo, filter *types.ChunkFilter, spawn *level.Spawn, overworld bool) bool {
+	if ch.inhabited == math.MaxInt64 {
+		return false
+	}
+
+	if filter.MinInhabitedTicks > 0 && ch.inhabited < filter.MinInhabitedTicks {
+		return true
+	}
+
+	if filter.Radius > 0 && overworld {
+		dx := float64(int64(ch.x)*16 + 8 - spawn.X)
+		dz := float64(int64(ch.z)*16 + 8 - spawn.Z)
+		return math.Hypot(dx, dz) > float64(filter.Radius)
+	}
+	return false
+}
+
+func rewriteRegion(r io.Reader, size int64, drop map[int]bool) ([]byte, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := anvil.ReadRegion(bytes.NewReader(b), size)
+	if err != nil {
+		return nil, err
+	}
+
+	return anvil.Rewrite(bytes.NewReader(b), region, func(ch anvil.Chunk) bool {
+		return drop[ch.Index()]
+	})
+}
+
+// decodable reports whether a chunk can be parsed here. External and LZ4
+// chunks are carried over untouched.
+func decodable(ch anvil.Chunk) bool {
+	if ch.External {
+		return false
+	}
+	switch ch.Compression {
+	case anvil.CompressionGzip, anvil.CompressionZlib, anvil.CompressionUncompressed:
+		return true
+	default:
+		return false
+	}
+}
+
+// regionKey matches a region's entities and poi files with its block data.
+func regionKey(f *anvil.File) string {
+	return f.Dimension + "/" + strconv.Itoa(f.X) + "," + strconv.Itoa(f.Z)
+}
+
+// eachFile calls fn for every regular file in a tar.gz.
+func eachFile(r io.ReadSeeker, fn func(hdr *tar.Header, r io.Reader) error) error {
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("invalid archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		err = fn(hdr, tr)
+		if err != nil {
+			return err
+		}
+	}
+}
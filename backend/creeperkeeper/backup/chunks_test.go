@@ -0,0 +1,38 @@
+package backup
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/level"
+	"github.com/hnucamendi/creeper-keeper/types"
+)
+
+func TestPruneChunk(t *testing.T) {
+	spawn := &level.Spawn{X: 8, Z: 8}
+
+	tests := []struct {
+		name      string
+		chunk     chunkInfo
+		filter    types.ChunkFilter
+		overworld bool
+		want      bool
+	}{
+		{name: "barely visited", chunk: chunkInfo{inhabited: 10}, filter: types.ChunkFilter{MinInhabitedTicks: 100}, want: true},
+		{name: "visited", chunk: chunkInfo{inhabited: 100}, filter: types.ChunkFilter{MinInhabitedTicks: 100}},
+		{name: "undecodable", chunk: chunkInfo{inhabited: math.MaxInt64}, filter: types.ChunkFilter{MinInhabitedTicks: 100, Radius: 1}, overworld: true},
+		// Chunk 4,0 is centred 64 blocks east of spawn.
+		{name: "inside radius", chunk: chunkInfo{x: 4, inhabited: 1000}, filter: types.ChunkFilter{Radius: 64}, overworld: true},
+		{name: "outside radius", chunk: chunkInfo{x: 4, inhabited: 1000}, filter: types.ChunkFilter{Radius: 63}, overworld: true, want: true},
+		{name: "negative coordinates", chunk: chunkInfo{x: -5, z: -5, inhabited: 1000}, filter: types.ChunkFilter{Radius: 100}, overworld: true, want: true},
+		{name: "radius outside overworld", chunk: chunkInfo{x: 100, inhabited: 1000}, filter: types.ChunkFilter{Radius: 64}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pruneChunk(tt.chunk, &tt.filter, spawn, tt.overworld)
+			if got != tt.want {
+				t.Errorf("pruneChunk = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
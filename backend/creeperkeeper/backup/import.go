@@ -0,0 +1,256 @@
+package backup
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const (
+	// DefaultLevelName is the world directory the server loads from its data
+	// directory when server.properties does not set level-name.
+	DefaultLevelName string = "world"
+
+	uploadPrefix  string = "uploads/"
+	uploadSuffix  string = ".zip"
+	maxImportSize uint64 = 16 << 30
+
+	tagCompound byte = 0x0a
+)
+
+var ErrInvalidWorld = errors.New("invalid world archive")
+
+// UploadKey is where a user supplied world zip is uploaded before import.
+// Uploads are expired by a lifecycle rule on the bucket.
+func UploadKey(serverID string, uploadID string) string {
+	return uploadPrefix + serverID + "/" + uploadID + uploadSuffix
+}
+
+// NewUploadID returns an ID for an upload, in the same format as backup IDs.
+func NewUploadID() string {
+	return time.Now().UTC().Format(backupIDFormat)
+}
+
+// Import is a validated world zip, with each file mapped to its path in the
+// normalized world directory.
+type Import struct {
+	Files            int    `json:"files"`
+	UncompressedSize uint64 `json:"uncompressedSize"`
+
+	file  *os.File
+	files map[string]*zip.File
+}
+
+// OpenImport downloads a world zip and checks it holds a world: a readable
+// level.dat with a region folder beside it. Close the import when done.
+func (c *Client) OpenImport(ctx context.Context, key string) (*Import, error) {
+	body, err := c.Storage.Get(ctx, c.Bucket, key)
+	if errors.Is(err, types.ErrObjectNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	tmp, err := os.CreateTemp("", "import-*"+uploadSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &Import{file: tmp}
+	size, err := io.Copy(tmp, body)
+	if err != nil {
+		imp.Close()
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		imp.Close()
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWorld, err)
+	}
+
+	imp.files, err = planImport(zr.File)
+	if err != nil {
+		imp.Close()
+		return nil, err
+	}
+
+	for _, f := range imp.files {
+		imp.Files++
+		imp.UncompressedSize += f.UncompressedSize64
+	}
+	if imp.UncompressedSize > maxImportSize {
+		imp.Close()
+		return nil, fmt.Errorf("%w: world is larger than %d bytes", ErrInvalidWorld, maxImportSize)
+	}
+
+	return imp, nil
+}
+
+// Stage replaces the server's world directory in the bucket with the
+// imported world, so it is synced onto the instance on the next start.
+func (c *Client) Stage(ctx context.Context, serverName string, imp *Import) error {
+	levelName, err := c.LevelName(ctx, serverName)
+	if err != nil {
+		return err
+	}
+	worldPrefix := WorldPrefix(serverName) + levelName + "/"
+
+	for rel, f := range imp.files {
+		r, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+
+		err = c.putFile(ctx, worldPrefix+rel, r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("failed to stage %s: %w", rel, err)
+		}
+	}
+
+	objects, err := c.Storage.List(ctx, c.Bucket, worldPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to list world: %w", err)
+	}
+
+	for _, o := range objects {
+		key := utils.ToString(o.Key)
+		if _, ok := imp.files[strings.TrimPrefix(key, worldPrefix)]; ok {
+			continue
+		}
+
+		err = c.Storage.Delete(ctx, c.Bucket, key)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (imp *Import) Close() error {
+	if imp.file == nil {
+		return nil
+	}
+	imp.file.Close()
+	return os.Remove(imp.file.Name())
+}
+
+// planImport finds the world in a zip and maps its files to the layout the
+// container expects: level.dat at the top of the world directory, with the
+// Nether and End in DIM-1 and DIM1. Worlds from Bukkit style servers keep
+// those dimensions in sibling world_nether and world_the_end directories,
+// which are folded back in. Anything outside the world is dropped.
+func planImport(entries []*zip.File) (map[string]*zip.File, error) {
+	var levelDat *zip.File
+	for _, f := range entries {
+		if skipImport(f) || path.Base(f.Name) != "level.dat" {
+			continue
+		}
+		if levelDat == nil || strings.Count(f.Name, "/") < strings.Count(levelDat.Name, "/") {
+			levelDat = f
+		}
+	}
+
+	if levelDat == nil {
+		return nil, fmt.Errorf("%w: no level.dat found", ErrInvalidWorld)
+	}
+
+	err := checkLevelDat(levelDat)
+	if err != nil {
+		return nil, err
+	}
+
+	root := path.Dir(levelDat.Name)
+	roots := map[string]string{}
+	if root == "." {
+		roots[""] = ""
+	} else {
+		roots[root+"/"] = ""
+		roots[root+"_nether/DIM-1/"] = "DIM-1/"
+		roots[root+"_the_end/DIM1/"] = "DIM1/"
+	}
+
+	files := map[string]*zip.File{}
+	hasRegion := false
+	for _, f := range entries {
+		if skipImport(f) {
+			continue
+		}
+
+		for from, to := range roots {
+			if !strings.HasPrefix(f.Name, from) {
+				continue
+			}
+
+			rel := to + strings.TrimPrefix(f.Name, from)
+			if !fs.ValidPath(rel) {
+				return nil, fmt.Errorf("%w: invalid path %s", ErrInvalidWorld, f.Name)
+			}
+			if _, ok := files[rel]; ok {
+				continue
+			}
+
+			files[rel] = f
+			if path.Dir(rel) == "region" && path.Ext(rel) == ".mca" {
+				hasRegion = true
+			}
+			break
+		}
+	}
+
+	if !hasRegion {
+		return nil, fmt.Errorf("%w: no region files beside level.dat", ErrInvalidWorld)
+	}
+	return files, nil
+}
+
+// skipImport drops directories and files that are never part of a world.
+func skipImport(f *zip.File) bool {
+	if f.FileInfo().IsDir() {
+		return true
+	}
+
+	name := f.Name
+	base := path.Base(name)
+	return strings.HasPrefix(name, "__MACOSX/") ||
+		strings.Contains(name, "/__MACOSX/") ||
+		base == ".DS_Store" ||
+		base == "session.lock"
+}
+
+// checkLevelDat makes sure level.dat is gzipped NBT with a compound at its
+// root, which is what the game reads.
+func checkLevelDat(f *zip.File) error {
+	r, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWorld, err)
+	}
+	defer r.Close()
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("%w: level.dat is not gzipped: %v", ErrInvalidWorld, err)
+	}
+	defer gz.Close()
+
+	tag, err := bufio.NewReader(gz).ReadByte()
+	if err != nil || tag != tagCompound {
+		return fmt.Errorf("%w: level.dat is not NBT", ErrInvalidWorld)
+	}
+	return nil
+}
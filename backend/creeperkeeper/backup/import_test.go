@@ -0,0 +1,159 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"path"
+	"reflect"
+	"testing"
+)
+
+// levelDat is a gzipped level.dat holding an empty root compound.
+func levelDat(t *testing.T) string {
+	t.Helper()
+	b := &bytes.Buffer{}
+	gz := gzip.NewWriter(b)
+	gz.Write([]byte{tagCompound, 0, 0, 0})
+	gz.Close()
+	return b.String()
+}
+
+// zipEntries writes a zip of the named files, with a valid level.dat for
+// each one named level.dat, and returns its entries.
+func zipEntries(t *testing.T, names ...string) []*zip.File {
+	t.Helper()
+	b := &bytes.Buffer{}
+	zw := zip.NewWriter(b)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := "data"
+		if path.Base(name) == "level.dat" {
+			content = levelDat(t)
+		}
+		w.Write([]byte(content))
+	}
+	err := zw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries outside the zip's root are reported, planImport rejects them
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil && !errors.Is(err, zip.ErrInsecurePath) {
+		t.Fatal(err)
+	}
+	return zr.File
+}
+
+func TestPlanImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		// want maps each path in the world directory to its entry.
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "root level world",
+			entries: []string{"level.dat", "region/r.0.0.mca", "DIM-1/region/r.-1.0.mca", "icon.png"},
+			want: map[string]string{
+				"level.dat":               "level.dat",
+				"region/r.0.0.mca":        "region/r.0.0.mca",
+				"DIM-1/region/r.-1.0.mca": "DIM-1/region/r.-1.0.mca",
+				"icon.png":                "icon.png",
+			},
+		},
+		{
+			name:    "nested world",
+			entries: []string{"README.txt", "saves/MyWorld/level.dat", "saves/MyWorld/region/r.0.0.mca", "saves/MyWorld/data/raids.dat", "saves/MyWorld/backup/level.dat"},
+			want: map[string]string{
+				"level.dat":        "saves/MyWorld/level.dat",
+				"region/r.0.0.mca": "saves/MyWorld/region/r.0.0.mca",
+				"data/raids.dat":   "saves/MyWorld/data/raids.dat",
+				"backup/level.dat": "saves/MyWorld/backup/level.dat",
+			},
+		},
+		{
+			name: "bukkit dimensions",
+			entries: []string{
+				"world/level.dat", "world/region/r.0.0.mca",
+				"world_nether/level.dat", "world_nether/DIM-1/region/r.-1.0.mca",
+				"world_the_end/level.dat", "world_the_end/DIM1/region/r.0.0.mca",
+				"plugins/config.yml",
+			},
+			want: map[string]string{
+				"level.dat":               "world/level.dat",
+				"region/r.0.0.mca":        "world/region/r.0.0.mca",
+				"DIM-1/region/r.-1.0.mca": "world_nether/DIM-1/region/r.-1.0.mca",
+				"DIM1/region/r.0.0.mca":   "world_the_end/DIM1/region/r.0.0.mca",
+			},
+		},
+		{
+			name: "macos metadata",
+			entries: []string{
+				"__MACOSX/level.dat", "__MACOSX/world/._level.dat",
+				"world/level.dat", "world/region/r.0.0.mca",
+				"world/__MACOSX/._r.0.0.mca", "world/.DS_Store", "world/session.lock",
+			},
+			want: map[string]string{
+				"level.dat":        "world/level.dat",
+				"region/r.0.0.mca": "world/region/r.0.0.mca",
+			},
+		},
+		{name: "path outside root level world", entries: []string{"level.dat", "region/r.0.0.mca", "../escape.txt"}, wantErr: true},
+		{name: "path outside nested world", entries: []string{"world/level.dat", "world/region/r.0.0.mca", "world/../../escape.txt"}, wantErr: true},
+		{name: "no region folder", entries: []string{"world/level.dat", "world/data/raids.dat", "region/r.0.0.mca"}, wantErr: true},
+		{name: "region file in a subfolder", entries: []string{"level.dat", "region/old/r.0.0.mca"}, wantErr: true},
+		{name: "no level.dat", entries: []string{"world/region/r.0.0.mca"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := planImport(zipEntries(t, tt.entries...))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidWorld) {
+					t.Fatalf("planImport = %v, want ErrInvalidWorld", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]string{}
+			for rel, f := range files {
+				got[rel] = f.Name
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planImport = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanImportInvalidLevelDat(t *testing.T) {
+	b := &bytes.Buffer{}
+	zw := zip.NewWriter(b)
+	for _, name := range []string{"level.dat", "region/r.0.0.mca"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("not nbt"))
+	}
+	zw.Close()
+
+	zr, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = planImport(zr.File)
+	if !errors.Is(err, ErrInvalidWorld) {
+		t.Errorf("planImport = %v, want ErrInvalidWorld", err)
+	}
+}
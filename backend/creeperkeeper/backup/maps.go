@@ -0,0 +1,232 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/anvil"
+	"github.com/hnucamendi/creeper-keeper/minecraft/render"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const (
+	mapDir     string = "/map/"
+	mapIndex   string = "index.json"
+	tileSuffix string = ".png"
+	netherRoof int    = 128
+)
+
+// MapTile is a rendered region of a backup's world.
+type MapTile struct {
+	X       int    `json:"x"`
+	Z       int    `json:"z"`
+	Key     string `json:"key"`
+	Chunks  int    `json:"chunks"`
+	Skipped int    `json:"skipped,omitempty"`
+	// URL is filled in when the index is served.
+	URL string `json:"url,omitempty"`
+}
+
+// MapDimension holds the tiles of one dimension. Tiles are TileSize pixels
+// square, one pixel per block, and tile X,Z covers blocks X*TileSize to
+// (X+1)*TileSize-1.
+type MapDimension struct {
+	Dimension string    `json:"dimension"`
+	Tiles     []MapTile `json:"tiles"`
+}
+
+// MapIndex lists the tiles rendered for a backup.
+type MapIndex struct {
+	BackupID    string         `json:"backupID"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+	TileSize    int            `json:"tileSize"`
+	Dimensions  []MapDimension `json:"dimensions"`
+	Errors      []string       `json:"errors,omitempty"`
+}
+
+// MapPrefix is where a backup's map tiles are stored, next to its archive.
+func MapPrefix(serverID string, backupID string) string {
+	return prefix + serverID + "/" + backupID + mapDir
+}
+
+// MapTileKey names the tile of a region. The dimension's namespace becomes
+// a directory, so minecraft:overworld tiles are under minecraft/overworld/.
+func MapTileKey(serverID string, backupID string, dimension string, x int, z int) string {
+	return MapPrefix(serverID, backupID) + strings.Replace(dimension, ":", "/", 1) + "/" + strconv.Itoa(x) + "." + strconv.Itoa(z) + tileSuffix
+}
+
+func MapIndexKey(serverID string, backupID string) string {
+	return MapPrefix(serverID, backupID) + mapIndex
+}
+
+// RenderMap draws a top-down tile of every region in a backup and stores
+// them with an index. progress is called after each region with how many
+// of the backup's regions have been drawn. Regions that cannot be read are
+// reported in the index and left out.
+func (c *Client) RenderMap(ctx context.Context, m *Manifest, progress func(done int, total int)) (*MapIndex, error) {
+	total := 0
+	for _, f := range m.Files {
+		if rf, ok := regionFile(m.Level(), f.Path); ok && rf.Folder == anvil.FolderRegion {
+			total++
+		}
+	}
+
+	tmp, err := c.download(ctx, m.Archive, "", m)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	index := &MapIndex{
+		BackupID:    m.ID,
+		GeneratedAt: time.Now().UTC(),
+		TileSize:    render.TileSize,
+	}
+	dimensions := map[string]*MapDimension{}
+
+	done := 0
+	err = eachFile(tmp, func(hdr *tar.Header, r io.Reader) error {
+		f, ok := regionFile(m.Level(), hdr.Name)
+		if !ok || f.Folder != anvil.FolderRegion {
+			return nil
+		}
+
+		tile, err := renderRegion(r, hdr.Size, f.Dimension)
+		done++
+		if err != nil {
+			index.Errors = append(index.Errors, fmt.Sprintf("%s: %v", hdr.Name, err))
+			progress(done, total)
+			return nil
+		}
+
+		key := MapTileKey(m.ServerID, m.ID, f.Dimension, f.X, f.Z)
+		err = c.putTile(ctx, key, tile)
+		if err != nil {
+			return err
+		}
+
+		dim := dimensions[f.Dimension]
+		if dim == nil {
+			dim = &MapDimension{Dimension: f.Dimension, Tiles: []MapTile{}}
+			dimensions[f.Dimension] = dim
+		}
+		dim.Tiles = append(dim.Tiles, MapTile{
+			X:       f.X,
+			Z:       f.Z,
+			Key:     key,
+			Chunks:  tile.Chunks,
+			Skipped: tile.Skipped,
+		})
+		progress(done, total)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	index.Dimensions = []MapDimension{}
+	for _, dim := range dimensions {
+		sort.Slice(dim.Tiles, func(i, j int) bool {
+			if dim.Tiles[i].Z != dim.Tiles[j].Z {
+				return dim.Tiles[i].Z < dim.Tiles[j].Z
+			}
+			return dim.Tiles[i].X < dim.Tiles[j].X
+		})
+		index.Dimensions = append(index.Dimensions, *dim)
+	}
+	sort.Slice(index.Dimensions, func(i, j int) bool {
+		return index.Dimensions[i].Dimension < index.Dimensions[j].Dimension
+	})
+
+	b, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	err = c.Storage.Put(ctx, c.Bucket, MapIndexKey(m.ServerID, m.ID), bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload map index: %w", err)
+	}
+	return index, nil
+}
+
+// GetMap returns the index of a backup's rendered map.
+func (c *Client) GetMap(ctx context.Context, serverID string, backupID string) (*MapIndex, error) {
+	key := MapIndexKey(serverID, backupID)
+	body, err := c.Storage.Get(ctx, c.Bucket, key)
+	if errors.Is(err, types.ErrObjectNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	index := &MapIndex{}
+	err = json.NewDecoder(body).Decode(index)
+	if err != nil {
+		return nil, fmt.Errorf("invalid map index %s: %w", key, err)
+	}
+	return index, nil
+}
+
+// deleteMap removes a backup's map tiles and index, if it has any.
+func (c *Client) deleteMap(ctx context.Context, serverID string, backupID string) error {
+	objects, err := c.Storage.List(ctx, c.Bucket, MapPrefix(serverID, backupID))
+	if err != nil {
+		return err
+	}
+
+	for _, o := range objects {
+		err = c.Storage.Delete(ctx, c.Bucket, utils.ToString(o.Key))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) putTile(ctx context.Context, key string, tile *render.Tile) error {
+	b, err := tile.PNG()
+	if err != nil {
+		return err
+	}
+
+	err = c.Storage.Put(ctx, c.Bucket, key, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to upload tile %s: %w", key, err)
+	}
+	return nil
+}
+
+// regionFile parses an archive path under the levelName world directory.
+func regionFile(levelName string, name string) (*anvil.File, bool) {
+	if !strings.HasPrefix(name, levelName+"/") {
+		return nil, false
+	}
+	return anvil.ParsePath(strings.TrimPrefix(name, levelName+"/"))
+}
+
+func renderRegion(r io.Reader, size int64, dimension string) (*render.Tile, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := render.Options{}
+	if dimension == anvil.Nether {
+		opts.Roof = netherRoof
+	}
+	return render.RenderRegion(bytes.NewReader(b), size, opts)
+}
@@ -0,0 +1,131 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/types"
+)
+
+// PruneResult lists the backups a policy keeps and those it deletes, or
+// would delete on a dry run.
+type PruneResult struct {
+	ServerID string   `json:"serverID"`
+	DryRun   bool     `json:"dryRun"`
+	Kept     []string `json:"kept"`
+	Deleted  []string `json:"deleted"`
+}
+
+// retentionPeriods maps each tier of a policy to the period a backup falls
+// in for that tier.
+var retentionPeriods = []struct {
+	count  func(*types.RetentionPolicy) int
+	period func(time.Time) string
+}{
+	{
+		count:  func(p *types.RetentionPolicy) int { return p.Hourly },
+		period: func(t time.Time) string { return t.Format("2006-01-02T15") },
+	},
+	{
+		count:  func(p *types.RetentionPolicy) int { return p.Daily },
+		period: func(t time.Time) string { return t.Format(time.DateOnly) },
+	},
+	{
+		count: func(p *types.RetentionPolicy) int { return p.Weekly },
+		period: func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		},
+	},
+	{
+		count:  func(p *types.RetentionPolicy) int { return p.Monthly },
+		period: func(t time.Time) string { return t.Format("2006-01") },
+	},
+}
+
+// Retain splits backups into those kept by the policy and those to prune.
+// The newest backup is always kept. Both are returned newest first.
+func Retain(manifests []Manifest, policy *types.RetentionPolicy) ([]Manifest, []Manifest) {
+	sorted := make([]Manifest, len(manifests))
+	copy(sorted, manifests)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	keep := map[string]bool{}
+	if len(sorted) > 0 {
+		keep[sorted[0].ID] = true
+	}
+	for _, m := range sorted {
+		if policy.IsProtected(m.ID) {
+			keep[m.ID] = true
+		}
+	}
+
+	for _, tier := range retentionPeriods {
+		count := tier.count(policy)
+		seen := map[string]bool{}
+		for _, m := range sorted {
+			if len(seen) >= count {
+				break
+			}
+
+			period := tier.period(m.CreatedAt.UTC())
+			if seen[period] {
+				continue
+			}
+			seen[period] = true
+			keep[m.ID] = true
+		}
+	}
+
+	kept := []Manifest{}
+	pruned := []Manifest{}
+	for _, m := range sorted {
+		if keep[m.ID] {
+			kept = append(kept, m)
+			continue
+		}
+		pruned = append(pruned, m)
+	}
+	return kept, pruned
+}
+
+// Prune deletes the server's backups that its policy does not keep. With
+// dryRun set nothing is deleted. An invalid policy, such as one saved before
+// policies were validated, prunes nothing.
+func (c *Client) Prune(ctx context.Context, serverID string, policy *types.RetentionPolicy, dryRun bool) (*PruneResult, error) {
+	err := policy.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	manifests, err := c.List(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	kept, pruned := Retain(manifests, policy)
+	result := &PruneResult{
+		ServerID: serverID,
+		DryRun:   dryRun,
+		Kept:     []string{},
+		Deleted:  []string{},
+	}
+	for _, m := range kept {
+		result.Kept = append(result.Kept, m.ID)
+	}
+
+	for _, m := range pruned {
+		if !dryRun {
+			err = c.Delete(ctx, serverID, m.ID)
+			if err != nil {
+				return result, fmt.Errorf("failed to delete backup %s: %w", m.ID, err)
+			}
+		}
+		result.Deleted = append(result.Deleted, m.ID)
+	}
+	return result, nil
+}
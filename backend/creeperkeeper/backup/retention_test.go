@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/types"
+)
+
+func TestRetain(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	// Newest first: two in one hour, another that day, one the day before,
+	// one in the previous ISO week and one in the previous month.
+	manifests := []Manifest{
+		{ID: "a", CreatedAt: at("2025-03-10T12:40:00Z")},
+		{ID: "b", CreatedAt: at("2025-03-10T12:10:00Z")},
+		{ID: "c", CreatedAt: at("2025-03-10T09:00:00Z")},
+		{ID: "d", CreatedAt: at("2025-03-09T23:30:00Z")},
+		{ID: "e", CreatedAt: at("2025-03-03T08:00:00Z")},
+		{ID: "f", CreatedAt: at("2025-02-27T08:00:00Z")},
+	}
+
+	tests := []struct {
+		name   string
+		policy types.RetentionPolicy
+		kept   []string
+		pruned []string
+	}{
+		{
+			name:   "hourly buckets by hour",
+			policy: types.RetentionPolicy{Hourly: 2},
+			kept:   []string{"a", "c"},
+			pruned: []string{"b", "d", "e", "f"},
+		},
+		{
+			name:   "daily buckets by UTC day",
+			policy: types.RetentionPolicy{Daily: 2},
+			kept:   []string{"a", "d"},
+			pruned: []string{"b", "c", "e", "f"},
+		},
+		{
+			// 2025-03-09 is a Sunday, so it shares the week of 03-03.
+			name:   "weekly buckets by ISO week",
+			policy: types.RetentionPolicy{Weekly: 3},
+			kept:   []string{"a", "d", "f"},
+			pruned: []string{"b", "c", "e"},
+		},
+		{
+			name:   "monthly buckets by month",
+			policy: types.RetentionPolicy{Monthly: 12},
+			kept:   []string{"a", "f"},
+			pruned: []string{"b", "c", "d", "e"},
+		},
+		{
+			name:   "tiers combine",
+			policy: types.RetentionPolicy{Hourly: 1, Monthly: 2},
+			kept:   []string{"a", "f"},
+			pruned: []string{"b", "c", "d", "e"},
+		},
+		{
+			name:   "protected are kept",
+			policy: types.RetentionPolicy{Hourly: 1, Protected: []string{"e"}},
+			kept:   []string{"a", "e"},
+			pruned: []string{"b", "c", "d", "f"},
+		},
+		{
+			name:   "newest is always kept",
+			policy: types.RetentionPolicy{},
+			kept:   []string{"a"},
+			pruned: []string{"b", "c", "d", "e", "f"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Retain sorts, so the order it is given does not matter.
+			shuffled := slices.Clone(manifests)
+			slices.Reverse(shuffled)
+
+			kept, pruned := Retain(shuffled, &tt.policy)
+			if got := ids(kept); !slices.Equal(got, tt.kept) {
+				t.Errorf("kept %v, want %v", got, tt.kept)
+			}
+			if got := ids(pruned); !slices.Equal(got, tt.pruned) {
+				t.Errorf("pruned %v, want %v", got, tt.pruned)
+			}
+		})
+	}
+}
+
+func TestRetainEmpty(t *testing.T) {
+	kept, pruned := Retain(nil, &types.RetentionPolicy{Daily: 7})
+	if len(kept) != 0 || len(pruned) != 0 {
+		t.Errorf("Retain(nil) = %v, %v, want nothing", kept, pruned)
+	}
+}
+
+func ids(manifests []Manifest) []string {
+	out := []string{}
+	for _, m := range manifests {
+		out = append(out, m.ID)
+	}
+	return out
+}
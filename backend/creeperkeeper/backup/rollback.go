@@ -0,0 +1,231 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/anvil"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+// Box is an inclusive block coordinate range.
+type Box struct {
+	MinX int
+	MinZ int
+	MaxX int
+	MaxZ int
+}
+
+// containsChunk reports whether any block of the chunk is in the box.
+func (b Box) containsChunk(cx int, cz int) bool {
+	return cx*16+15 >= b.MinX && cx*16 <= b.MaxX && cz*16+15 >= b.MinZ && cz*16 <= b.MaxZ
+}
+
+type RollbackReport struct {
+	AsOf  time.Time `json:"asOf"`
+	Files []string  `json:"files"`
+	// ChunksRestored were copied from the old version, ChunksRemoved did
+	// not exist then and were dropped so the game generates them again.
+	ChunksRestored int `json:"chunksRestored"`
+	ChunksRemoved  int `json:"chunksRemoved"`
+}
+
+var rollbackFolders = []string{anvil.FolderRegion, anvil.FolderEntities, anvil.FolderPOI}
+
+// Rollback replaces the chunks in box with the versions stored as of asOf,
+// leaving the rest of each region file as it is now. Entities and points of
+// interest are rolled back with the blocks. When versionID is set it names
+// a version of one of the box's region files and asOf is taken from it.
+func (c *Client) Rollback(ctx context.Context, serverName string, dimension string, box Box, asOf time.Time, versionID string) (*RollbackReport, error) {
+	dir, ok := anvil.DimensionDir(dimension)
+	if !ok {
+		return nil, fmt.Errorf("unknown dimension %q", dimension)
+	}
+	levelName, err := c.LevelName(ctx, serverName)
+	if err != nil {
+		return nil, err
+	}
+	dimPrefix := WorldPrefix(serverName) + levelName + "/" + dir
+
+	minRX, maxRX := floorDiv(box.MinX, anvil.RegionBlocks), floorDiv(box.MaxX, anvil.RegionBlocks)
+	minRZ, maxRZ := floorDiv(box.MinZ, anvil.RegionBlocks), floorDiv(box.MaxZ, anvil.RegionBlocks)
+
+	if versionID != "" {
+		var err error
+		asOf, err = c.versionTime(ctx, dimPrefix+anvil.FolderRegion+"/", versionID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report := &RollbackReport{AsOf: asOf, Files: []string{}}
+	for rx := minRX; rx <= maxRX; rx++ {
+		for rz := minRZ; rz <= maxRZ; rz++ {
+			for _, folder := range rollbackFolders {
+				key := dimPrefix + folder + "/r." + strconv.Itoa(rx) + "." + strconv.Itoa(rz) + ".mca"
+				restored, removed, changed, err := c.rollbackRegion(ctx, key, rx, rz, box, asOf)
+				if err != nil {
+					return nil, fmt.Errorf("failed to roll back %s: %w", key, err)
+				}
+				if !changed {
+					continue
+				}
+
+				report.Files = append(report.Files, key)
+				if folder == anvil.FolderRegion {
+					report.ChunksRestored += restored
+					report.ChunksRemoved += removed
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+// versionTime finds when a region file version was written.
+func (c *Client) versionTime(ctx context.Context, prefix string, versionID string) (time.Time, error) {
+	versions, err := c.Storage.ListVersions(ctx, c.Bucket, prefix)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, v := range versions {
+		if utils.ToString(v.VersionID) == versionID && !v.DeleteMarker {
+			return v.LastModified, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("version %s is not a region file version of this dimension", versionID)
+}
+
+func (c *Client) rollbackRegion(ctx context.Context, key string, rx int, rz int, box Box, asOf time.Time) (int, int, bool, error) {
+	old, err := c.regionAt(ctx, key, asOf)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	current, err := c.regionAt(ctx, key, time.Time{})
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	if old == nil && current == nil {
+		return 0, 0, false, nil
+	}
+
+	chunks := []anvil.Source{}
+	restored, removed := 0, 0
+	for i := 0; i < anvil.RegionChunks*anvil.RegionChunks; i++ {
+		cx := rx*anvil.RegionChunks + i%anvil.RegionChunks
+		cz := rz*anvil.RegionChunks + i/anvil.RegionChunks
+
+		from := current
+		if box.containsChunk(cx, cz) {
+			from = old
+			if old.chunk(i) != nil {
+				restored++
+			} else if current.chunk(i) != nil {
+				removed++
+			}
+		}
+
+		if ch := from.chunk(i); ch != nil {
+			chunks = append(chunks, anvil.Source{R: from.r, Chunk: *ch})
+		}
+	}
+
+	if restored == 0 && removed == 0 {
+		return 0, 0, false, nil
+	}
+
+	b, err := anvil.Write(chunks)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	if len(b) == 0 {
+		err = c.Storage.Delete(ctx, c.Bucket, key)
+	} else {
+		err = c.Storage.Put(ctx, c.Bucket, key, bytes.NewReader(b))
+	}
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return restored, removed, true, nil
+}
+
+// storedRegion is a region file read from storage, indexed by chunk.
+type storedRegion struct {
+	r      io.ReaderAt
+	chunks map[int]*anvil.Chunk
+}
+
+func (s *storedRegion) chunk(i int) *anvil.Chunk {
+	if s == nil {
+		return nil
+	}
+	return s.chunks[i]
+}
+
+// regionAt reads the version of a region file current at asOf, or the
+// current version for a zero asOf. It returns nil if the file did not exist.
+func (c *Client) regionAt(ctx context.Context, key string, asOf time.Time) (*storedRegion, error) {
+	versionID := ""
+	if !asOf.IsZero() {
+		versions, err := c.Storage.ListVersions(ctx, c.Bucket, key)
+		if err != nil {
+			return nil, err
+		}
+
+		var found *types.Object
+		for i := range versions {
+			v := &versions[i]
+			if utils.ToString(v.Key) == key && !v.LastModified.After(asOf) {
+				found = v
+				break
+			}
+		}
+		if found == nil || found.DeleteMarker {
+			return nil, nil
+		}
+		versionID = utils.ToString(found.VersionID)
+	}
+
+	body, err := c.Storage.GetVersion(ctx, c.Bucket, key, versionID)
+	if errors.Is(err, types.ErrObjectNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := anvil.ReadRegion(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &storedRegion{r: bytes.NewReader(b), chunks: map[int]*anvil.Chunk{}}
+	for i := range region.Chunks {
+		s.chunks[region.Chunks[i].Index()] = &region.Chunks[i]
+	}
+	return s, nil
+}
+
+func floorDiv(a int, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
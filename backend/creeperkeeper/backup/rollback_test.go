@@ -0,0 +1,55 @@
+package backup
+
+import "testing"
+
+func TestFloorDiv(t *testing.T) {
+	tests := []struct {
+		a, b int
+		want int
+	}{
+		{a: 0, b: 512, want: 0},
+		{a: 511, b: 512, want: 0},
+		{a: 512, b: 512, want: 1},
+		{a: -1, b: 512, want: -1},
+		{a: -512, b: 512, want: -1},
+		{a: -513, b: 512, want: -2},
+		{a: 7, b: -2, want: -4},
+		{a: -7, b: -2, want: 3},
+	}
+	for _, tt := range tests {
+		got := floorDiv(tt.a, tt.b)
+		if got != tt.want {
+			t.Errorf("floorDiv(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestBoxContainsChunk(t *testing.T) {
+	// Blocks 0 to 31 on each axis, exactly chunks 0 and 1.
+	box := Box{MinX: 0, MinZ: 0, MaxX: 31, MaxZ: 31}
+	// Blocks -1 to 1, a corner of four chunks.
+	corner := Box{MinX: -1, MinZ: -1, MaxX: 1, MaxZ: 1}
+
+	tests := []struct {
+		name   string
+		box    Box
+		cx, cz int
+		want   bool
+	}{
+		{name: "first chunk", box: box, cx: 0, cz: 0, want: true},
+		{name: "last chunk", box: box, cx: 1, cz: 1, want: true},
+		{name: "past max x", box: box, cx: 2, cz: 0},
+		{name: "before min z", box: box, cx: 0, cz: -1},
+		{name: "corner negative", box: corner, cx: -1, cz: -1, want: true},
+		{name: "corner mixed", box: corner, cx: 0, cz: -1, want: true},
+		{name: "corner outside", box: corner, cx: -2, cz: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.box.containsChunk(tt.cx, tt.cz)
+			if got != tt.want {
+				t.Errorf("containsChunk(%d, %d) = %v, want %v", tt.cx, tt.cz, got, tt.want)
+			}
+		})
+	}
+}
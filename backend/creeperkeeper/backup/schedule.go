@@ -0,0 +1,22 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ParseSchedule parses a standard five field cron expression.
+func ParseSchedule(expr string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup schedule %q: %w", expr, err)
+	}
+	return schedule, nil
+}
+
+// Due reports whether a scheduled backup has come up since last.
+func Due(schedule cron.Schedule, last time.Time, now time.Time) bool {
+	return !schedule.Next(last.UTC()).After(now.UTC())
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/minecraft/slp"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const backupJobRoute string = "/creeperkeeper/jobs/backup"
+
+func (h *Handler) ListBackups(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	backups, err := h.Client.backup.List(r.Context(), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, backups)
+}
+
+// CreateBackup starts backing up the server's world. A running server is
+// backed up online from a fresh sync, a stopped one from the world in S3.
+func (h *Handler) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	h.startOperation(w, r, serverID, backupJobRoute, types.NewOperation(types.OperationBackup, nil))
+}
+
+// BackupJob runs a backup started by CreateBackup or ScheduledBackups,
+// leaving the backup ID in the operation's result. Scheduled backups also
+// record the backup time the schedule counts from.
+func (h *Handler) BackupJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.loadOperation(r)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	server := run.server
+	trigger := backup.TriggerManual
+	if run.op.Params["trigger"] == backup.TriggerScheduled {
+		trigger = backup.TriggerScheduled
+	}
+
+	err = run.step(ctx, "back up world", func() error {
+		var m *backup.Manifest
+		var err error
+		if utils.ToBool(server.IsRunning) {
+			m, err = h.onlineBackup(ctx, server, trigger)
+		} else {
+			m, err = h.Client.backup.Create(ctx, &backup.Manifest{
+				ServerID:   run.serverID,
+				ServerName: utils.ToString(server.Name),
+				MCVersion:  h.worldVersion(ctx, server),
+				Trigger:    trigger,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		run.op.Result = map[string]string{"backupID": m.ID}
+		return nil
+	})
+	if err == nil && trigger == backup.TriggerScheduled {
+		err = run.step(ctx, "record backup time", func() error {
+			return h.Client.db.Client.UpdateServerLastBackup(ctx, utils.ToString(h.Client.db.Table), run.serverID, time.Now().UTC().Format(time.RFC3339))
+		})
+	}
+	run.finish(ctx, err)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, run.op)
+}
+
+func (h *Handler) GetBackup(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	backupID := r.PathValue("backupID")
+	if serverID == "" || backupID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID and backupID must be provided")
+		return
+	}
+
+	m, err := h.Client.backup.Get(r.Context(), serverID, backupID)
+	if errors.Is(err, backup.ErrNotFound) {
+		writeResponse(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, m)
+}
+
+func (h *Handler) DeleteBackup(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	backupID := r.PathValue("backupID")
+	if serverID == "" || backupID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID and backupID must be provided")
+		return
+	}
+
+	err := h.Client.backup.Delete(r.Context(), serverID, backupID)
+	if errors.Is(err, backup.ErrNotFound) {
+		writeResponse(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, "backup deleted")
+}
+
+// PruneServerBackups applies the server's retention policy to its backups.
+// With dryRun=true in the query nothing is deleted.
+func (h *Handler) PruneServerBackups(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Retention == nil {
+		writeResponse(w, r, http.StatusBadRequest, "server has no retention policy: "+serverID)
+		return
+	}
+
+	if server.Operation.Active() {
+		writeResponse(w, r, http.StatusConflict, types.ErrOperationInProgress.Error())
+		return
+	}
+
+	result, err := h.Client.backup.Prune(r.Context(), serverID, prunePolicy(server), r.URL.Query().Get("dryRun") == "true")
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, result)
+}
+
+// prunePolicy is the server's retention policy, also protecting the safety
+// backup of its last operation unless that operation succeeded, since the
+// backup is what undoes a failed restore, import, rollback or upgrade.
+func prunePolicy(server *types.Server) *types.RetentionPolicy {
+	policy := *server.Retention
+	op := server.Operation
+	if op != nil && utils.ToString(op.Status) != types.OperationSucceeded && op.Result["safetyBackupID"] != "" {
+		policy.Protected = append(slices.Clone(policy.Protected), op.Result["safetyBackupID"])
+	}
+	return &policy
+}
+
+// onlineBackup backs up a running server without stopping it. Saving is
+// turned off while the world is flushed and synced to S3 so the copy is
+// consistent, and turned back on as soon as the sync ends, whether or not it
+// succeeded. The archive is then built from the synced copy.
+func (h *Handler) onlineBackup(ctx context.Context, server *types.Server, trigger string) (*backup.Manifest, error) {
+	serverID := utils.ToString(server.ID)
+	serverName := utils.ToString(server.Name)
+
+	_, err := h.Client.console.Client.Exec(ctx, server, "save-off")
+	if err != nil {
+		return nil, fmt.Errorf("failed to turn off saving: %w", err)
+	}
+
+	err = h.syncWorldSaved(ctx, server)
+	// The job's context may already be done, saving must come back on
+	_, saveErr := h.Client.console.Client.Exec(context.WithoutCancel(ctx), server, "save-on")
+	if saveErr != nil {
+		log.Println("failed to turn saving back on for", serverID, saveErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m := &backup.Manifest{
+		ServerID:   serverID,
+		ServerName: serverName,
+		Trigger:    trigger,
+	}
+
+	// The synced level.dat names the version when the server does not answer
+	status, err := slp.Query(ctx, utils.ToString(server.IP), server.GamePort(), slp.DefaultTimeout)
+	if err == nil {
+		m.MCVersion = status.Version.Name
+	} else {
+		m.MCVersion = h.worldVersion(ctx, server)
+	}
+
+	return h.Client.backup.Create(ctx, m)
+}
+
+// syncWorldSaved flushes the world to disk and syncs it to S3.
+func (h *Handler) syncWorldSaved(ctx context.Context, server *types.Server) error {
+	_, err := h.Client.console.Client.Exec(ctx, server, "save-all flush")
+	if err != nil {
+		return fmt.Errorf("failed to save world: %w", err)
+	}
+
+	err = h.run(ctx, utils.ToString(server.ID), []string{syncWorldCommand(utils.ToString(server.Name))})
+	if err != nil {
+		return fmt.Errorf("failed to sync world: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/types"
+)
+
+const pruneChunksJobRoute string = "/creeperkeeper/jobs/prune-chunks"
+
+// PruneChunks starts writing a smaller copy of a backup without the chunks
+// the filter selects. The source backup is left as it is.
+func (h *Handler) PruneChunks(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	backupID := r.PathValue("backupID")
+	if serverID == "" || backupID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID and backupID must be provided")
+		return
+	}
+
+	filter := &types.ChunkFilter{}
+	err := filter.UnmarshallRequest(r.Body)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = filter.Validate()
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, err = h.Client.backup.Get(r.Context(), serverID, backupID)
+	if errors.Is(err, backup.ErrNotFound) {
+		writeResponse(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	params := map[string]string{
+		"backupID":          backupID,
+		"minInhabitedTicks": strconv.FormatInt(filter.MinInhabitedTicks, 10),
+		"radius":            strconv.FormatInt(filter.Radius, 10),
+	}
+	h.startTask(w, r, serverID, types.BackupTask(types.OperationPrune, backupID), pruneChunksJobRoute, types.NewOperation(types.OperationPrune, params))
+}
+
+func (h *Handler) PruneChunksJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.loadOperation(r)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.pruneChunks(r.Context(), run)
+	run.finish(r.Context(), err)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, run.op)
+}
+
+func (h *Handler) pruneChunks(ctx context.Context, run *operationRun) error {
+	params := run.op.Params
+	filter := &types.ChunkFilter{}
+	var err error
+	filter.MinInhabitedTicks, err = strconv.ParseInt(params["minInhabitedTicks"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid minInhabitedTicks: %w", err)
+	}
+	filter.Radius, err = strconv.ParseInt(params["radius"], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid radius: %w", err)
+	}
+
+	var src *backup.Manifest
+	err = run.step(ctx, "load backup", func() error {
+		src, err = h.Client.backup.Get(ctx, run.serverID, params["backupID"])
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return run.step(ctx, "prune chunks", func() error {
+		_, report, err := h.Client.backup.PruneChunks(ctx, src, filter)
+		if err != nil {
+			return err
+		}
+
+		run.op.Result = map[string]string{
+			"backupID":      report.BackupID,
+			"chunksBefore":  strconv.Itoa(report.ChunksBefore),
+			"chunksRemoved": strconv.Itoa(report.ChunksRemoved),
+			"sizeBefore":    strconv.FormatInt(report.SizeBefore, 10),
+			"sizeAfter":     strconv.FormatInt(report.SizeAfter, 10),
+		}
+		return nil
+	})
+}
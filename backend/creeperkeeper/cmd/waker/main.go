@@ -0,0 +1,75 @@
+// Command waker listens on the Minecraft port in front of stopped servers and
+// starts them when a player tries to join.
+//
+//	waker -listen :25565 -config waker.json
+//
+// The config maps the hostnames players connect with to server IDs:
+//
+//	{"default": "i-0123", "servers": {"mc.example.com": "i-0123"}}
+//
+// Server records are read from the API's table, which needs DynamoDB read
+// access, so servers busy with an operation are not woken.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/hnucamendi/creeper-keeper/service/compute"
+	"github.com/hnucamendi/creeper-keeper/service/database"
+	"github.com/hnucamendi/creeper-keeper/waker"
+)
+
+type config struct {
+	Default string            `json:"default"`
+	Servers map[string]string `json:"servers"`
+}
+
+func main() {
+	listen := flag.String("listen", ":25565", "address to accept Minecraft connections on")
+	configPath := flag.String("config", "waker.json", "path to the hostname to server ID mapping")
+	table := flag.String("table", "creeperkeeper", "DynamoDB table holding the server records")
+	flag.Parse()
+
+	b, err := os.ReadFile(*configPath)
+	if err != nil {
+		log.Fatalf("failed to read config: %v", err)
+	}
+
+	var cfg config
+	err = json.Unmarshal(b, &cfg)
+	if err != nil {
+		log.Fatalf("failed to parse config: %v", err)
+	}
+
+	servers := map[string]string{}
+	for host, id := range cfg.Servers {
+		servers[strings.ToLower(host)] = id
+	}
+
+	c := compute.NewCompute()
+	db := database.NewDatabase(database.WithClient(database.DYNAMODB))
+
+	s := &waker.Server{
+		Compute:         c.Client,
+		DB:              db.Client,
+		Table:           *table,
+		Servers:         servers,
+		DefaultServerID: cfg.Default,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Println("waker listening on", *listen)
+	err = s.ListenAndServe(ctx, *listen)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
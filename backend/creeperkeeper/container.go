@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/hnucamendi/creeper-keeper/container"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+// GetContainerSpec renders the container the server should run from its
+// profile. The instance boot uses it to recreate the container whenever the
+// profile changed, so edits take effect on the next start.
+func (h *Handler) GetContainerSpec(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	profile, err := h.Client.db.Client.GetServerProfile(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if errors.Is(err, types.ErrProfileNotFound) {
+		writeResponse(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Profiles saved before they had a level name load the stored world.
+	err = h.worldDefaults(r.Context(), server, profile)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, container.New(*server.Name, profile))
+}
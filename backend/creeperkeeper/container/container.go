@@ -0,0 +1,271 @@
+// Package container renders the itzg/minecraft-server container a server runs
+// from its profile, and the script that boots it on the instance.
+package container
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const (
+	Image string = "itzg/minecraft-server"
+
+	// HashLabel holds the spec hash on the container, so a boot can tell
+	// whether the container still matches the profile.
+	HashLabel string = "creeperkeeper.spec-hash"
+
+	// RCONPasswordParameter is the SSM parameter holding the RCON password
+	// the backend's console uses.
+	RCONPasswordParameter string = "/creeperkeeper/rcon/password"
+
+	// CurseForgeAPIKeyParameter is the SSM parameter holding the API key
+	// the image downloads CurseForge modpacks with.
+	CurseForgeAPIKeyParameter string = "/creeperkeeper/curseforge/api-key"
+
+	// DataDir is the instance directory mounted as the server's /data, the
+	// same one the world is synced to and from S3.
+	DataDir string = "/home/ec2-user/data"
+
+	gamePort int = 25565
+	rconPort int = 25575
+)
+
+var releasePattern = regexp.MustCompile(`^1\.(\d+)(?:\.(\d+))?`)
+
+type Port struct {
+	Host      int `json:"host"`
+	Container int `json:"container"`
+}
+
+type Volume struct {
+	Host      string `json:"host"`
+	Container string `json:"container"`
+}
+
+// Spec is the container a server should be running.
+type Spec struct {
+	Name  string            `json:"name"`
+	Image string            `json:"image"`
+	Env   map[string]string `json:"env"`
+	// Secrets are env vars read from SSM parameters on the instance as the
+	// container is created, so their values stay out of the spec.
+	Secrets map[string]string `json:"secrets"`
+	Ports   []Port            `json:"ports"`
+	Volumes []Volume          `json:"volumes"`
+	// Properties are written to server.properties before every start. Unlike
+	// Env they are not part of the hash, so changing them does not recreate
+	// the container.
+	Properties map[string]string `json:"properties"`
+	// Hash identifies the spec and is stored on the container as HashLabel.
+	Hash string `json:"hash"`
+	// Run creates the container once the secrets are in shell variables, and
+	// Start is the boot script that starts the existing container if it
+	// matches the spec and reads the secrets and recreates it if not.
+	Run   string   `json:"run"`
+	Start []string `json:"start"`
+}
+
+// New renders the container for the named server from its profile, which
+// must have been validated. Settings that the properties and access
+// endpoints manage, such as the MOTD and ops, are left out so the image does
+// not overwrite them on every start. A difficulty set in the profile is
+// written to server.properties instead.
+func New(name string, p *types.ServerProfile) *Spec {
+	s := &Spec{
+		Name:  name,
+		Image: Image + ":" + imageTag(p),
+		Env:   env(p),
+		Secrets: map[string]string{
+			"RCON_PASSWORD": RCONPasswordParameter,
+		},
+		Ports: []Port{
+			{Host: *p.Ports.Game, Container: gamePort},
+			{Host: *p.Ports.RCON, Container: rconPort},
+		},
+		Volumes:    []Volume{{Host: DataDir, Container: "/data"}},
+		Properties: map[string]string{},
+	}
+	if *p.Type == types.ServerTypeCurseForge {
+		s.Secrets["CF_API_KEY"] = CurseForgeAPIKeyParameter
+	}
+	if p.Difficulty != nil {
+		s.Properties["difficulty"] = *p.Difficulty
+	}
+
+	s.Hash = s.hash()
+	s.Run = s.runCommand()
+	s.Start = s.startScript()
+	return s
+}
+
+func env(p *types.ServerProfile) map[string]string {
+	env := map[string]string{
+		"EULA":   "TRUE",
+		"TYPE":   *p.Type,
+		"MEMORY": strconv.Itoa(*p.MemoryGB) + "G",
+	}
+
+	switch *p.Type {
+	case types.ServerTypeFTB:
+		env["FTB_MODPACK_ID"] = utils.ToString(p.ModpackID)
+		if p.ModpackVersionID != nil {
+			env["FTB_MODPACK_VERSION_ID"] = *p.ModpackVersionID
+		}
+	case types.ServerTypeCurseForge:
+		env["CF_SLUG"] = utils.ToString(p.ModpackID)
+		if p.ModpackVersionID != nil {
+			env["CF_FILE_ID"] = *p.ModpackVersionID
+		}
+	case types.ServerTypeModrinth:
+		env["MODRINTH_MODPACK"] = utils.ToString(p.ModpackID)
+		if p.ModpackVersionID != nil {
+			env["MODRINTH_VERSION"] = *p.ModpackVersionID
+		}
+	default:
+		env["VERSION"] = *p.Version
+	}
+
+	if len(p.JVMFlags) > 0 {
+		env["JVM_OPTS"] = strings.Join(p.JVMFlags, " ")
+	}
+	if p.LevelName != nil {
+		env["LEVEL"] = *p.LevelName
+	}
+	if p.Seed != nil {
+		env["SEED"] = *p.Seed
+	}
+	if len(p.LastDisconnectCommands) > 0 {
+		env["RCON_CMDS_LAST_DISCONNECT"] = strings.Join(p.LastDisconnectCommands, "\n")
+	}
+
+	return env
+}
+
+// imageTag picks the image tag with the Java release the game version
+// needs, unless the profile pins one. Snapshots and LATEST run on the
+// newest Java.
+func imageTag(p *types.ServerProfile) string {
+	if p.ImageTag != nil {
+		return *p.ImageTag
+	}
+
+	m := releasePattern.FindStringSubmatch(utils.ToString(p.Version))
+	if m == nil {
+		return "latest"
+	}
+
+	minor, _ := strconv.Atoi(m[1])
+	patch, _ := strconv.Atoi(m[2])
+	switch {
+	case minor < 17:
+		return "java8"
+	case minor < 20 || (minor == 20 && patch < 5):
+		return "java17"
+	default:
+		return "java21"
+	}
+}
+
+func (s *Spec) hash() string {
+	// The hash covers everything docker run is given, and json sorts the
+	// env keys so equal specs always hash the same.
+	b, _ := json.Marshal(struct {
+		Name    string
+		Image   string
+		Env     map[string]string
+		Secrets map[string]string
+		Ports   []Port
+		Volumes []Volume
+	}{s.Name, s.Image, s.Env, s.Secrets, s.Ports, s.Volumes})
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Spec) runCommand() string {
+	args := []string{
+		"sudo docker run -d",
+		"--name " + utils.ShellQuote(s.Name),
+		"--label " + utils.ShellQuote(HashLabel+"="+s.Hash),
+	}
+
+	for _, port := range s.Ports {
+		args = append(args, fmt.Sprintf("-p %d:%d", port.Host, port.Container))
+	}
+
+	for _, key := range sortedKeys(s.Env) {
+		args = append(args, "-e "+utils.ShellQuote(key+"="+s.Env[key]))
+	}
+
+	// Secrets are expanded from the variables the start script reads them
+	// into.
+	for _, key := range sortedKeys(s.Secrets) {
+		args = append(args, "-e \""+key+"=$"+key+"\"")
+	}
+
+	for _, volume := range s.Volumes {
+		args = append(args, "-v "+utils.ShellQuote(volume.Host+":"+volume.Container))
+	}
+
+	args = append(args, "--tty", "--interactive", utils.ShellQuote(s.Image))
+	return strings.Join(args, " ")
+}
+
+func (s *Spec) startScript() []string {
+	name := utils.ShellQuote(s.Name)
+	current := fmt.Sprintf("sudo docker ps -aq --filter %s --filter %s",
+		utils.ShellQuote("name=^"+s.Name+"$"), utils.ShellQuote("label="+HashLabel+"="+s.Hash))
+
+	script := s.propertiesScript()
+	script = append(script,
+		"if [ -n \"$("+current+")\" ]; then sudo docker start "+name+"; exit $?; fi",
+		"echo "+utils.ShellQuote("container "+s.Name+" does not match its profile, recreating it"),
+	)
+	for _, key := range sortedKeys(s.Secrets) {
+		script = append(script, key+"=\"$(aws ssm get-parameter --name "+utils.ShellQuote(s.Secrets[key])+
+			" --with-decryption --query Parameter.Value --output text)\" || exit 1")
+	}
+	return append(script,
+		"sudo docker rm -f "+name+" || true",
+		s.Run,
+	)
+}
+
+// propertiesScript replaces each of the spec's properties in the data
+// directory's server.properties, creating the file for a new server. The file
+// is left owned like the data directory so the server can still write it.
+func (s *Spec) propertiesScript() []string {
+	if len(s.Properties) == 0 {
+		return []string{}
+	}
+
+	file := utils.ShellQuote(DataDir + "/server.properties")
+	script := []string{
+		"sudo touch " + file + " || exit 1",
+	}
+	for _, key := range sortedKeys(s.Properties) {
+		script = append(script,
+			"sudo sed -i "+utils.ShellQuote("/^[[:space:]]*"+regexp.QuoteMeta(key)+"[[:space:]]*[=:]/d")+" "+file+" || exit 1",
+			"echo "+utils.ShellQuote(key+"="+s.Properties[key])+" | sudo tee -a "+file+" > /dev/null || exit 1",
+		)
+	}
+	return append(script, "sudo chown --reference="+utils.ShellQuote(DataDir)+" "+file)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
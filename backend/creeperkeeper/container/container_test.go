@@ -0,0 +1,187 @@
+package container
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+// testProfile is a validated profile of type typ with everything the
+// container renders from set.
+func testProfile(t *testing.T, typ string) *types.ServerProfile {
+	t.Helper()
+	memory := 4
+	p := &types.ServerProfile{
+		ServerID:               utils.String("i-123"),
+		Type:                   utils.String(typ),
+		Version:                utils.String("1.21.1"),
+		MemoryGB:               &memory,
+		JVMFlags:               []string{"-XX:+UseG1GC"},
+		Difficulty:             utils.String("hard"),
+		LevelName:              utils.String("survival"),
+		Seed:                   utils.String("RedCraft"),
+		LastDisconnectCommands: []string{"save-all", "say bye"},
+	}
+	if typ != types.ServerTypeVanilla {
+		p.Version = nil
+		p.ModpackID = utils.String("all-the-mods-10")
+		p.ModpackVersionID = utils.String("42")
+	}
+
+	err := p.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestNew(t *testing.T) {
+	common := map[string]string{
+		"EULA":                      "TRUE",
+		"MEMORY":                    "4G",
+		"JVM_OPTS":                  "-XX:+UseG1GC",
+		"LEVEL":                     "survival",
+		"SEED":                      "RedCraft",
+		"RCON_CMDS_LAST_DISCONNECT": "save-all\nsay bye",
+	}
+
+	tests := []struct {
+		typ         string
+		wantEnv     map[string]string
+		wantImage   string
+		wantSecrets map[string]string
+	}{
+		{
+			typ:         types.ServerTypeVanilla,
+			wantEnv:     map[string]string{"TYPE": "VANILLA", "VERSION": "1.21.1"},
+			wantImage:   Image + ":java21",
+			wantSecrets: map[string]string{"RCON_PASSWORD": RCONPasswordParameter},
+		},
+		{
+			typ:         types.ServerTypeFTB,
+			wantEnv:     map[string]string{"TYPE": "FTBA", "FTB_MODPACK_ID": "all-the-mods-10", "FTB_MODPACK_VERSION_ID": "42"},
+			wantImage:   Image + ":latest",
+			wantSecrets: map[string]string{"RCON_PASSWORD": RCONPasswordParameter},
+		},
+		{
+			typ:       types.ServerTypeCurseForge,
+			wantEnv:   map[string]string{"TYPE": "AUTO_CURSEFORGE", "CF_SLUG": "all-the-mods-10", "CF_FILE_ID": "42"},
+			wantImage: Image + ":latest",
+			wantSecrets: map[string]string{
+				"RCON_PASSWORD": RCONPasswordParameter,
+				"CF_API_KEY":    CurseForgeAPIKeyParameter,
+			},
+		},
+		{
+			typ:         types.ServerTypeModrinth,
+			wantEnv:     map[string]string{"TYPE": "MODRINTH", "MODRINTH_MODPACK": "all-the-mods-10", "MODRINTH_VERSION": "42"},
+			wantImage:   Image + ":latest",
+			wantSecrets: map[string]string{"RCON_PASSWORD": RCONPasswordParameter},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			s := New("RedCraft", testProfile(t, tt.typ))
+
+			want := map[string]string{}
+			for k, v := range common {
+				want[k] = v
+			}
+			for k, v := range tt.wantEnv {
+				want[k] = v
+			}
+			if !reflect.DeepEqual(s.Env, want) {
+				t.Errorf("Env = %v, want %v", s.Env, want)
+			}
+			if s.Image != tt.wantImage {
+				t.Errorf("Image = %q, want %q", s.Image, tt.wantImage)
+			}
+			if !reflect.DeepEqual(s.Secrets, tt.wantSecrets) {
+				t.Errorf("Secrets = %v, want %v", s.Secrets, tt.wantSecrets)
+			}
+			if !reflect.DeepEqual(s.Properties, map[string]string{"difficulty": "hard"}) {
+				t.Errorf("Properties = %v, want difficulty hard", s.Properties)
+			}
+		})
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	s := New("RedCraft", testProfile(t, types.ServerTypeVanilla))
+
+	want := "sudo docker run -d --name 'RedCraft' --label 'creeperkeeper.spec-hash=" + s.Hash + "'" +
+		" -p 25565:25565 -p 25575:25575" +
+		" -e 'EULA=TRUE' -e 'JVM_OPTS=-XX:+UseG1GC' -e 'LEVEL=survival' -e 'MEMORY=4G'" +
+		" -e 'RCON_CMDS_LAST_DISCONNECT=save-all\nsay bye' -e 'SEED=RedCraft' -e 'TYPE=VANILLA' -e 'VERSION=1.21.1'" +
+		" -e \"RCON_PASSWORD=$RCON_PASSWORD\"" +
+		" -v '/home/ec2-user/data:/data'" +
+		" --tty --interactive 'itzg/minecraft-server:java21'"
+	if s.Run != want {
+		t.Errorf("Run =\n%s\nwant\n%s", s.Run, want)
+	}
+}
+
+func TestStartScript(t *testing.T) {
+	s := New("RedCraft", testProfile(t, types.ServerTypeVanilla))
+
+	file := "'" + DataDir + "/server.properties'"
+	want := []string{
+		"sudo touch " + file + " || exit 1",
+		"sudo sed -i '/^[[:space:]]*difficulty[[:space:]]*[=:]/d' " + file + " || exit 1",
+		"echo 'difficulty=hard' | sudo tee -a " + file + " > /dev/null || exit 1",
+		"sudo chown --reference='" + DataDir + "' " + file,
+	}
+	if !reflect.DeepEqual(s.Start[:len(want)], want) {
+		t.Errorf("Start begins %q, want %q", s.Start[:len(want)], want)
+	}
+
+	rest := strings.Join(s.Start[len(want):], "\n")
+	for _, part := range []string{
+		"--filter 'label=" + HashLabel + "=" + s.Hash + "'",
+		"RCON_PASSWORD=\"$(aws ssm get-parameter --name '" + RCONPasswordParameter + "'",
+		s.Run,
+	} {
+		if !strings.Contains(rest, part) {
+			t.Errorf("Start = %q, want it to contain %q", rest, part)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	base := New("RedCraft", testProfile(t, types.ServerTypeVanilla)).Hash
+
+	// The hash is stored on running containers, so any change to it
+	// recreates every container on its next start.
+	if want := "b6d50d4f0a35162141063a8ac6a7fb7dbb44ecdd193e70240d9dbd57ddfdb229"; base != want {
+		t.Errorf("hash = %s, want %s", base, want)
+	}
+
+	tests := []struct {
+		name     string
+		change   func(p *types.ServerProfile)
+		wantSame bool
+	}{
+		{name: "unchanged", change: func(p *types.ServerProfile) {}, wantSame: true},
+		{name: "difficulty", change: func(p *types.ServerProfile) { p.Difficulty = utils.String("easy") }, wantSame: true},
+		{name: "updated at", change: func(p *types.ServerProfile) { p.UpdatedAt = utils.String("2025-01-01T00:00:00Z") }, wantSame: true},
+		{name: "version", change: func(p *types.ServerProfile) { p.Version = utils.String("1.21.4") }},
+		{name: "memory", change: func(p *types.ServerProfile) { memory := 8; p.MemoryGB = &memory }},
+		{name: "jvm flags", change: func(p *types.ServerProfile) { p.JVMFlags = nil }},
+		{name: "seed", change: func(p *types.ServerProfile) { p.Seed = utils.String("other") }},
+		{name: "image tag", change: func(p *types.ServerProfile) { p.ImageTag = utils.String("java17") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testProfile(t, types.ServerTypeVanilla)
+			tt.change(p)
+
+			got := New("RedCraft", p).Hash
+			if (got == base) != tt.wantSame {
+				t.Errorf("hash = %s, base %s, want same %v", got, base, tt.wantSame)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/awslabs/aws-lambda-go-api-proxy/core"
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const downloadJobRoute string = "/creeperkeeper/jobs/download"
+
+const downloadExpiry time.Duration = 15 * time.Minute
+
+// downloadScopes grant access to world downloads. API Gateway enforces them
+// too, this guards against the route being exposed without them.
+var downloadScopes = []string{"download:world", "write:all"}
+
+// DownloadWorld starts packaging the server's current world for the caller.
+// The task's result names the download once it is ready, and
+// GetDownload hands out its short lived link. Every download is recorded
+// against the caller.
+func (h *Handler) DownloadWorld(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	rc, ok := core.GetAPIGatewayV2ContextFromContext(r.Context())
+	if !ok || !hasScope(rc, downloadScopes) {
+		writeResponse(w, r, http.StatusForbidden, "not allowed to download worlds")
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	params := map[string]string{
+		"requestedBy": rc.Authorizer.JWT.Claims["sub"],
+		"sourceIP":    rc.HTTP.SourceIP,
+	}
+	h.startTask(w, r, serverID, types.WorldTask(types.OperationDownload), downloadJobRoute, types.NewOperation(types.OperationDownload, params))
+}
+
+// DownloadJob packages the world for a download started by DownloadWorld
+// and records it. The link to it is only valid for downloadExpiry from when
+// the archive is ready.
+func (h *Handler) DownloadJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.loadOperation(r)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	var m *backup.Manifest
+	err = run.step(ctx, "package world", func() error {
+		var err error
+		m, err = h.Client.backup.Export(ctx, &backup.Manifest{
+			ServerID:   run.serverID,
+			ServerName: utils.ToString(run.server.Name),
+			Trigger:    backup.TriggerDownload,
+		})
+		return err
+	})
+	if err == nil {
+		// A download that cannot be audited is not handed out
+		err = run.step(ctx, "record download", func() error {
+			err := h.Client.db.Client.RecordDownload(ctx, utils.ToString(h.Client.db.Table), &types.Download{
+				ServerID:    utils.String(run.serverID),
+				ID:          utils.String(m.ID),
+				Archive:     utils.String(m.Archive),
+				Size:        m.Size,
+				SHA256:      utils.String(m.SHA256),
+				CreatedAt:   utils.String(m.CreatedAt.Format(time.RFC3339)),
+				ExpiresAt:   utils.String(m.CreatedAt.Add(downloadExpiry).Format(time.RFC3339)),
+				RequestedBy: utils.String(run.op.Params["requestedBy"]),
+				SourceIP:    utils.String(run.op.Params["sourceIP"]),
+			})
+			if err != nil {
+				return err
+			}
+			run.op.Result = map[string]string{"downloadID": m.ID}
+			return nil
+		})
+	}
+	run.finish(ctx, err)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, run.op)
+}
+
+// GetDownload returns a download with a link to its archive, for the caller
+// who requested it and until it expires.
+func (h *Handler) GetDownload(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	downloadID := r.PathValue("downloadID")
+	if serverID == "" || downloadID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID and downloadID must be provided")
+		return
+	}
+
+	rc, ok := core.GetAPIGatewayV2ContextFromContext(r.Context())
+	if !ok || !hasScope(rc, downloadScopes) {
+		writeResponse(w, r, http.StatusForbidden, "not allowed to download worlds")
+		return
+	}
+
+	downloads, err := h.Client.db.Client.ListDownloads(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	i := slices.IndexFunc(downloads, func(d types.Download) bool {
+		return utils.ToString(d.ID) == downloadID
+	})
+	if i < 0 {
+		writeResponse(w, r, http.StatusNotFound, "download not found: "+downloadID)
+		return
+	}
+	download := &downloads[i]
+
+	if utils.ToString(download.RequestedBy) != rc.Authorizer.JWT.Claims["sub"] {
+		writeResponse(w, r, http.StatusForbidden, "download was requested by someone else")
+		return
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, utils.ToString(download.ExpiresAt))
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	remaining := time.Until(expiresAt)
+	if remaining <= 0 {
+		writeResponse(w, r, http.StatusGone, "download has expired: "+downloadID)
+		return
+	}
+
+	url, err := h.Client.storage.Client.PresignGet(r.Context(), utils.ToString(h.Client.storage.Bucket), utils.ToString(download.Archive), remaining)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	download.URL = utils.String(url)
+
+	writeResponse(w, r, http.StatusOK, download)
+}
+
+func (h *Handler) ListDownloads(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	downloads, err := h.Client.db.Client.ListDownloads(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, downloads)
+}
+
+// hasScope reports whether the request's token carries any of scopes.
+func hasScope(rc events.APIGatewayV2HTTPRequestContext, scopes []string) bool {
+	if rc.Authorizer == nil || rc.Authorizer.JWT == nil {
+		return false
+	}
+
+	granted := slices.Clone(rc.Authorizer.JWT.Scopes)
+	granted = append(granted, strings.Fields(rc.Authorizer.JWT.Claims["scope"])...)
+	for _, s := range scopes {
+		if slices.Contains(granted, s) {
+			return true
+		}
+	}
+	return false
+}
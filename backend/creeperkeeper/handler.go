@@ -7,6 +7,8 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/minecraft/slp"
 	"github.com/hnucamendi/creeper-keeper/types"
 	"github.com/hnucamendi/creeper-keeper/utils"
 )
@@ -56,11 +58,109 @@ func (h *Handler) RegisterServer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.Client.db.Client.RegisterServer(r.Context(), utils.ToString(h.Client.db.Table), utils.ToString(ck.ID), utils.ToString(ck.SK), utils.ToString(ck.IP), utils.ToString(ck.Name), utils.ToBool(ck.IsRunning), utils.ToString(ck.LastUpdated))
+	if ck.State == nil {
+		ck.State = utils.String(types.StateStarting)
+	}
+
+	if !types.ValidState(utils.ToString(ck.State)) {
+		writeResponse(w, r, http.StatusBadRequest, errors.New("invalid server state: "+utils.ToString(ck.State)).Error())
+		return
+	}
+
+	h.Client.db.Client.RegisterServer(r.Context(), utils.ToString(h.Client.db.Table), utils.ToString(ck.ID), utils.ToString(ck.SK), utils.ToString(ck.IP), utils.ToString(ck.Name), utils.ToBool(ck.IsRunning), utils.ToString(ck.LastUpdated), utils.ToString(ck.State))
 
 	writeResponse(w, r, http.StatusOK, "server registered")
 }
 
+// Records the Minecraft server's readiness, reported by EC2 directly
+func (h *Handler) UpdateServerState(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	ck := &types.Server{}
+	err := ck.UnmarshallRequest(r.Body)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !types.ValidState(utils.ToString(ck.State)) {
+		writeResponse(w, r, http.StatusBadRequest, errors.New("invalid server state: "+utils.ToString(ck.State)).Error())
+		return
+	}
+
+	err = h.Client.db.Client.UpdateServerState(r.Context(), utils.ToString(h.Client.db.Table), serverID, utils.ToString(ck.State))
+	if errors.Is(err, types.ErrServerNotFound) {
+		writeResponse(w, r, http.StatusNotFound, err.Error()+": "+serverID)
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	switch utils.ToString(ck.State) {
+	case types.StateReady:
+		h.syncAccessLists(r.Context(), serverID)
+		h.resumeUpgrade(r.Context(), serverID)
+	case types.StateBootFailed:
+		h.resumeUpgrade(r.Context(), serverID)
+	}
+
+	writeResponse(w, r, http.StatusOK, "server state updated")
+}
+
+func (h *Handler) UpdateServerSettings(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	settings := &types.ServerSettings{}
+	err := settings.UnmarshallRequest(r.Body)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if settings.IdleTimeoutMinutes != nil && *settings.IdleTimeoutMinutes < 0 {
+		writeResponse(w, r, http.StatusBadRequest, "idle timeout must not be negative")
+		return
+	}
+
+	if settings.BackupSchedule != nil {
+		_, err = backup.ParseSchedule(*settings.BackupSchedule)
+		if err != nil {
+			writeResponse(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if settings.Retention != nil {
+		err = settings.Retention.Validate()
+		if err != nil {
+			writeResponse(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	err = h.Client.db.Client.UpdateServerSettings(r.Context(), utils.ToString(h.Client.db.Table), serverID, settings)
+	if errors.Is(err, types.ErrServerNotFound) {
+		writeResponse(w, r, http.StatusNotFound, err.Error()+": "+serverID)
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, "server settings updated")
+}
+
 func (h *Handler) Ping(w http.ResponseWriter, r *http.Request) {
 	serverID := r.PathValue("serverID")
 	if serverID == "" {
@@ -73,7 +173,46 @@ func (h *Handler) Ping(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeResponse(w, r, http.StatusOK, status)
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Pings are frequent, so the world comes from the bucket rather than a
+	// command on the instance.
+	ping := &types.ServerPing{State: status, World: h.worldInfo(r.Context(), server)}
+	if utils.ToString(status) != "RUNNING" {
+		writeResponse(w, r, http.StatusOK, ping)
+		return
+	}
+
+	ping.Status, err = slp.Query(r.Context(), utils.ToString(server.IP), server.GamePort(), slp.DefaultTimeout)
+	if err != nil {
+		ping.StatusError = utils.String(err.Error())
+	}
+
+	writeResponse(w, r, http.StatusOK, ping)
+}
+
+func (h *Handler) GetCommand(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	commandID := r.PathValue("commandID")
+	if serverID == "" || commandID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID and commandID must be provided")
+		return
+	}
+
+	result, err := h.Client.systemsmanager.Client.GetCommand(r.Context(), &types.Command{
+		ID:       utils.String(commandID),
+		ServerID: utils.String(serverID),
+	})
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, result)
 }
 
 func (h *Handler) ListServers(w http.ResponseWriter, r *http.Request) {
@@ -83,6 +222,10 @@ func (h *Handler) ListServers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for i := range servers {
+		servers[i].World = h.worldInfo(r.Context(), &servers[i])
+	}
+
 	writeResponse(w, r, http.StatusOK, servers)
 }
 
@@ -99,57 +242,62 @@ func (h *Handler) StartServer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.Client.compute.Client.StartServer(r.Context(), utils.ToString(ck.ID))
+	serverID := utils.ToString(ck.ID)
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
 	if err != nil {
 		writeResponse(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	writeResponse(w, r, http.StatusOK, "Server Started")
-}
-
-func (h *Handler) StopServer(w http.ResponseWriter, r *http.Request) {
-	ck := &types.Server{}
-	err := ck.UnmarshallRequest(r.Body)
-	if err != nil {
-		writeResponse(w, r, http.StatusBadRequest, err.Error())
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
 		return
 	}
 
-	if ck.ID == nil {
-		writeResponse(w, r, http.StatusBadRequest, "server id must be provided")
+	// Operations stop and start the server themselves, starting it under
+	// one could boot it on a world that is half restored.
+	if server.Operation.Active() {
+		writeResponse(w, r, http.StatusConflict, types.ErrOperationInProgress.Error())
 		return
 	}
 
-	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), utils.ToString(ck.ID))
+	err = h.Client.compute.Client.StartServer(r.Context(), serverID)
 	if err != nil {
 		writeResponse(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	err = h.Client.db.Client.UpsertServer(r.Context(), utils.ToString(h.Client.db.Table), utils.ToString(server.ID), utils.ToString(server.IP), utils.ToString(server.Name))
+	writeResponse(w, r, http.StatusOK, "Server Started")
+}
+
+func (h *Handler) StopServer(w http.ResponseWriter, r *http.Request) {
+	ck := &types.Server{}
+	err := ck.UnmarshallRequest(r.Body)
 	if err != nil {
-		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	commands := []string{
-		utils.Concat("sudo docker exec -i ", utils.ToString(server.Name), " rcon-cli"), "stop",
-		utils.Concat("sudo aws s3 sync --delete data s3://creeperkeeper-world-data/", utils.ToString(server.Name), "/"),
+	if ck.ID == nil {
+		writeResponse(w, r, http.StatusBadRequest, "server id must be provided")
+		return
 	}
-	err = h.Client.systemsmanager.Client.Send(r.Context(), utils.ToString(server.ID), commands)
+
+	serverID := utils.ToString(ck.ID)
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
 	if err != nil {
 		writeResponse(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	err = h.Client.compute.Client.StopServer(r.Context(), utils.ToString(ck.ID))
-	if err != nil {
-		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
 		return
 	}
 
-	writeResponse(w, r, http.StatusOK, "Server stopping")
+	// The stop outlasts the request, so it runs as a job and its outcome is
+	// kept on the server record.
+	h.startOperation(w, r, serverID, stopJobRoute, types.NewOperation(types.OperationStop, nil))
 }
 
 func generateETag[T any](data T) string {
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/minecraft/slp"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+// Jobs are invoked on a schedule by EventBridge Scheduler, which sends the
+// lambda an API Gateway shaped event for the job's route.
+
+var listPlayersPattern = regexp.MustCompile(`There are (\d+) of a max`)
+
+// IdleShutdown starts a stop of each ready server that has had no players for
+// longer than its idle timeout. Servers still booting, or busy with an
+// operation that may stop or restart them, are left alone.
+func (h *Handler) IdleShutdown(w http.ResponseWriter, r *http.Request) {
+	servers, err := h.Client.db.Client.ListServers(r.Context(), utils.ToString(h.Client.db.Table))
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results := map[string]string{}
+	for i := range servers {
+		server := &servers[i]
+		if !utils.ToBool(server.IsRunning) || server.IdleTimeout() == 0 {
+			continue
+		}
+
+		if utils.ToString(server.State) != types.StateReady {
+			results[utils.ToString(server.ID)] = "not ready"
+			continue
+		}
+
+		if server.Operation.Active() {
+			results[utils.ToString(server.ID)] = "operation in progress"
+			continue
+		}
+
+		result, err := h.checkIdle(r.Context(), server)
+		if err != nil {
+			result = err.Error()
+		}
+		results[utils.ToString(server.ID)] = result
+	}
+
+	writeResponse(w, r, http.StatusOK, results)
+}
+
+func (h *Handler) checkIdle(ctx context.Context, server *types.Server) (string, error) {
+	table := utils.ToString(h.Client.db.Table)
+	serverID := utils.ToString(server.ID)
+
+	online, err := h.onlinePlayers(ctx, server)
+	if err != nil {
+		return "", fmt.Errorf("failed to count players: %w", err)
+	}
+
+	if online > 0 {
+		if server.EmptySince != nil {
+			err = h.Client.db.Client.SetServerEmptySince(ctx, table, serverID, nil)
+			if err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("%d players online", online), nil
+	}
+
+	now := time.Now().UTC()
+	if server.EmptySince == nil {
+		err = h.Client.db.Client.SetServerEmptySince(ctx, table, serverID, utils.String(now.Format(time.RFC3339)))
+		if err != nil {
+			return "", err
+		}
+		return "empty", nil
+	}
+
+	emptySince, err := time.Parse(time.RFC3339, *server.EmptySince)
+	if err != nil {
+		return "", fmt.Errorf("invalid empty since %q: %w", *server.EmptySince, err)
+	}
+
+	idle := now.Sub(emptySince)
+	if idle < server.IdleTimeout() {
+		return fmt.Sprintf("empty for %v", idle.Round(time.Minute)), nil
+	}
+
+	// The stop runs as its own operation, so it takes the server's lock and
+	// records its outcome, and each server stops in its own job.
+	op := types.NewOperation(types.OperationStop, map[string]string{"trigger": "idle"})
+	err = h.enqueueOperation(ctx, serverID, stopJobRoute, op)
+	if errors.Is(err, types.ErrOperationInProgress) {
+		return "operation in progress", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to stop idle server: %w", err)
+	}
+	return fmt.Sprintf("stopping after %v empty, operation %s", idle.Round(time.Minute), utils.ToString(op.ID)), nil
+}
+
+// onlinePlayers asks the server for its player count through the status
+// protocol, falling back to the list command over the console.
+func (h *Handler) onlinePlayers(ctx context.Context, server *types.Server) (int, error) {
+	status, err := slp.Query(ctx, utils.ToString(server.IP), server.GamePort(), slp.DefaultTimeout)
+	if err == nil {
+		return status.Players.Online, nil
+	}
+
+	out, err := h.Client.console.Client.Exec(ctx, server, "list")
+	if err != nil {
+		return 0, err
+	}
+
+	m := listPlayersPattern.FindStringSubmatch(out)
+	if m == nil {
+		return 0, fmt.Errorf("unexpected list response %q", out)
+	}
+	return strconv.Atoi(m[1])
+}
+
+// PruneBackups applies each server's retention policy to its backups.
+// Servers without a policy, or with an operation in progress, are left alone.
+// With dryRun=true in the query the backups that would be deleted are listed
+// instead.
+func (h *Handler) PruneBackups(w http.ResponseWriter, r *http.Request) {
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+
+	servers, err := h.Client.db.Client.ListServers(r.Context(), utils.ToString(h.Client.db.Table))
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results := []any{}
+	for i := range servers {
+		server := &servers[i]
+		if server.Retention == nil {
+			continue
+		}
+
+		if server.Operation.Active() {
+			results = append(results, map[string]any{
+				"serverID": utils.ToString(server.ID),
+				"skipped":  "operation in progress",
+			})
+			continue
+		}
+
+		result, err := h.Client.backup.Prune(r.Context(), utils.ToString(server.ID), prunePolicy(server), dryRun)
+		if err != nil {
+			results = append(results, map[string]any{
+				"serverID": utils.ToString(server.ID),
+				"error":    err.Error(),
+				"partial":  result,
+			})
+			continue
+		}
+		results = append(results, result)
+	}
+
+	writeResponse(w, r, http.StatusOK, results)
+}
+
+// ScheduledBackups starts a backup of each running server whose backup
+// schedule has come up since its last scheduled backup. Servers busy with an
+// operation are left for the next run.
+func (h *Handler) ScheduledBackups(w http.ResponseWriter, r *http.Request) {
+	servers, err := h.Client.db.Client.ListServers(r.Context(), utils.ToString(h.Client.db.Table))
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	results := map[string]string{}
+	for i := range servers {
+		server := &servers[i]
+		if server.BackupSchedule == nil || !utils.ToBool(server.IsRunning) {
+			continue
+		}
+
+		result, err := h.scheduledBackup(r.Context(), server)
+		if err != nil {
+			result = err.Error()
+		}
+		results[utils.ToString(server.ID)] = result
+	}
+
+	writeResponse(w, r, http.StatusOK, results)
+}
+
+func (h *Handler) scheduledBackup(ctx context.Context, server *types.Server) (string, error) {
+	schedule, err := backup.ParseSchedule(utils.ToString(server.BackupSchedule))
+	if err != nil {
+		return "", err
+	}
+
+	if utils.ToString(server.State) != types.StateReady {
+		return "not ready", nil
+	}
+
+	if server.Operation.Active() {
+		return "operation in progress", nil
+	}
+
+	// Servers that have never been backed up count from when they became
+	// ready, so the first backup is at the first scheduled time after that.
+	now := time.Now().UTC()
+	last, err := lastBackup(server)
+	if err != nil {
+		return "", err
+	}
+
+	if !backup.Due(schedule, last, now) {
+		return "next backup at " + schedule.Next(last).Format(time.RFC3339), nil
+	}
+
+	// BackupJob records the backup time once the backup is made.
+	op := types.NewOperation(types.OperationBackup, map[string]string{"trigger": backup.TriggerScheduled})
+	err = h.enqueueOperation(ctx, utils.ToString(server.ID), backupJobRoute, op)
+	if errors.Is(err, types.ErrOperationInProgress) {
+		return "operation in progress", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to start backup: %w", err)
+	}
+	return "backing up, operation " + utils.ToString(op.ID), nil
+}
+
+func lastBackup(server *types.Server) (time.Time, error) {
+	if server.LastBackupAt != nil {
+		return time.Parse(time.RFC3339, *server.LastBackupAt)
+	}
+
+	if server.ReadyAt == nil {
+		return time.Now().UTC(), nil
+	}
+
+	return time.Parse(time.RFC3339, *server.ReadyAt)
+}
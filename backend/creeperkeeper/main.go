@@ -4,26 +4,44 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/container"
 	"github.com/hnucamendi/creeper-keeper/service/compute"
+	"github.com/hnucamendi/creeper-keeper/service/console"
 	"github.com/hnucamendi/creeper-keeper/service/database"
+	"github.com/hnucamendi/creeper-keeper/service/jobs"
+	"github.com/hnucamendi/creeper-keeper/service/storage"
 	"github.com/hnucamendi/creeper-keeper/service/systemsmanager"
+	"github.com/hnucamendi/creeper-keeper/utils"
 	"github.com/hnucamendi/jwt-go/jwt"
 	"golang.org/x/exp/rand"
 )
 
 const (
-	tableName string = "creeperkeeper"
+	tableName             string = "creeperkeeper"
+	rconPasswordParameter string = container.RCONPasswordParameter
+	worldBucket           string = "creeperkeeper-world-data"
+
+	// rconDirectEnv enables sending console commands straight to the
+	// server's RCON port. It is only set when terraform opens that port to
+	// the backend, otherwise commands go through SSM.
+	rconDirectEnv string = "RCON_DIRECT"
 )
 
 var (
 	dbClient             *database.Client
 	computeClient        *compute.Client
 	systemsmanagerClient *systemsmanager.Client
+	consoleClient        *console.Client
+	storageClient        *storage.Client
+	backupClient         *backup.Client
+	jobsClient           *jobs.Client
 	mux                  *http.ServeMux
 	j                    *jwt.JWT
 )
@@ -32,6 +50,10 @@ type C struct {
 	db             *database.Client
 	compute        *compute.Client
 	systemsmanager *systemsmanager.Client
+	console        *console.Client
+	storage        *storage.Client
+	backup         *backup.Client
+	jobs           *jobs.Client
 	j              *jwt.JWT
 	*http.Client
 }
@@ -42,11 +64,26 @@ func init() {
 	rand.Seed(uint64(time.Now().UnixNano()))
 
 	systemsmanagerClient = systemsmanager.NewSystemsManager()
+	consoleOpts := []console.Opts{console.WithSystemsManager(systemsmanagerClient)}
+	if os.Getenv(rconDirectEnv) == "true" {
+		rconPassword, err := systemsmanagerClient.Client.GetParameter(context.Background(), rconPasswordParameter)
+		if err != nil {
+			log.Println("RCON password unavailable, console commands will go through SSM:", err)
+		}
+		consoleOpts = append(consoleOpts, console.WithRCON(utils.ToString(rconPassword)))
+	}
+	consoleClient = console.NewConsole(consoleOpts...)
 	computeClient = compute.NewCompute()
 	dbClient = database.NewDatabase(
 		database.WithClient(database.DYNAMODB),
 		database.WithTable(tableName),
 	)
+	storageClient = storage.NewStorage(
+		storage.WithClient(storage.S3),
+		storage.WithBucket(worldBucket),
+	)
+	backupClient = backup.NewBackup(storageClient)
+	jobsClient = jobs.NewJobs()
 
 	j = &jwt.JWT{
 		TenantURL: "https://dev-bxn245l6be2yzhil.us.auth0.com/oauth/token",
@@ -58,6 +95,10 @@ func init() {
 		db:             dbClient,
 		compute:        computeClient,
 		systemsmanager: systemsmanagerClient,
+		console:        consoleClient,
+		storage:        storageClient,
+		backup:         backupClient,
+		jobs:           jobsClient,
 		j:              j,
 		Client:         hc,
 	}
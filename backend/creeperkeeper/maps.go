@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const (
+	mapJobRoute string = "/creeperkeeper/jobs/render-map"
+
+	// tileExpiry is how long the tile links in a served map index work.
+	tileExpiry time.Duration = time.Hour
+)
+
+// GetMap returns a backup's rendered map index with a link to each tile.
+// With dimension in the query only that dimension's tiles are returned.
+func (h *Handler) GetMap(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	backupID := r.PathValue("backupID")
+	if serverID == "" || backupID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID and backupID must be provided")
+		return
+	}
+
+	index, err := h.Client.backup.GetMap(r.Context(), serverID, backupID)
+	if errors.Is(err, backup.ErrNotFound) {
+		writeResponse(w, r, http.StatusNotFound, "no map for backup "+backupID+", render it first")
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	dimension := r.URL.Query().Get("dimension")
+	dimensions := []backup.MapDimension{}
+	for _, dim := range index.Dimensions {
+		if dimension != "" && dim.Dimension != dimension {
+			continue
+		}
+		for i := range dim.Tiles {
+			tile := &dim.Tiles[i]
+			tile.URL, err = h.Client.storage.Client.PresignGet(r.Context(), utils.ToString(h.Client.storage.Bucket), tile.Key, tileExpiry)
+			if err != nil {
+				writeResponse(w, r, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+		dimensions = append(dimensions, dim)
+	}
+	index.Dimensions = dimensions
+
+	writeResponse(w, r, http.StatusOK, index)
+}
+
+// RenderMap starts drawing a top-down map of a backup's world. Rendering
+// reads every chunk, so it runs as a job that reports its progress.
+func (h *Handler) RenderMap(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	backupID := r.PathValue("backupID")
+	if serverID == "" || backupID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID and backupID must be provided")
+		return
+	}
+
+	_, err := h.Client.backup.Get(r.Context(), serverID, backupID)
+	if errors.Is(err, backup.ErrNotFound) {
+		writeResponse(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	params := map[string]string{"backupID": backupID}
+	h.startTask(w, r, serverID, types.BackupTask(types.OperationMap, backupID), mapJobRoute, types.NewOperation(types.OperationMap, params))
+}
+
+func (h *Handler) RenderMapJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.loadOperation(r)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.renderMap(r.Context(), run)
+	run.finish(r.Context(), err)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, run.op)
+}
+
+func (h *Handler) renderMap(ctx context.Context, run *operationRun) error {
+	var m *backup.Manifest
+	var err error
+	err = run.step(ctx, "load backup", func() error {
+		m, err = h.Client.backup.Get(ctx, run.serverID, run.op.Params["backupID"])
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return run.step(ctx, "render regions", func() error {
+		index, err := h.Client.backup.RenderMap(ctx, m, func(done int, total int) {
+			run.progress(ctx, done, total)
+		})
+		if err != nil {
+			return err
+		}
+
+		tiles := 0
+		for _, dim := range index.Dimensions {
+			tiles += len(dim.Tiles)
+		}
+		run.op.Result = map[string]string{
+			"backupID":   m.ID,
+			"dimensions": strconv.Itoa(len(index.Dimensions)),
+			"tiles":      strconv.Itoa(tiles),
+			"errors":     strconv.Itoa(len(index.Errors)),
+		}
+		return nil
+	})
+}
@@ -0,0 +1,239 @@
+// Package access reads and writes the server's access lists, whitelist.json,
+// ops.json, banned-players.json and banned-ips.json, and builds the console
+// commands that change them on a running server.
+package access
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	Whitelist     string = "whitelist"
+	Ops           string = "ops"
+	BannedPlayers string = "banned-players"
+	BannedIPs     string = "banned-ips"
+)
+
+// Lists are the access lists in the order they are applied.
+var Lists = []string{Whitelist, Ops, BannedPlayers, BannedIPs}
+
+const (
+	// dateFormat is how the game records when a ban was made.
+	dateFormat    string = "2006-01-02 15:04:05 -0700"
+	defaultSource string = "Creeper Keeper"
+	defaultReason string = "Banned by an operator."
+	forever       string = "forever"
+)
+
+var (
+	ErrInvalidEntry = errors.New("invalid access list entry")
+
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+)
+
+// Entry is an entry in any of the lists. Only the fields the list's file
+// has are used: IP for banned-ips and UUID and Name for the others, Level
+// and BypassesPlayerLimit for ops, and the ban fields for both ban lists.
+type Entry struct {
+	UUID                string `json:"uuid,omitempty"`
+	Name                string `json:"name,omitempty"`
+	IP                  string `json:"ip,omitempty"`
+	Level               int    `json:"level,omitempty"`
+	BypassesPlayerLimit bool   `json:"bypassesPlayerLimit,omitempty"`
+	Created             string `json:"created,omitempty"`
+	Source              string `json:"source,omitempty"`
+	Expires             string `json:"expires,omitempty"`
+	Reason              string `json:"reason,omitempty"`
+}
+
+// Valid reports whether list names an access list.
+func Valid(list string) bool {
+	return slices.Contains(Lists, list)
+}
+
+// FileName is the list's file in the server's data directory.
+func FileName(list string) string {
+	return list + ".json"
+}
+
+// Key identifies an entry within its list: its IP for IP bans, otherwise
+// its player name, which is case insensitive, or UUID.
+func Key(list string, e Entry) string {
+	if list == BannedIPs {
+		return NormalizeKey(list, e.IP)
+	}
+	if e.Name != "" {
+		return NormalizeKey(list, e.Name)
+	}
+	return NormalizeKey(list, e.UUID)
+}
+
+// NormalizeKey puts a player name, UUID or IP that names an entry of list in
+// the form Key gives, so IPs written differently still match.
+func NormalizeKey(list string, key string) string {
+	if list == BannedIPs {
+		if ip := net.ParseIP(key); ip != nil {
+			return ip.String()
+		}
+	}
+	return strings.ToLower(key)
+}
+
+// Validate checks an entry being added to list and fills in the fields the
+// game expects, such as when a ban was made.
+func Validate(list string, e *Entry) error {
+	if list == BannedIPs {
+		if net.ParseIP(e.IP) == nil {
+			return fmt.Errorf("%w: %q is not an IP address", ErrInvalidEntry, e.IP)
+		}
+		e.UUID, e.Name = "", ""
+	} else {
+		if !namePattern.MatchString(e.Name) {
+			return fmt.Errorf("%w: %q is not a player name", ErrInvalidEntry, e.Name)
+		}
+		e.IP = ""
+	}
+
+	if list == Ops {
+		if e.Level == 0 {
+			e.Level = 4
+		}
+		if e.Level < 1 || e.Level > 4 {
+			return fmt.Errorf("%w: op level must be from 1 to 4", ErrInvalidEntry)
+		}
+	} else {
+		e.Level, e.BypassesPlayerLimit = 0, false
+	}
+
+	if list == BannedPlayers || list == BannedIPs {
+		if e.Created == "" {
+			e.Created = time.Now().UTC().Format(dateFormat)
+		}
+		if e.Source == "" {
+			e.Source = defaultSource
+		}
+		if e.Expires == "" {
+			e.Expires = forever
+		}
+		if e.Reason == "" {
+			e.Reason = defaultReason
+		}
+		if strings.ContainsAny(e.Reason, "\r\n") {
+			return fmt.Errorf("%w: ban reason must be a single line", ErrInvalidEntry)
+		}
+	} else {
+		e.Created, e.Source, e.Expires, e.Reason = "", "", "", ""
+	}
+	return nil
+}
+
+// Read parses a list file. A missing or empty file is an empty list.
+func Read(r io.Reader) ([]Entry, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	if len(strings.TrimSpace(string(b))) == 0 {
+		return entries, nil
+	}
+	err = json.Unmarshal(b, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read access list: %w", err)
+	}
+	return entries, nil
+}
+
+// Write renders a list file the way the game writes it. Ops always have
+// bypassesPlayerLimit, so it is written even when false.
+func Write(list string, entries []Entry) ([]byte, error) {
+	out := []map[string]any{}
+	for _, e := range entries {
+		m := map[string]any{}
+		switch list {
+		case BannedIPs:
+			m["ip"] = e.IP
+		default:
+			m["uuid"] = e.UUID
+			m["name"] = e.Name
+		}
+
+		switch list {
+		case Ops:
+			m["level"] = e.Level
+			m["bypassesPlayerLimit"] = e.BypassesPlayerLimit
+		case BannedPlayers, BannedIPs:
+			m["created"] = e.Created
+			m["source"] = e.Source
+			m["expires"] = e.Expires
+			m["reason"] = e.Reason
+		}
+		out = append(out, m)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// Diff returns the entries of desired that are not in current and the
+// entries of current that are not in desired.
+func Diff(list string, current []Entry, desired []Entry) ([]Entry, []Entry) {
+	have := map[string]bool{}
+	for _, e := range current {
+		have[Key(list, e)] = true
+	}
+	want := map[string]bool{}
+	for _, e := range desired {
+		want[Key(list, e)] = true
+	}
+
+	add := []Entry{}
+	for _, e := range desired {
+		if !have[Key(list, e)] {
+			add = append(add, e)
+		}
+	}
+	remove := []Entry{}
+	for _, e := range current {
+		if !want[Key(list, e)] {
+			remove = append(remove, e)
+		}
+	}
+	return add, remove
+}
+
+// AddCommand is the console command that adds e to list. Op levels cannot
+// be given to the op command, the server's op-permission-level is used.
+func AddCommand(list string, e Entry) string {
+	switch list {
+	case Whitelist:
+		return "whitelist add " + e.Name
+	case Ops:
+		return "op " + e.Name
+	case BannedPlayers:
+		return strings.TrimSpace("ban " + e.Name + " " + e.Reason)
+	default:
+		return strings.TrimSpace("ban-ip " + e.IP + " " + e.Reason)
+	}
+}
+
+// RemoveCommand is the console command that removes e from list.
+func RemoveCommand(list string, e Entry) string {
+	switch list {
+	case Whitelist:
+		return "whitelist remove " + e.Name
+	case Ops:
+		return "deop " + e.Name
+	case BannedPlayers:
+		return "pardon " + e.Name
+	default:
+		return "pardon-ip " + e.IP
+	}
+}
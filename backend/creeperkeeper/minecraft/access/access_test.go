@@ -0,0 +1,263 @@
+package access
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		entry   Entry
+		want    Entry
+		wantErr bool
+	}{
+		{
+			name:  "whitelist drops other fields",
+			list:  Whitelist,
+			entry: Entry{Name: "Steve", IP: "10.0.0.1", Level: 4, Reason: "x"},
+			want:  Entry{Name: "Steve"},
+		},
+		{name: "op default level", list: Ops, entry: Entry{Name: "Steve"}, want: Entry{Name: "Steve", Level: 4}},
+		{name: "op level kept", list: Ops, entry: Entry{Name: "Steve", Level: 2, BypassesPlayerLimit: true}, want: Entry{Name: "Steve", Level: 2, BypassesPlayerLimit: true}},
+		{name: "op level too high", list: Ops, entry: Entry{Name: "Steve", Level: 5}, wantErr: true},
+		{
+			name:  "ban defaults",
+			list:  BannedPlayers,
+			entry: Entry{Name: "Griefer", Created: "2025-01-02 03:04:05 +0000"},
+			want:  Entry{Name: "Griefer", Created: "2025-01-02 03:04:05 +0000", Source: defaultSource, Expires: forever, Reason: defaultReason},
+		},
+		{name: "ban reason on two lines", list: BannedPlayers, entry: Entry{Name: "Griefer", Reason: "a\nb"}, wantErr: true},
+		{
+			name:  "ip ban drops player fields",
+			list:  BannedIPs,
+			entry: Entry{IP: "2001:db8::1", Name: "Griefer", Created: "2025-01-02 03:04:05 +0000", Reason: "spam"},
+			want:  Entry{IP: "2001:db8::1", Created: "2025-01-02 03:04:05 +0000", Source: defaultSource, Expires: forever, Reason: "spam"},
+		},
+		{name: "not an ip", list: BannedIPs, entry: Entry{IP: "example.com"}, wantErr: true},
+		{name: "name too long", list: Whitelist, entry: Entry{Name: "SeventeenLetters1"}, wantErr: true},
+		{name: "name with space", list: Whitelist, entry: Entry{Name: "Steve Two"}, wantErr: true},
+		{name: "missing name", list: Ops, entry: Entry{UUID: "069a79f4-44e9-4726-a5be-fca90e38aaf5"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := tt.entry
+			err := Validate(tt.list, &e)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidEntry) {
+					t.Fatalf("Validate = %v, want ErrInvalidEntry", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e != tt.want {
+				t.Errorf("Validate = %+v, want %+v", e, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCreated(t *testing.T) {
+	e := Entry{Name: "Griefer"}
+	err := Validate(BannedPlayers, &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(e.Created, " +0000") || len(e.Created) != len(dateFormat) {
+		t.Errorf("Created = %q, want the time now in %q", e.Created, dateFormat)
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		list  string
+		entry Entry
+		key   string
+	}{
+		{name: "name", list: Ops, entry: Entry{UUID: "069A79F4-44E9-4726-A5BE-FCA90E38AAF5", Name: "Notch"}, key: "notch"},
+		{name: "uuid without name", list: Whitelist, entry: Entry{UUID: "069A79F4-44E9-4726-A5BE-FCA90E38AAF5"}, key: "069a79f4-44e9-4726-a5be-fca90e38aaf5"},
+		{name: "ipv4", list: BannedIPs, entry: Entry{IP: "10.0.0.1"}, key: "10.0.0.1"},
+		{name: "ipv6 upper case", list: BannedIPs, entry: Entry{IP: "2001:DB8::1"}, key: "2001:db8::1"},
+		{name: "ipv6 long form", list: BannedIPs, entry: Entry{IP: "2001:0db8:0000:0000:0000:0000:0000:0001"}, key: "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Key(tt.list, tt.entry); got != tt.key {
+				t.Errorf("Key = %q, want %q", got, tt.key)
+			}
+			// A key given to remove an entry matches however it is written
+			if got := NormalizeKey(tt.list, strings.ToUpper(tt.key)); got != tt.key {
+				t.Errorf("NormalizeKey(%q) = %q, want %q", strings.ToUpper(tt.key), got, tt.key)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name       string
+		list       string
+		current    []Entry
+		desired    []Entry
+		wantAdd    []Entry
+		wantRemove []Entry
+	}{
+		{
+			name:       "empty",
+			list:       Whitelist,
+			wantAdd:    []Entry{},
+			wantRemove: []Entry{},
+		},
+		{
+			name:       "add and remove",
+			list:       Whitelist,
+			current:    []Entry{{Name: "Steve"}, {Name: "Alex"}},
+			desired:    []Entry{{Name: "Alex"}, {Name: "Notch"}},
+			wantAdd:    []Entry{{Name: "Notch"}},
+			wantRemove: []Entry{{Name: "Steve"}},
+		},
+		{
+			name:       "names differ in case",
+			list:       Ops,
+			current:    []Entry{{Name: "steve", Level: 4}},
+			desired:    []Entry{{Name: "Steve", Level: 4}},
+			wantAdd:    []Entry{},
+			wantRemove: []Entry{},
+		},
+		{
+			name:       "ips written differently",
+			list:       BannedIPs,
+			current:    []Entry{{IP: "2001:db8::1"}, {IP: "10.0.0.1"}},
+			desired:    []Entry{{IP: "2001:DB8:0:0:0:0:0:1"}, {IP: "10.0.0.2"}},
+			wantAdd:    []Entry{{IP: "10.0.0.2"}},
+			wantRemove: []Entry{{IP: "10.0.0.1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			add, remove := Diff(tt.list, tt.current, tt.desired)
+			if !reflect.DeepEqual(add, tt.wantAdd) {
+				t.Errorf("add = %+v, want %+v", add, tt.wantAdd)
+			}
+			if !reflect.DeepEqual(remove, tt.wantRemove) {
+				t.Errorf("remove = %+v, want %+v", remove, tt.wantRemove)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		list  string
+		entry Entry
+		want  string
+	}{
+		{
+			list:  Whitelist,
+			entry: Entry{UUID: "u", Name: "Steve", Level: 4, Reason: "x"},
+			want:  `[{"name":"Steve","uuid":"u"}]`,
+		},
+		{
+			list:  Ops,
+			entry: Entry{UUID: "u", Name: "Steve", Level: 4},
+			want:  `[{"bypassesPlayerLimit":false,"level":4,"name":"Steve","uuid":"u"}]`,
+		},
+		{
+			list:  BannedPlayers,
+			entry: Entry{UUID: "u", Name: "Griefer", Created: "c", Source: "s", Expires: "forever", Reason: "r"},
+			want:  `[{"created":"c","expires":"forever","name":"Griefer","reason":"r","source":"s","uuid":"u"}]`,
+		},
+		{
+			list:  BannedIPs,
+			entry: Entry{IP: "10.0.0.1", Name: "Griefer", Created: "c", Source: "s", Expires: "forever", Reason: "r"},
+			want:  `[{"created":"c","expires":"forever","ip":"10.0.0.1","reason":"r","source":"s"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			b, err := Write(tt.list, []Entry{tt.entry})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Compare compacted, the file itself is indented like the game's
+			var got []map[string]any
+			err = json.Unmarshal(b, &got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			compact, _ := json.Marshal(got)
+			if string(compact) != tt.want {
+				t.Errorf("Write = %s, want %s", compact, tt.want)
+			}
+
+			entries, err := Read(strings.NewReader(string(b)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 || Key(tt.list, entries[0]) != Key(tt.list, tt.entry) {
+				t.Errorf("Read back %+v, want %+v", entries, tt.entry)
+			}
+		})
+	}
+}
+
+func TestWriteEmpty(t *testing.T) {
+	b, err := Write(Whitelist, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "[]" {
+		t.Errorf("Write = %s, want []", b)
+	}
+
+	entries, err := Read(strings.NewReader(" \n"))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Read of a blank file = %v, %v, want an empty list", entries, err)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		list       string
+		entry      Entry
+		wantAdd    string
+		wantRemove string
+	}{
+		{list: Whitelist, entry: Entry{Name: "Steve"}, wantAdd: "whitelist add Steve", wantRemove: "whitelist remove Steve"},
+		{list: Ops, entry: Entry{Name: "Steve", Level: 2}, wantAdd: "op Steve", wantRemove: "deop Steve"},
+		{list: BannedPlayers, entry: Entry{Name: "Griefer", Reason: "Broke the spawn"}, wantAdd: "ban Griefer Broke the spawn", wantRemove: "pardon Griefer"},
+		{list: BannedPlayers, entry: Entry{Name: "Griefer"}, wantAdd: "ban Griefer", wantRemove: "pardon Griefer"},
+		{list: BannedIPs, entry: Entry{IP: "10.0.0.1", Reason: "spam"}, wantAdd: "ban-ip 10.0.0.1 spam", wantRemove: "pardon-ip 10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantAdd, func(t *testing.T) {
+			if got := AddCommand(tt.list, tt.entry); got != tt.wantAdd {
+				t.Errorf("AddCommand = %q, want %q", got, tt.wantAdd)
+			}
+			if got := RemoveCommand(tt.list, tt.entry); got != tt.wantRemove {
+				t.Errorf("RemoveCommand = %q, want %q", got, tt.wantRemove)
+			}
+		})
+	}
+}
+
+func TestOfflineUUID(t *testing.T) {
+	// The UUID an offline mode server gives Notch
+	want := "b50ad385-829d-3141-a216-7e7d7539ba7f"
+	if got := OfflineUUID("Notch"); got != want {
+		t.Errorf("OfflineUUID(Notch) = %s, want %s", got, want)
+	}
+
+	// Offline UUIDs are case sensitive, unlike names
+	if OfflineUUID("notch") == want {
+		t.Error("OfflineUUID gave Notch and notch the same UUID")
+	}
+}
@@ -0,0 +1,70 @@
+package access
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ProfileURL looks up the account of a player name.
+const ProfileURL string = "https://api.mojang.com/users/profiles/minecraft/"
+
+const lookupTimeout = 5 * time.Second
+
+// LookupUUID returns the account UUID of a player name, as servers in
+// online mode use. It fails for names that have no account.
+func LookupUUID(ctx context.Context, name string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ProfileURL+url.PathEscape(name), nil)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up %s: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusNoContent {
+		return "", fmt.Errorf("%w: no account named %s", ErrInvalidEntry, name)
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to look up %s: status %d", name, res.StatusCode)
+	}
+
+	var profile struct {
+		ID string `json:"id"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&profile)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up %s: %w", name, err)
+	}
+
+	b, err := hex.DecodeString(profile.ID)
+	if err != nil || len(b) != 16 {
+		return "", fmt.Errorf("failed to look up %s: unexpected id %q", name, profile.ID)
+	}
+	return formatUUID(b), nil
+}
+
+// OfflineUUID is the UUID a server in offline mode gives a player name, a
+// version 3 UUID of "OfflinePlayer:" and the name.
+func OfflineUUID(name string) string {
+	sum := md5.Sum([]byte("OfflinePlayer:" + name))
+	sum[6] = sum[6]&0x0f | 0x30
+	sum[8] = sum[8]&0x3f | 0x80
+	return formatUUID(sum[:])
+}
+
+func formatUUID(b []byte) string {
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
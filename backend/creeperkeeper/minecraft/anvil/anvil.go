@@ -0,0 +1,248 @@
+// Package anvil reads Minecraft's Anvil region files. Each r.<x>.<z>.mca
+// file holds up to 32x32 chunks behind a header of chunk locations and
+// modification times.
+package anvil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/nbt"
+)
+
+const (
+	SectorSize   int64 = 4096
+	RegionChunks int   = 32
+	HeaderSize   int64 = 2 * SectorSize
+
+	// Blocks per region along each axis
+	RegionBlocks int = RegionChunks * 16
+
+	chunkHeaderSize int64 = 5
+	externalFlag    byte  = 0x80
+)
+
+const (
+	CompressionGzip         byte = 1
+	CompressionZlib         byte = 2
+	CompressionUncompressed byte = 3
+	CompressionLZ4          byte = 4
+	CompressionCustom       byte = 127
+)
+
+var (
+	ErrInvalid       = errors.New("invalid region file")
+	ErrExternalChunk = errors.New("chunk is stored in an external file")
+)
+
+// Chunk is a chunk's entry in the region header. X and Z are relative to
+// the region.
+type Chunk struct {
+	X           int       `json:"x"`
+	Z           int       `json:"z"`
+	Sector      int64     `json:"sector"`
+	Sectors     int       `json:"sectors"`
+	Timestamp   time.Time `json:"timestamp"`
+	Length      int64     `json:"length"`
+	Compression byte      `json:"compression"`
+	External    bool      `json:"external"`
+}
+
+// Region is a parsed region header with its present chunks.
+type Region struct {
+	Size   int64   `json:"size"`
+	Chunks []Chunk `json:"chunks"`
+}
+
+// ParseName returns the region coordinates from a file name like
+// r.-1.2.mca.
+func ParseName(name string) (int, int, bool) {
+	parts := strings.Split(path.Base(name), ".")
+	if len(parts) != 4 || parts[0] != "r" || parts[3] != "mca" {
+		return 0, 0, false
+	}
+
+	x, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	z, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return 0, 0, false
+	}
+	return x, z, true
+}
+
+// ReadRegion reads the header of a region file of the given size and the
+// length and compression of each chunk in it.
+func ReadRegion(r io.ReaderAt, size int64) (*Region, error) {
+	region := &Region{Size: size, Chunks: []Chunk{}}
+	if size == 0 {
+		// The game leaves empty region files behind
+		return region, nil
+	}
+	if size < HeaderSize {
+		return nil, fmt.Errorf("%w: %d bytes is shorter than the header", ErrInvalid, size)
+	}
+
+	header := make([]byte, HeaderSize)
+	_, err := r.ReadAt(header, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	chunkHeader := make([]byte, chunkHeaderSize)
+	for i := 0; i < RegionChunks*RegionChunks; i++ {
+		loc := binary.BigEndian.Uint32(header[i*4:])
+		if loc == 0 {
+			continue
+		}
+
+		c := Chunk{
+			X:         i % RegionChunks,
+			Z:         i / RegionChunks,
+			Sector:    int64(loc >> 8),
+			Sectors:   int(loc & 0xff),
+			Timestamp: time.Unix(int64(binary.BigEndian.Uint32(header[SectorSize+int64(i*4):])), 0).UTC(),
+		}
+
+		offset := c.Sector * SectorSize
+		if c.Sector < 2 || offset+chunkHeaderSize > size {
+			return nil, fmt.Errorf("%w: chunk %d,%d is outside the file", ErrInvalid, c.X, c.Z)
+		}
+
+		_, err = r.ReadAt(chunkHeader, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Length = int64(binary.BigEndian.Uint32(chunkHeader)) - 1
+		c.Compression = chunkHeader[4] &^ externalFlag
+		c.External = chunkHeader[4]&externalFlag != 0
+		if c.Length < 0 || (!c.External && offset+chunkHeaderSize+c.Length > size) {
+			return nil, fmt.Errorf("%w: chunk %d,%d has a bad length", ErrInvalid, c.X, c.Z)
+		}
+
+		region.Chunks = append(region.Chunks, c)
+	}
+
+	return region, nil
+}
+
+// ReadChunk decodes a chunk's NBT. Chunks stored in external .mcc files and
+// LZ4 or custom compression are not supported.
+func ReadChunk(r io.ReaderAt, c Chunk) (nbt.Compound, error) {
+	if c.External {
+		return nil, ErrExternalChunk
+	}
+
+	data := make([]byte, c.Length)
+	_, err := r.ReadAt(data, c.Sector*SectorSize+chunkHeaderSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader = bytes.NewReader(data)
+	switch c.Compression {
+	case CompressionGzip:
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = gz
+	case CompressionZlib:
+		zr, err := zlib.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		body = zr
+	case CompressionUncompressed:
+	default:
+		return nil, fmt.Errorf("unsupported chunk compression %d", c.Compression)
+	}
+
+	_, root, err := nbt.Decode(body)
+	return root, err
+}
+
+// CompressionName names a chunk compression type for reports.
+func CompressionName(compression byte) string {
+	switch compression {
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZlib:
+		return "zlib"
+	case CompressionUncompressed:
+		return "none"
+	case CompressionLZ4:
+		return "lz4"
+	case CompressionCustom:
+		return "custom"
+	default:
+		return "unknown(" + strconv.Itoa(int(compression)) + ")"
+	}
+}
+
+// Index is a chunk's position in the region header.
+func (c Chunk) Index() int {
+	return c.Z*RegionChunks + c.X
+}
+
+// Source is a chunk and the region file it is read from.
+type Source struct {
+	R     io.ReaderAt
+	Chunk Chunk
+}
+
+// Rewrite writes a compacted copy of a region file without the chunks drop
+// selects. Kept chunks are copied byte for byte with their timestamps.
+func Rewrite(r io.ReaderAt, region *Region, drop func(Chunk) bool) ([]byte, error) {
+	chunks := []Source{}
+	for _, c := range region.Chunks {
+		if !drop(c) {
+			chunks = append(chunks, Source{R: r, Chunk: c})
+		}
+	}
+	return Write(chunks)
+}
+
+// Write builds a region file from chunks taken from other region files,
+// copying each byte for byte with its timestamp. No chunks makes an empty
+// file.
+func Write(chunks []Source) ([]byte, error) {
+	if len(chunks) == 0 {
+		return []byte{}, nil
+	}
+
+	out := make([]byte, HeaderSize)
+	sector := HeaderSize / SectorSize
+	for _, src := range chunks {
+		c := src.Chunk
+		if c.Sectors > 0xff || sector > 0xffffff {
+			return nil, fmt.Errorf("%w: region too large to write", ErrInvalid)
+		}
+
+		data := make([]byte, int64(c.Sectors)*SectorSize)
+		n, err := src.R.ReadAt(data, c.Sector*SectorSize)
+		if err != nil && !(err == io.EOF && int64(n) >= chunkHeaderSize+c.Length) {
+			return nil, err
+		}
+
+		binary.BigEndian.PutUint32(out[c.Index()*4:], uint32(sector)<<8|uint32(c.Sectors))
+		binary.BigEndian.PutUint32(out[SectorSize+int64(c.Index()*4):], uint32(c.Timestamp.Unix()))
+		out = append(out, data...)
+		sector += int64(c.Sectors)
+	}
+	return out, nil
+}
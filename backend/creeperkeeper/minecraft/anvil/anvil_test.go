@@ -0,0 +1,237 @@
+package anvil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testTime = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// chunkNBT is a chunk root compound holding only xPos.
+func chunkNBT(x int32) []byte {
+	b := &bytes.Buffer{}
+	b.Write([]byte{0x0a, 0, 0})
+	b.Write([]byte{0x03, 0, 4, 'x', 'P', 'o', 's'})
+	binary.Write(b, binary.BigEndian, x)
+	b.WriteByte(0)
+	return b.Bytes()
+}
+
+func compress(t *testing.T, compression byte, data []byte) []byte {
+	t.Helper()
+	b := &bytes.Buffer{}
+	switch compression {
+	case CompressionGzip:
+		w := gzip.NewWriter(b)
+		w.Write(data)
+		w.Close()
+	case CompressionZlib:
+		w := zlib.NewWriter(b)
+		w.Write(data)
+		w.Close()
+	default:
+		b.Write(data)
+	}
+	return b.Bytes()
+}
+
+// testChunk is a chunk to lay out in a test region file.
+type testChunk struct {
+	x, z        int
+	compression byte
+	data        []byte
+}
+
+// buildRegion writes chunks one sector each, in order, after the header.
+func buildRegion(chunks ...testChunk) []byte {
+	out := make([]byte, HeaderSize)
+	for i, c := range chunks {
+		index := c.z*RegionChunks + c.x
+		sector := uint32(2 + i)
+		binary.BigEndian.PutUint32(out[index*4:], sector<<8|1)
+		binary.BigEndian.PutUint32(out[SectorSize+int64(index*4):], uint32(testTime.Unix()))
+
+		data := make([]byte, SectorSize)
+		binary.BigEndian.PutUint32(data, uint32(len(c.data)+1))
+		data[4] = c.compression
+		copy(data[chunkHeaderSize:], c.data)
+		out = append(out, data...)
+	}
+	return out
+}
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name   string
+		x, z   int
+		wantOK bool
+	}{
+		{name: "r.0.0.mca", wantOK: true},
+		{name: "world/region/r.-1.2.mca", x: -1, z: 2, wantOK: true},
+		{name: "r.0.0.mcr"},
+		{name: "r.a.0.mca"},
+		{name: "r.0.mca"},
+		{name: "c.0.0.mcc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, z, ok := ParseName(tt.name)
+			if ok != tt.wantOK || x != tt.x || z != tt.z {
+				t.Errorf("ParseName(%q) = %d, %d, %v, want %d, %d, %v", tt.name, x, z, ok, tt.x, tt.z, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestReadRegion(t *testing.T) {
+	valid := buildRegion(
+		testChunk{x: 0, z: 0, compression: CompressionUncompressed, data: chunkNBT(0)},
+		testChunk{x: 31, z: 1, compression: CompressionZlib | externalFlag},
+	)
+
+	outside := buildRegion(testChunk{compression: CompressionUncompressed, data: chunkNBT(0)})
+	binary.BigEndian.PutUint32(outside, 9<<8|1)
+
+	badLength := buildRegion(testChunk{compression: CompressionUncompressed, data: chunkNBT(0)})
+	binary.BigEndian.PutUint32(badLength[HeaderSize:], uint32(SectorSize))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []Chunk
+		wantErr error
+	}{
+		{name: "empty file", data: []byte{}, want: []Chunk{}},
+		{
+			name: "chunks",
+			data: valid,
+			want: []Chunk{
+				{X: 0, Z: 0, Sector: 2, Sectors: 1, Timestamp: testTime, Length: int64(len(chunkNBT(0))), Compression: CompressionUncompressed},
+				{X: 31, Z: 1, Sector: 3, Sectors: 1, Timestamp: testTime, Length: 0, Compression: CompressionZlib, External: true},
+			},
+		},
+		{name: "shorter than header", data: make([]byte, SectorSize), wantErr: ErrInvalid},
+		{name: "chunk outside file", data: outside, wantErr: ErrInvalid},
+		{name: "bad length", data: badLength, wantErr: ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			region, err := ReadRegion(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ReadRegion = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(region.Chunks) != len(tt.want) {
+				t.Fatalf("ReadRegion found %d chunks, want %d", len(region.Chunks), len(tt.want))
+			}
+			for i, c := range region.Chunks {
+				if c != tt.want[i] {
+					t.Errorf("chunk %d = %+v, want %+v", i, c, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadChunk(t *testing.T) {
+	tests := []struct {
+		name        string
+		compression byte
+		wantErr     bool
+	}{
+		{name: "gzip", compression: CompressionGzip},
+		{name: "zlib", compression: CompressionZlib},
+		{name: "uncompressed", compression: CompressionUncompressed},
+		{name: "lz4", compression: CompressionLZ4, wantErr: true},
+		{name: "external", compression: CompressionZlib | externalFlag, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := buildRegion(testChunk{x: 5, z: 7, compression: tt.compression, data: compress(t, tt.compression, chunkNBT(5))})
+			r := bytes.NewReader(data)
+			region, err := ReadRegion(r, int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			root, err := ReadChunk(r, region.Chunks[0])
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if root.Int("xPos") != 5 {
+				t.Errorf("xPos = %d, want 5", root.Int("xPos"))
+			}
+		})
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	data := buildRegion(
+		testChunk{x: 0, z: 0, compression: CompressionZlib, data: compress(t, CompressionZlib, chunkNBT(0))},
+		testChunk{x: 1, z: 0, compression: CompressionZlib, data: compress(t, CompressionZlib, chunkNBT(1))},
+		testChunk{x: 2, z: 3, compression: CompressionGzip, data: compress(t, CompressionGzip, chunkNBT(2))},
+	)
+	region, err := ReadRegion(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		drop  func(Chunk) bool
+		wantX []int64
+	}{
+		{name: "keep all", drop: func(Chunk) bool { return false }, wantX: []int64{0, 1, 2}},
+		{name: "drop middle", drop: func(c Chunk) bool { return c.X == 1 }, wantX: []int64{0, 2}},
+		{name: "drop all", drop: func(Chunk) bool { return true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Rewrite(bytes.NewReader(data), region, tt.drop)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := bytes.NewReader(out)
+			rewritten, err := ReadRegion(r, int64(len(out)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rewritten.Chunks) != len(tt.wantX) {
+				t.Fatalf("rewrote %d chunks, want %d", len(rewritten.Chunks), len(tt.wantX))
+			}
+			if want := HeaderSize + int64(len(tt.wantX))*SectorSize; len(tt.wantX) > 0 && int64(len(out)) != want {
+				t.Errorf("rewritten region is %d bytes, want %d", len(out), want)
+			}
+
+			for i, c := range rewritten.Chunks {
+				if !c.Timestamp.Equal(testTime) {
+					t.Errorf("chunk %d,%d timestamp = %v, want %v", c.X, c.Z, c.Timestamp, testTime)
+				}
+				root, err := ReadChunk(r, c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if root.Int("xPos") != tt.wantX[i] {
+					t.Errorf("chunk %d xPos = %d, want %d", i, root.Int("xPos"), tt.wantX[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package anvil
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/nbt"
+)
+
+// dataVersionPackedPerLong is the first data version (20w17a, 1.16) whose
+// block states do not span longs.
+const dataVersionPackedPerLong int64 = 2527
+
+// Blocks are the block states of a chunk, decoded from its sections. Only
+// chunks from 1.13 on, which use section palettes, are supported.
+type Blocks struct {
+	// sections are ordered from the top of the world down
+	sections []section
+}
+
+type section struct {
+	y       int
+	palette []string
+	data    []int64
+	bits    int
+	spans   bool
+}
+
+// ReadBlocks decodes a chunk's block sections.
+func ReadBlocks(root nbt.Compound) *Blocks {
+	dataVersion := root.Int("DataVersion")
+	sections := root.List("sections")
+	if sections == nil {
+		// Before 1.18 chunk data was nested under Level
+		sections = root.Compound("Level").List("Sections")
+	}
+
+	b := &Blocks{}
+	for _, v := range sections {
+		s, ok := v.(nbt.Compound)
+		if !ok {
+			continue
+		}
+
+		states := s.Compound("block_states")
+		paletteTags := states.List("palette")
+		data := states.LongArray("data")
+		if states == nil {
+			paletteTags = s.List("Palette")
+			data = s.LongArray("BlockStates")
+		}
+		if len(paletteTags) == 0 {
+			continue
+		}
+
+		sec := section{
+			y:     int(s.Int("Y")),
+			data:  data,
+			bits:  max(4, bits.Len(uint(len(paletteTags)-1))),
+			spans: dataVersion < dataVersionPackedPerLong,
+		}
+		for _, p := range paletteTags {
+			state, _ := p.(nbt.Compound)
+			sec.palette = append(sec.palette, state.String("Name"))
+		}
+		b.sections = append(b.sections, sec)
+	}
+
+	sort.Slice(b.sections, func(i, j int) bool {
+		return b.sections[i].y > b.sections[j].y
+	})
+	return b
+}
+
+// Top returns the highest block in a column that is not air, and its y.
+func (b *Blocks) Top(x int, z int) (string, int, bool) {
+	return b.TopBelow(x, z, math.MaxInt)
+}
+
+// TopBelow is Top for the part of a column under y.
+func (b *Blocks) TopBelow(x int, z int, y int) (string, int, bool) {
+	for _, s := range b.sections {
+		for by := 15; by >= 0; by-- {
+			if s.y*16+by >= y {
+				continue
+			}
+			name := s.block(x, by, z)
+			if !IsAir(name) {
+				return name, s.y*16 + by, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// TopOpen returns the highest block under y that has air above it, for
+// dimensions with a roof such as the nether.
+func (b *Blocks) TopOpen(x int, z int, y int) (string, int, bool) {
+	for _, s := range b.sections {
+		for by := 15; by >= 0; by-- {
+			if s.y*16+by >= y {
+				continue
+			}
+			if IsAir(s.block(x, by, z)) {
+				return b.TopBelow(x, z, s.y*16+by)
+			}
+		}
+	}
+	return "", 0, false
+}
+
+func (s *section) block(x int, y int, z int) string {
+	if len(s.palette) == 1 || len(s.data) == 0 {
+		return s.palette[0]
+	}
+
+	i := y*256 + z*16 + x
+	mask := int64(1)<<s.bits - 1
+
+	var v int64
+	if s.spans {
+		bit := i * s.bits
+		long, off := bit/64, bit%64
+		if long >= len(s.data) {
+			return ""
+		}
+		v = int64(uint64(s.data[long]) >> off)
+		if off+s.bits > 64 && long+1 < len(s.data) {
+			v |= s.data[long+1] << (64 - off)
+		}
+	} else {
+		perLong := 64 / s.bits
+		long, off := i/perLong, (i%perLong)*s.bits
+		if long >= len(s.data) {
+			return ""
+		}
+		v = int64(uint64(s.data[long]) >> off)
+	}
+
+	v &= mask
+	if int(v) >= len(s.palette) {
+		return ""
+	}
+	return s.palette[v]
+}
+
+// IsAir reports whether a block is one of the air blocks, or unknown.
+func IsAir(name string) bool {
+	switch strings.TrimPrefix(name, "minecraft:") {
+	case "", "air", "cave_air", "void_air", "light", "barrier", "structure_void":
+		return true
+	default:
+		return false
+	}
+}
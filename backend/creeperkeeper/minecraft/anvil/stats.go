@@ -0,0 +1,207 @@
+package anvil
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	Overworld string = "minecraft:overworld"
+	Nether    string = "minecraft:the_nether"
+	End       string = "minecraft:the_end"
+
+	// FolderRegion holds block data; entities and poi use the same format
+	// for entities and points of interest.
+	FolderRegion   string = "region"
+	FolderEntities string = "entities"
+	FolderPOI      string = "poi"
+)
+
+// File is a region file found in a world directory.
+type File struct {
+	Dimension string
+	Folder    string
+	X         int
+	Z         int
+}
+
+// ParsePath places a path relative to the world directory, such as
+// DIM-1/region/r.0.0.mca, in its dimension and folder.
+func ParsePath(rel string) (*File, bool) {
+	parts := strings.Split(rel, "/")
+	if len(parts) < 2 {
+		return nil, false
+	}
+
+	x, z, ok := ParseName(parts[len(parts)-1])
+	if !ok {
+		return nil, false
+	}
+
+	f := &File{
+		Folder: parts[len(parts)-2],
+		X:      x,
+		Z:      z,
+	}
+	if f.Folder != FolderRegion && f.Folder != FolderEntities && f.Folder != FolderPOI {
+		return nil, false
+	}
+
+	dir := parts[:len(parts)-2]
+	switch {
+	case len(dir) == 0:
+		f.Dimension = Overworld
+	case len(dir) == 1 && dir[0] == "DIM-1":
+		f.Dimension = Nether
+	case len(dir) == 1 && dir[0] == "DIM1":
+		f.Dimension = End
+	case len(dir) == 3 && dir[0] == "dimensions":
+		f.Dimension = dir[1] + ":" + dir[2]
+	default:
+		return nil, false
+	}
+	return f, true
+}
+
+// DimensionDir is the directory a dimension's region folders are in,
+// relative to the world directory. It is the reverse of ParsePath.
+func DimensionDir(dimension string) (string, bool) {
+	switch dimension {
+	case Overworld:
+		return "", true
+	case Nether:
+		return "DIM-1/", true
+	case End:
+		return "DIM1/", true
+	}
+
+	ns, name, ok := strings.Cut(dimension, ":")
+	if !ok || ns == "" || name == "" || strings.ContainsAny(dimension, "/\\") || strings.Contains(dimension, "..") {
+		return "", false
+	}
+	return "dimensions/" + ns + "/" + name + "/", true
+}
+
+// DimensionStats summarizes the region files of one dimension.
+type DimensionStats struct {
+	Dimension string `json:"dimension"`
+	Regions   int    `json:"regions"`
+	Chunks    int    `json:"chunks"`
+	// DiskUsage is the size of every region, entities and poi file.
+	DiskUsage   int64            `json:"diskUsage"`
+	FolderUsage map[string]int64 `json:"folderUsage"`
+	Compression map[string]int   `json:"compression"`
+	External    int              `json:"external"`
+	Oldest      *time.Time       `json:"oldest,omitempty"`
+	Newest      *time.Time       `json:"newest,omitempty"`
+}
+
+// Area is a region and when any chunk in it was last saved.
+type Area struct {
+	Dimension   string    `json:"dimension"`
+	RegionX     int       `json:"regionX"`
+	RegionZ     int       `json:"regionZ"`
+	MinBlockX   int       `json:"minBlockX"`
+	MinBlockZ   int       `json:"minBlockZ"`
+	Chunks      int       `json:"chunks"`
+	Size        int64     `json:"size"`
+	LastTouched time.Time `json:"lastTouched"`
+}
+
+// Stats accumulates region files into per dimension totals.
+type Stats struct {
+	dimensions map[string]*DimensionStats
+	areas      []Area
+}
+
+func NewStats() *Stats {
+	return &Stats{dimensions: map[string]*DimensionStats{}}
+}
+
+// AddFile counts a file's size. Only region folder files need parsing, so
+// entities and poi files are added this way alone.
+func (s *Stats) AddFile(f *File, size int64) {
+	d := s.dimension(f.Dimension)
+	d.DiskUsage += size
+	d.FolderUsage[f.Folder] += size
+}
+
+// AddRegion adds the chunks of a parsed region folder file.
+func (s *Stats) AddRegion(f *File, r *Region) {
+	s.AddFile(f, r.Size)
+	d := s.dimension(f.Dimension)
+	d.Regions++
+	d.Chunks += len(r.Chunks)
+
+	if len(r.Chunks) == 0 {
+		return
+	}
+
+	area := Area{
+		Dimension: f.Dimension,
+		RegionX:   f.X,
+		RegionZ:   f.Z,
+		MinBlockX: f.X * RegionBlocks,
+		MinBlockZ: f.Z * RegionBlocks,
+		Chunks:    len(r.Chunks),
+		Size:      r.Size,
+	}
+	for _, c := range r.Chunks {
+		d.Compression[CompressionName(c.Compression)]++
+		if c.External {
+			d.External++
+		}
+
+		ts := c.Timestamp
+		if d.Oldest == nil || ts.Before(*d.Oldest) {
+			d.Oldest = &ts
+		}
+		if d.Newest == nil || ts.After(*d.Newest) {
+			d.Newest = &ts
+		}
+		if ts.After(area.LastTouched) {
+			area.LastTouched = ts
+		}
+	}
+	s.areas = append(s.areas, area)
+}
+
+func (s *Stats) dimension(name string) *DimensionStats {
+	d, ok := s.dimensions[name]
+	if !ok {
+		d = &DimensionStats{
+			Dimension:   name,
+			FolderUsage: map[string]int64{},
+			Compression: map[string]int{},
+		}
+		s.dimensions[name] = d
+	}
+	return d
+}
+
+// Dimensions returns the totals for each dimension, largest first.
+func (s *Stats) Dimensions() []DimensionStats {
+	out := []DimensionStats{}
+	for _, d := range s.dimensions {
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].DiskUsage > out[j].DiskUsage
+	})
+	return out
+}
+
+// LeastRecentlyTouched returns up to n regions whose chunks have gone
+// longest without being saved.
+func (s *Stats) LeastRecentlyTouched(n int) []Area {
+	areas := make([]Area, len(s.areas))
+	copy(areas, s.areas)
+	sort.Slice(areas, func(i, j int) bool {
+		return areas[i].LastTouched.Before(areas[j].LastTouched)
+	})
+	if len(areas) > n {
+		areas = areas[:n]
+	}
+	return areas
+}
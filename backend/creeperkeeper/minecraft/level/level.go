@@ -0,0 +1,112 @@
+// Package level reads world metadata from a world's level.dat.
+package level
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/nbt"
+)
+
+var difficulties = []string{"peaceful", "easy", "normal", "hard"}
+
+type Spawn struct {
+	X int64 `json:"x"`
+	Y int64 `json:"y"`
+	Z int64 `json:"z"`
+}
+
+// Info is the world metadata kept in level.dat.
+type Info struct {
+	Name        string            `json:"name"`
+	Seed        int64             `json:"seed,string"`
+	Version     string            `json:"version,omitempty"`
+	DataVersion int64             `json:"dataVersion"`
+	Spawn       Spawn             `json:"spawn"`
+	Difficulty  string            `json:"difficulty"`
+	Hardcore    bool              `json:"hardcore"`
+	GameRules   map[string]string `json:"gameRules"`
+	// TimePlayed is the world's age in game ticks, 20 to the second.
+	TimePlayed int64 `json:"timePlayed"`
+	DayTime    int64 `json:"dayTime"`
+}
+
+// Read parses a level.dat, compressed or not.
+func Read(r io.Reader) (*Info, error) {
+	_, root, err := nbt.Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read level.dat: %w", err)
+	}
+
+	data := root.Compound("Data")
+	if data == nil {
+		return nil, fmt.Errorf("%w: level.dat has no Data", nbt.ErrInvalid)
+	}
+
+	info := &Info{
+		Name:        data.String("LevelName"),
+		Version:     data.Compound("Version").String("Name"),
+		DataVersion: data.Int("DataVersion"),
+		Spawn:       spawn(data),
+		Difficulty:  difficulty(data),
+		Hardcore:    data.Bool("hardcore"),
+		GameRules:   gameRules(data.Compound("GameRules")),
+		TimePlayed:  data.Int("Time"),
+		DayTime:     data.Int("DayTime"),
+	}
+
+	// The seed moved into WorldGenSettings in 1.16
+	if settings := data.Compound("WorldGenSettings"); settings.Has("seed") {
+		info.Seed = settings.Int("seed")
+	} else {
+		info.Seed = data.Int("RandomSeed")
+	}
+
+	return info, nil
+}
+
+func spawn(data nbt.Compound) Spawn {
+	// Newer versions keep the spawn as a compound with a packed position
+	if s := data.Compound("spawn"); s != nil {
+		if pos := s.IntArray("pos"); len(pos) == 3 {
+			return Spawn{X: int64(pos[0]), Y: int64(pos[1]), Z: int64(pos[2])}
+		}
+	}
+	return Spawn{
+		X: data.Int("SpawnX"),
+		Y: data.Int("SpawnY"),
+		Z: data.Int("SpawnZ"),
+	}
+}
+
+func difficulty(data nbt.Compound) string {
+	d := data.Int("Difficulty")
+	if settings := data.Compound("difficulty_settings"); settings != nil {
+		name := settings.String("difficulty")
+		if name != "" {
+			return name
+		}
+	}
+	if d < 0 || int(d) >= len(difficulties) {
+		return strconv.FormatInt(d, 10)
+	}
+	return difficulties[d]
+}
+
+// gameRules flattens game rules to strings. They were stored as strings
+// until recent versions, which store them as typed values.
+func gameRules(rules nbt.Compound) map[string]string {
+	out := map[string]string{}
+	for name, v := range rules {
+		switch v := v.(type) {
+		case string:
+			out[name] = v
+		case int8:
+			out[name] = strconv.FormatBool(v != 0)
+		default:
+			out[name] = fmt.Sprint(v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package nbt
+
+// Getters return the zero value when a tag is missing or has another type.
+// Integer getters accept any integer tag, since the width of some tags has
+// changed between Minecraft versions.
+
+func (c Compound) Compound(name string) Compound {
+	v, _ := c[name].(Compound)
+	return v
+}
+
+func (c Compound) List(name string) []any {
+	v, _ := c[name].([]any)
+	return v
+}
+
+func (c Compound) String(name string) string {
+	v, _ := c[name].(string)
+	return v
+}
+
+func (c Compound) Int(name string) int64 {
+	switch v := c[name].(type) {
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	default:
+		return 0
+	}
+}
+
+func (c Compound) Float(name string) float64 {
+	switch v := c[name].(type) {
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	default:
+		return float64(c.Int(name))
+	}
+}
+
+func (c Compound) Bool(name string) bool {
+	return c.Int(name) != 0
+}
+
+func (c Compound) LongArray(name string) []int64 {
+	v, _ := c[name].([]int64)
+	return v
+}
+
+func (c Compound) IntArray(name string) []int32 {
+	v, _ := c[name].([]int32)
+	return v
+}
+
+func (c Compound) Has(name string) bool {
+	_, ok := c[name]
+	return ok
+}
@@ -0,0 +1,311 @@
+// Package nbt decodes Minecraft's Named Binary Tag format, as used by
+// level.dat, player data and region file chunks.
+//
+// Tags decode to plain Go values: int8, int16, int32, int64, float32,
+// float64, []byte, string, []any for lists, Compound, []int32 and []int64.
+package nbt
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf8"
+)
+
+const (
+	TagEnd byte = iota
+	TagByte
+	TagShort
+	TagInt
+	TagLong
+	TagFloat
+	TagDouble
+	TagByteArray
+	TagString
+	TagList
+	TagCompound
+	TagIntArray
+	TagLongArray
+)
+
+const (
+	// maxDepth and maxLength bound nesting and array sizes so a corrupt file
+	// cannot exhaust the stack or memory.
+	maxDepth  int   = 512
+	maxLength int32 = 1 << 24
+
+	gzipMagic byte = 0x1f
+	zlibMagic byte = 0x78
+)
+
+var ErrInvalid = errors.New("invalid nbt")
+
+// Compound is a set of named tags.
+type Compound map[string]any
+
+// Read decodes a named root tag, detecting whether the data is gzip or zlib
+// compressed or raw.
+func Read(r io.Reader) (string, Compound, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(1)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch magic[0] {
+	case gzipMagic:
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return "", nil, err
+		}
+		defer gz.Close()
+		return Decode(gz)
+	case zlibMagic:
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return "", nil, err
+		}
+		defer zr.Close()
+		return Decode(zr)
+	default:
+		return Decode(br)
+	}
+}
+
+// Decode decodes an uncompressed named root tag, which must be a compound.
+func Decode(r io.Reader) (string, Compound, error) {
+	d := &decoder{r: bufio.NewReader(r)}
+
+	tag, err := d.byte()
+	if err != nil {
+		return "", nil, err
+	}
+	if tag != TagCompound {
+		return "", nil, fmt.Errorf("%w: root tag is %d, not a compound", ErrInvalid, tag)
+	}
+
+	name, err := d.string()
+	if err != nil {
+		return "", nil, err
+	}
+
+	v, err := d.payload(TagCompound, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, v.(Compound), nil
+}
+
+type decoder struct {
+	r   *bufio.Reader
+	buf [8]byte
+}
+
+func (d *decoder) payload(tag byte, depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%w: nested too deep", ErrInvalid)
+	}
+
+	switch tag {
+	case TagByte:
+		b, err := d.byte()
+		return int8(b), err
+	case TagShort:
+		return d.short()
+	case TagInt:
+		return d.int()
+	case TagLong:
+		return d.long()
+	case TagFloat:
+		v, err := d.int()
+		return math.Float32frombits(uint32(v)), err
+	case TagDouble:
+		v, err := d.long()
+		return math.Float64frombits(uint64(v)), err
+	case TagByteArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(d.r, b)
+		return b, err
+	case TagString:
+		return d.string()
+	case TagList:
+		return d.list(depth)
+	case TagCompound:
+		return d.compound(depth)
+	case TagIntArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		a := make([]int32, n)
+		for i := range a {
+			a[i], err = d.int()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	case TagLongArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		a := make([]int64, n)
+		for i := range a {
+			a[i], err = d.long()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown tag %d", ErrInvalid, tag)
+	}
+}
+
+func (d *decoder) list(depth int) ([]any, error) {
+	tag, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := d.length()
+	if err != nil {
+		return nil, err
+	}
+
+	// Empty lists may be typed as TagEnd
+	if tag == TagEnd && n > 0 {
+		return nil, fmt.Errorf("%w: list of end tags", ErrInvalid)
+	}
+
+	list := make([]any, 0, min(n, 1024))
+	for i := int32(0); i < n; i++ {
+		v, err := d.payload(tag, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+func (d *decoder) compound(depth int) (Compound, error) {
+	c := Compound{}
+	for {
+		tag, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if tag == TagEnd {
+			return c, nil
+		}
+
+		name, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+
+		c[name], err = d.payload(tag, depth+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (d *decoder) byte() (byte, error) {
+	b, err := d.r.ReadByte()
+	if err == io.EOF {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return b, err
+}
+
+func (d *decoder) short() (int16, error) {
+	_, err := io.ReadFull(d.r, d.buf[:2])
+	return int16(binary.BigEndian.Uint16(d.buf[:2])), err
+}
+
+func (d *decoder) int() (int32, error) {
+	_, err := io.ReadFull(d.r, d.buf[:4])
+	return int32(binary.BigEndian.Uint32(d.buf[:4])), err
+}
+
+func (d *decoder) long() (int64, error) {
+	_, err := io.ReadFull(d.r, d.buf[:8])
+	return int64(binary.BigEndian.Uint64(d.buf[:8])), err
+}
+
+func (d *decoder) length() (int32, error) {
+	n, err := d.int()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n > maxLength {
+		return 0, fmt.Errorf("%w: bad length %d", ErrInvalid, n)
+	}
+	return n, nil
+}
+
+func (d *decoder) string() (string, error) {
+	n, err := d.short()
+	if err != nil {
+		return "", err
+	}
+
+	b := make([]byte, uint16(n))
+	_, err = io.ReadFull(d.r, b)
+	if err != nil {
+		return "", err
+	}
+	return decodeMUTF8(b), nil
+}
+
+// decodeMUTF8 converts Java's modified UTF-8, which encodes NUL as two bytes
+// and characters outside the BMP as surrogate pairs, to UTF-8.
+func decodeMUTF8(b []byte) string {
+	if utf8.Valid(b) && !bytes.Contains(b, []byte{0xc0, 0x80}) {
+		return string(b)
+	}
+
+	var units []uint16
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c < 0x80:
+			units = append(units, uint16(c))
+			i++
+		case c&0xe0 == 0xc0 && i+1 < len(b):
+			units = append(units, uint16(c&0x1f)<<6|uint16(b[i+1]&0x3f))
+			i += 2
+		case c&0xf0 == 0xe0 && i+2 < len(b):
+			units = append(units, uint16(c&0x0f)<<12|uint16(b[i+1]&0x3f)<<6|uint16(b[i+2]&0x3f))
+			i += 3
+		default:
+			units = append(units, utf8.RuneError)
+			i++
+		}
+	}
+
+	var sb bytes.Buffer
+	for i := 0; i < len(units); i++ {
+		u := units[i]
+		if u >= 0xd800 && u < 0xdc00 && i+1 < len(units) && units[i+1] >= 0xdc00 && units[i+1] < 0xe000 {
+			sb.WriteRune(rune(u-0xd800)<<10 | rune(units[i+1]-0xdc00) + 0x10000)
+			i++
+			continue
+		}
+		sb.WriteRune(rune(u))
+	}
+	return sb.String()
+}
@@ -0,0 +1,226 @@
+package nbt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// tag encodes a named tag of type typ with an already encoded payload.
+func tag(typ byte, name string, payload ...[]byte) []byte {
+	b := &bytes.Buffer{}
+	b.WriteByte(typ)
+	b.Write(str(name))
+	for _, p := range payload {
+		b.Write(p)
+	}
+	return b.Bytes()
+}
+
+// root wraps tags in an unnamed root compound.
+func root(tags ...[]byte) []byte {
+	return tag(TagCompound, "", append(tags, []byte{TagEnd})...)
+}
+
+func str(s string) []byte {
+	return append(be(uint16(len(s))), s...)
+}
+
+func be(v any) []byte {
+	b := &bytes.Buffer{}
+	binary.Write(b, binary.BigEndian, v)
+	return b.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Compound
+	}{
+		{
+			name: "scalars",
+			data: root(
+				tag(TagByte, "b", []byte{0xff}),
+				tag(TagShort, "s", be(int16(-2))),
+				tag(TagInt, "i", be(int32(3953))),
+				tag(TagLong, "l", be(int64(-1)<<40)),
+				tag(TagFloat, "f", be(math.Float32bits(1.5))),
+				tag(TagDouble, "d", be(math.Float64bits(-0.25))),
+				tag(TagString, "str", str("RedCraft")),
+			),
+			want: Compound{
+				"b":   int8(-1),
+				"s":   int16(-2),
+				"i":   int32(3953),
+				"l":   int64(-1) << 40,
+				"f":   float32(1.5),
+				"d":   float64(-0.25),
+				"str": "RedCraft",
+			},
+		},
+		{
+			name: "arrays",
+			data: root(
+				tag(TagByteArray, "ba", be(int32(3)), []byte{1, 2, 3}),
+				tag(TagIntArray, "ia", be(int32(2)), be([]int32{-1, 7})),
+				tag(TagLongArray, "la", be(int32(1)), be([]int64{1 << 62})),
+			),
+			want: Compound{
+				"ba": []byte{1, 2, 3},
+				"ia": []int32{-1, 7},
+				"la": []int64{1 << 62},
+			},
+		},
+		{
+			name: "nested",
+			data: root(
+				tag(TagCompound, "Data",
+					tag(TagString, "LevelName", str("world")),
+					[]byte{TagEnd},
+				),
+				tag(TagList, "Pos", []byte{TagDouble}, be(int32(2)), be(math.Float64bits(1)), be(math.Float64bits(2))),
+				tag(TagList, "empty", []byte{TagEnd}, be(int32(0))),
+			),
+			want: Compound{
+				"Data":  Compound{"LevelName": "world"},
+				"Pos":   []any{float64(1), float64(2)},
+				"empty": []any{},
+			},
+		},
+		{
+			name: "modified utf-8",
+			data: root(
+				// NUL is two bytes, and U+1F600 a surrogate pair.
+				tag(TagString, "nul", be(uint16(4)), []byte{'a', 0xc0, 0x80, 'b'}),
+				tag(TagString, "pair", be(uint16(6)), []byte{0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80}),
+			),
+			want: Compound{
+				"nul":  "a\x00b",
+				"pair": "\U0001f600",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, err := Decode(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	deep := &bytes.Buffer{}
+	deep.Write(tag(TagCompound, ""))
+	for i := 0; i <= maxDepth; i++ {
+		deep.Write(tag(TagCompound, "c"))
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{name: "empty", data: nil, wantErr: io.ErrUnexpectedEOF},
+		{name: "root not a compound", data: tag(TagInt, "", be(int32(1))), wantErr: ErrInvalid},
+		{name: "unknown tag", data: root(tag(13, "x")), wantErr: ErrInvalid},
+		{name: "negative length", data: root(tag(TagByteArray, "x", be(int32(-1)))), wantErr: ErrInvalid},
+		{name: "length too large", data: root(tag(TagIntArray, "x", be(maxLength+1))), wantErr: ErrInvalid},
+		{name: "list of end tags", data: root(tag(TagList, "x", []byte{TagEnd}, be(int32(1)))), wantErr: ErrInvalid},
+		{name: "truncated", data: root(tag(TagLong, "x", []byte{0, 0})), wantErr: io.ErrUnexpectedEOF},
+		{name: "unterminated compound", data: tag(TagCompound, "", tag(TagByte, "x", []byte{1})), wantErr: io.ErrUnexpectedEOF},
+		{name: "nested too deep", data: deep.Bytes(), wantErr: ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Decode(bytes.NewReader(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decode = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	raw := tag(TagCompound, "level", tag(TagInt, "DataVersion", be(int32(3953))), []byte{TagEnd})
+
+	gz := &bytes.Buffer{}
+	gw := gzip.NewWriter(gz)
+	gw.Write(raw)
+	gw.Close()
+
+	zl := &bytes.Buffer{}
+	zw := zlib.NewWriter(zl)
+	zw.Write(raw)
+	zw.Close()
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "raw", data: raw},
+		{name: "gzip", data: gz.Bytes()},
+		{name: "zlib", data: zl.Bytes()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, c, err := Read(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != "level" || c.Int("DataVersion") != 3953 {
+				t.Errorf("Read = %q %v, want level with DataVersion 3953", name, c)
+			}
+		})
+	}
+}
+
+func TestCompoundGetters(t *testing.T) {
+	c := Compound{
+		"byte":   int8(1),
+		"short":  int16(-2),
+		"int":    int32(3),
+		"long":   int64(4),
+		"float":  float32(0.5),
+		"string": "s",
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{name: "byte as int", got: c.Int("byte"), want: int64(1)},
+		{name: "short as int", got: c.Int("short"), want: int64(-2)},
+		{name: "int as int", got: c.Int("int"), want: int64(3)},
+		{name: "long as int", got: c.Int("long"), want: int64(4)},
+		{name: "string as int", got: c.Int("string"), want: int64(0)},
+		{name: "missing int", got: c.Int("missing"), want: int64(0)},
+		{name: "float", got: c.Float("float"), want: 0.5},
+		{name: "int as float", got: c.Float("int"), want: float64(3)},
+		{name: "byte as bool", got: c.Bool("byte"), want: true},
+		{name: "missing bool", got: c.Bool("missing"), want: false},
+		{name: "string", got: c.String("string"), want: "s"},
+		{name: "int as string", got: c.String("int"), want: ""},
+		{name: "missing compound", got: c.Compound("missing") == nil, want: true},
+		{name: "has", got: c.Has("long"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %#v, want %#v", tt.got, tt.want)
+			}
+		})
+	}
+}
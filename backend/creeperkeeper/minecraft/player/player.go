@@ -0,0 +1,188 @@
+// Package player reads per-player files from a world: playerdata NBT,
+// statistics JSON and the server's usercache.json.
+package player
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/anvil"
+	"github.com/hnucamendi/creeper-keeper/minecraft/nbt"
+)
+
+var gameModes = []string{"survival", "creative", "adventure", "spectator"}
+
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Data is what a player's playerdata file says about them when they last
+// logged out or the world was last saved.
+type Data struct {
+	Position  Position `json:"position"`
+	Dimension string   `json:"dimension"`
+	Health    float64  `json:"health"`
+	XPLevel   int64    `json:"xpLevel"`
+	GameMode  string   `json:"gameMode"`
+}
+
+// Read parses a playerdata/<uuid>.dat file, compressed or not.
+func Read(r io.Reader) (*Data, error) {
+	_, root, err := nbt.Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read player data: %w", err)
+	}
+
+	d := &Data{
+		Dimension: dimension(root),
+		Health:    root.Float("Health"),
+		XPLevel:   root.Int("XpLevel"),
+		GameMode:  gameMode(root.Int("playerGameType")),
+	}
+
+	pos := root.List("Pos")
+	if len(pos) == 3 {
+		d.Position = Position{X: float(pos[0]), Y: float(pos[1]), Z: float(pos[2])}
+	}
+	return d, nil
+}
+
+// dimension names the player's dimension. It was stored as a number until
+// 1.16.
+func dimension(root nbt.Compound) string {
+	if name := root.String("Dimension"); name != "" {
+		return name
+	}
+
+	switch root.Int("Dimension") {
+	case -1:
+		return anvil.Nether
+	case 1:
+		return anvil.End
+	default:
+		return anvil.Overworld
+	}
+}
+
+func gameMode(mode int64) string {
+	if mode < 0 || int(mode) >= len(gameModes) {
+		return strconv.FormatInt(mode, 10)
+	}
+	return gameModes[mode]
+}
+
+func float(v any) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case float32:
+		return float64(v)
+	default:
+		return 0
+	}
+}
+
+// Stats are the statistics the game keeps for a player that are useful for
+// leaderboards. PlayTime is in ticks, 20 to the second.
+type Stats struct {
+	PlayTime    int64 `json:"playTime"`
+	Deaths      int64 `json:"deaths"`
+	MobKills    int64 `json:"mobKills"`
+	PlayerKills int64 `json:"playerKills"`
+}
+
+// ReadStats parses a stats/<uuid>.json file. Since 1.13 statistics are
+// grouped by type, custom ones under minecraft:custom. Older files are a
+// flat map of stat.* names.
+func ReadStats(r io.Reader) (*Stats, error) {
+	var file struct {
+		Stats map[string]map[string]int64 `json:"stats"`
+	}
+	var legacy map[string]any
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats: %w", err)
+	}
+
+	if file.Stats != nil {
+		custom := file.Stats["minecraft:custom"]
+		// play_one_minute was renamed play_time in 1.17, but was always ticks
+		playTime := custom["minecraft:play_time"]
+		if playTime == 0 {
+			playTime = custom["minecraft:play_one_minute"]
+		}
+		return &Stats{
+			PlayTime:    playTime,
+			Deaths:      custom["minecraft:deaths"],
+			MobKills:    custom["minecraft:mob_kills"],
+			PlayerKills: custom["minecraft:player_kills"],
+		}, nil
+	}
+
+	err = json.Unmarshal(b, &legacy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats: %w", err)
+	}
+	return &Stats{
+		PlayTime:    legacyStat(legacy, "stat.playOneMinute"),
+		Deaths:      legacyStat(legacy, "stat.deaths"),
+		MobKills:    legacyStat(legacy, "stat.mobKills"),
+		PlayerKills: legacyStat(legacy, "stat.playerKills"),
+	}, nil
+}
+
+func legacyStat(stats map[string]any, name string) int64 {
+	v, _ := stats[name].(float64)
+	return int64(v)
+}
+
+// ReadUserCache maps player UUIDs to the names in a usercache.json. The
+// file records the last name the server saw each player use.
+func ReadUserCache(r io.Reader) (map[string]string, error) {
+	var entries []struct {
+		Name string `json:"name"`
+		UUID string `json:"uuid"`
+	}
+	err := json.NewDecoder(r).Decode(&entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usercache.json: %w", err)
+	}
+
+	names := map[string]string{}
+	for _, e := range entries {
+		names[strings.ToLower(e.UUID)] = e.Name
+	}
+	return names, nil
+}
+
+// ParseUUID checks a player UUID in its dashed form and returns it lower
+// cased, the way the game names player files.
+func ParseUUID(s string) (string, bool) {
+	s = strings.ToLower(s)
+	if len(s) != 36 {
+		return "", false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return "", false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdef", c) {
+				return "", false
+			}
+		}
+	}
+	return s, true
+}
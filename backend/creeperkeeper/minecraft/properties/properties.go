@@ -0,0 +1,267 @@
+// Package properties reads and writes Java .properties files such as
+// server.properties. Comments, blank lines and the order of keys are kept,
+// so a file read and written back without changes is unchanged.
+package properties
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// File is a parsed properties file.
+type File struct {
+	lines []line
+}
+
+// line is one logical line of the file. raw is the original text, including
+// any continuation lines, and is written back as is unless the entry is set.
+type line struct {
+	raw   string
+	entry bool
+	key   string
+	value string
+}
+
+// Parse reads a properties file. Keys and values are unescaped, and lines
+// ending in an odd number of backslashes continue on the next line.
+func Parse(r io.Reader) (*File, error) {
+	f := &File{}
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var raw []string
+	var logical strings.Builder
+	for s.Scan() {
+		text := strings.TrimSuffix(s.Text(), "\r")
+		if len(raw) > 0 {
+			text = strings.TrimLeftFunc(text, isSpace)
+		}
+		raw = append(raw, s.Text())
+
+		comment := len(raw) == 1 && isComment(text)
+		if !comment && continues(text) {
+			logical.WriteString(text[:len(text)-1])
+			continue
+		}
+		logical.WriteString(text)
+
+		f.lines = append(f.lines, parseLine(strings.Join(raw, "\n"), logical.String()))
+		raw = nil
+		logical.Reset()
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read properties: %w", err)
+	}
+	if len(raw) > 0 {
+		f.lines = append(f.lines, parseLine(strings.Join(raw, "\n"), logical.String()))
+	}
+	return f, nil
+}
+
+func parseLine(raw string, logical string) line {
+	text := strings.TrimLeftFunc(logical, isSpace)
+	if text == "" || isComment(text) {
+		return line{raw: raw}
+	}
+
+	// The key ends at the first unescaped separator or whitespace
+	end := len(text)
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+			continue
+		}
+		if text[i] == '=' || text[i] == ':' || isSpace(rune(text[i])) {
+			end = i
+			break
+		}
+	}
+
+	rest := strings.TrimLeftFunc(text[end:], isSpace)
+	if strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ":") {
+		rest = strings.TrimLeftFunc(rest[1:], isSpace)
+	}
+
+	return line{
+		raw:   raw,
+		entry: true,
+		key:   unescape(text[:end]),
+		value: unescape(rest),
+	}
+}
+
+func isComment(text string) bool {
+	text = strings.TrimLeftFunc(text, isSpace)
+	return strings.HasPrefix(text, "#") || strings.HasPrefix(text, "!")
+}
+
+func continues(text string) bool {
+	n := 0
+	for i := len(text) - 1; i >= 0 && text[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\f'
+}
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i == len(s)-1 {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if r, ok := hexRune(s, i+1); ok {
+				i += 4
+				// Characters outside the BMP are written as surrogate pairs
+				if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], `\u`) {
+					if low, ok := hexRune(s, i+3); ok {
+						if pair := utf16.DecodeRune(r, low); pair != unicode.ReplacementChar {
+							r = pair
+							i += 6
+						}
+					}
+				}
+				b.WriteRune(r)
+				continue
+			}
+			b.WriteByte('u')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func hexRune(s string, i int) (rune, bool) {
+	if i+4 > len(s) {
+		return 0, false
+	}
+	r, err := strconv.ParseUint(s[i:i+4], 16, 16)
+	return rune(r), err == nil
+}
+
+// escape writes s the way Java's Properties.store does, so files stay
+// readable by servers that load them as ISO-8859-1.
+func escape(s string, key bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == '=' || r == ':' || r == '#' || r == '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == ' ' && (key || i == 0):
+			b.WriteString(`\ `)
+		case r < 0x20 || r > 0x7e:
+			if r > 0xffff {
+				r1, r2 := utf16.EncodeRune(r)
+				fmt.Fprintf(&b, `\u%04X\u%04X`, r1, r2)
+				continue
+			}
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Get returns the value of key. As in Java, the last entry for a key wins.
+func (f *File) Get(key string) (string, bool) {
+	for i := len(f.lines) - 1; i >= 0; i-- {
+		if f.lines[i].entry && f.lines[i].key == key {
+			return f.lines[i].value, true
+		}
+	}
+	return "", false
+}
+
+// Set changes every entry for key to value, or adds the key at the end of
+// the file when it is not there.
+func (f *File) Set(key string, value string) {
+	raw := escape(key, true) + "=" + escape(value, false)
+
+	found := false
+	for i := range f.lines {
+		l := &f.lines[i]
+		if l.entry && l.key == key {
+			l.raw = raw
+			l.value = value
+			found = true
+		}
+	}
+	if !found {
+		f.lines = append(f.lines, line{raw: raw, entry: true, key: key, value: value})
+	}
+}
+
+// Keys returns the keys in the order they first appear.
+func (f *File) Keys() []string {
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, l := range f.lines {
+		if l.entry && !seen[l.key] {
+			seen[l.key] = true
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+// Map returns every key with its value.
+func (f *File) Map() map[string]string {
+	m := map[string]string{}
+	for _, l := range f.lines {
+		if l.entry {
+			m[l.key] = l.value
+		}
+	}
+	return m
+}
+
+// Bytes writes the file out, one line per logical line with each ending in
+// a newline.
+func (f *File) Bytes() []byte {
+	var b bytes.Buffer
+	for _, l := range f.lines {
+		b.WriteString(l.raw)
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,153 @@
+package properties
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want map[string]string
+	}{
+		{name: "equals", in: "motd=A Minecraft Server\n", want: map[string]string{"motd": "A Minecraft Server"}},
+		{name: "colon and spaces", in: "  pvp : true\n", want: map[string]string{"pvp": "true"}},
+		{name: "whitespace separator", in: "max-players 20\n", want: map[string]string{"max-players": "20"}},
+		{name: "empty value", in: "level-seed=\n", want: map[string]string{"level-seed": ""}},
+		{name: "comments and blank lines", in: "#Minecraft server properties\n! also a comment\n\nwhite-list=false\n", want: map[string]string{"white-list": "false"}},
+		{name: "escaped separator in key", in: `a\=b\:c=d` + "\n", want: map[string]string{"a=b:c": "d"}},
+		{name: "escapes", in: `motd=Tab\there\nline\\slash` + "\n", want: map[string]string{"motd": "Tab\there\nline\\slash"}},
+		{name: "unicode escape", in: `motd=\u00A7aGreen` + "\n", want: map[string]string{"motd": "§aGreen"}},
+		{name: "surrogate pair", in: `motd=\uD83D\uDE00` + "\n", want: map[string]string{"motd": "😀"}},
+		{name: "bad unicode escape", in: `motd=\uZZ` + "\n", want: map[string]string{"motd": "uZZ"}},
+		{name: "continuation", in: "motd=Hello \\\n    World\n", want: map[string]string{"motd": "Hello World"}},
+		{name: "escaped backslash at end", in: "motd=C:\\\\\npvp=true\n", want: map[string]string{"motd": `C:\`, "pvp": "true"}},
+		{name: "comment ending in backslash", in: "# not continued \\\npvp=true\n", want: map[string]string{"pvp": "true"}},
+		{name: "crlf", in: "pvp=true\r\nmotd=Hi\r\n", want: map[string]string{"pvp": "true", "motd": "Hi"}},
+		{name: "no trailing newline", in: "pvp=true", want: map[string]string{"pvp": "true"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(strings.NewReader(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Map(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnchangedRoundTrip(t *testing.T) {
+	in := "#Minecraft server properties\n#Sat Jan 04 12:00:00 UTC 2025\n" +
+		"motd=\\u00A7aTamo\\: Server\n" +
+		"level-name=RedCraft\n" +
+		"\n" +
+		"generator-settings={} \\\n    \n" +
+		"pvp:true\n"
+
+	f, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(f.Bytes()); got != in {
+		t.Errorf("Bytes() = %q, want the file unchanged %q", got, in)
+	}
+}
+
+func TestSetRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		value    string
+		wantLine string
+	}{
+		{name: "plain", key: "motd", value: "Hello", wantLine: "motd=Hello"},
+		{name: "separators", key: "motd", value: "a=b:c#d!e", wantLine: `motd=a\=b\:c\#d\!e`},
+		{name: "leading space", key: "motd", value: " padded ", wantLine: `motd=\ padded `},
+		{name: "control characters", key: "motd", value: "a\tb\nc\\", wantLine: `motd=a\tb\nc\\`},
+		{name: "non ascii", key: "motd", value: "§aGrün", wantLine: `motd=\u00A7aGr\u00FCn`},
+		{name: "outside bmp", key: "motd", value: "😀", wantLine: `motd=\uD83D\uDE00`},
+		{name: "key with space", key: "a key", value: "v", wantLine: `a\ key=v`},
+		{name: "empty", key: "level-seed", value: "", wantLine: "level-seed="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(strings.NewReader("#comment\nmotd=old\npvp=true\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Set(tt.key, tt.value)
+
+			out := string(f.Bytes())
+			if !strings.Contains(out, "\n"+tt.wantLine+"\n") {
+				t.Errorf("Bytes() = %q, want a line %q", out, tt.wantLine)
+			}
+			if !strings.HasPrefix(out, "#comment\n") || !strings.Contains(out, "\npvp=true\n") {
+				t.Errorf("Bytes() = %q, want the other lines kept", out)
+			}
+
+			reparsed, err := Parse(strings.NewReader(out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := reparsed.Get(tt.key); got != tt.value {
+				t.Errorf("value read back = %q, want %q", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestGetLastWins(t *testing.T) {
+	f, err := Parse(strings.NewReader("pvp=true\nmotd=Hi\npvp=false\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := f.Get("pvp"); v != "false" {
+		t.Errorf("Get(pvp) = %q, want the last entry false", v)
+	}
+	if keys := f.Keys(); !reflect.DeepEqual(keys, []string{"pvp", "motd"}) {
+		t.Errorf("Keys() = %v, want [pvp motd]", keys)
+	}
+
+	f.Set("pvp", "true")
+	if got := string(f.Bytes()); got != "pvp=true\nmotd=Hi\npvp=true\n" {
+		t.Errorf("Set changed the file to %q, want every pvp entry set", got)
+	}
+}
+
+func TestValidateServer(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		want    string
+		wantErr error
+	}{
+		{key: "pvp", value: "TRUE", want: "true"},
+		{key: "pvp", value: "yes", wantErr: ErrValue},
+		{key: "max-players", value: "020", want: "20"},
+		{key: "view-distance", value: "2", wantErr: ErrValue},
+		{key: "difficulty", value: "Hard", want: "hard"},
+		{key: "difficulty", value: "1", want: "easy"},
+		{key: "difficulty", value: "4", wantErr: ErrValue},
+		{key: "motd", value: "two\nlines", wantErr: ErrValue},
+		{key: "level-name", value: "world", wantErr: ErrManagedKey},
+		{key: "level-seed", value: "RedCraft", wantErr: ErrManagedKey},
+		{key: "not-a-property", value: "x", wantErr: ErrUnknownKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			got, err := ValidateServer(tt.key, tt.value)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateServer = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ValidateServer = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
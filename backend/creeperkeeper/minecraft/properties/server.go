@@ -0,0 +1,178 @@
+package properties
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ServerFile is the name of the Minecraft server's properties file.
+const ServerFile string = "server.properties"
+
+var (
+	ErrUnknownKey = errors.New("unknown server property")
+	ErrManagedKey = errors.New("server property is managed by creeper keeper")
+	ErrValue      = errors.New("invalid server property value")
+)
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindBool
+	kindInt
+	kindEnum
+)
+
+type property struct {
+	kind     kind
+	min, max int64
+	values   []string
+}
+
+func boolean() property {
+	return property{kind: kindBool}
+}
+
+func integer(min int64, max int64) property {
+	return property{kind: kindInt, min: min, max: max}
+}
+
+// enum accepts the names of values, or their index as older versions wrote
+// them.
+func enum(values ...string) property {
+	return property{kind: kindEnum, values: values}
+}
+
+func text() property {
+	return property{kind: kindString}
+}
+
+// serverProperties are the keys of server.properties that can be changed,
+// across the versions of the game still in use.
+var serverProperties = map[string]property{
+	"accepts-transfers":                 boolean(),
+	"allow-flight":                      boolean(),
+	"allow-nether":                      boolean(),
+	"broadcast-console-to-ops":          boolean(),
+	"broadcast-rcon-to-ops":             boolean(),
+	"bug-report-link":                   text(),
+	"difficulty":                        enum("peaceful", "easy", "normal", "hard"),
+	"enable-command-block":              boolean(),
+	"enable-jmx-monitoring":             boolean(),
+	"enable-query":                      boolean(),
+	"enforce-secure-profile":            boolean(),
+	"enforce-whitelist":                 boolean(),
+	"entity-broadcast-range-percentage": integer(10, 1000),
+	"force-gamemode":                    boolean(),
+	"function-permission-level":         integer(1, 4),
+	"gamemode":                          enum("survival", "creative", "adventure", "spectator"),
+	"generate-structures":               boolean(),
+	"generator-settings":                text(),
+	"hardcore":                          boolean(),
+	"hide-online-players":               boolean(),
+	"initial-disabled-packs":            text(),
+	"initial-enabled-packs":             text(),
+	"level-type":                        text(),
+	"log-ips":                           boolean(),
+	"max-chained-neighbor-updates":      integer(-1, math.MaxInt32),
+	"max-players":                       integer(0, math.MaxInt32),
+	"max-tick-time":                     integer(-1, math.MaxInt64),
+	"max-world-size":                    integer(1, 29999984),
+	"motd":                              text(),
+	"network-compression-threshold":     integer(-1, 65535),
+	"online-mode":                       boolean(),
+	"op-permission-level":               integer(0, 4),
+	"pause-when-empty-seconds":          integer(0, math.MaxInt32),
+	"player-idle-timeout":               integer(0, math.MaxInt32),
+	"prevent-proxy-connections":         boolean(),
+	"pvp":                               boolean(),
+	"query.port":                        integer(1, 65535),
+	"rate-limit":                        integer(0, math.MaxInt32),
+	"region-file-compression":           enum("deflate", "lz4", "none"),
+	"require-resource-pack":             boolean(),
+	"resource-pack":                     text(),
+	"resource-pack-id":                  text(),
+	"resource-pack-prompt":              text(),
+	"resource-pack-sha1":                text(),
+	"server-ip":                         text(),
+	"simulation-distance":               integer(3, 32),
+	"spawn-animals":                     boolean(),
+	"spawn-monsters":                    boolean(),
+	"spawn-npcs":                        boolean(),
+	"spawn-protection":                  integer(0, math.MaxInt32),
+	"sync-chunk-writes":                 boolean(),
+	"text-filtering-config":             text(),
+	"use-native-transport":              boolean(),
+	"view-distance":                     integer(3, 32),
+	"white-list":                        boolean(),
+}
+
+// managedProperties are set up for the backend to reach and back up the
+// server, or come from its profile, and cannot be changed through the API.
+var managedProperties = []string{
+	"enable-rcon",
+	"enable-status",
+	"level-name",
+	"level-seed",
+	"rcon.password",
+	"rcon.port",
+	"server-port",
+}
+
+// secretProperties are left out when properties are shown.
+var secretProperties = []string{"rcon.password"}
+
+// ValidateServer checks that key can be changed and that value suits it,
+// returning the value as the server expects it.
+func ValidateServer(key string, value string) (string, error) {
+	if slices.Contains(managedProperties, key) {
+		return "", fmt.Errorf("%w: %s", ErrManagedKey, key)
+	}
+
+	p, ok := serverProperties[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, key)
+	}
+
+	switch p.kind {
+	case kindBool:
+		v := strings.ToLower(value)
+		if v != "true" && v != "false" {
+			return "", fmt.Errorf("%w: %s must be true or false", ErrValue, key)
+		}
+		return v, nil
+	case kindInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < p.min || n > p.max {
+			return "", fmt.Errorf("%w: %s must be a whole number from %d to %d", ErrValue, key, p.min, p.max)
+		}
+		return strconv.FormatInt(n, 10), nil
+	case kindEnum:
+		v := strings.ToLower(value)
+		if slices.Contains(p.values, v) {
+			return v, nil
+		}
+		if i, err := strconv.Atoi(value); err == nil && i >= 0 && i < len(p.values) {
+			return p.values[i], nil
+		}
+		return "", fmt.Errorf("%w: %s must be one of %s", ErrValue, key, strings.Join(p.values, ", "))
+	default:
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("%w: %s must be a single line", ErrValue, key)
+		}
+		return value, nil
+	}
+}
+
+// Public returns the properties of a server file without its secrets.
+func Public(f *File) map[string]string {
+	m := f.Map()
+	for _, key := range secretProperties {
+		delete(m, key)
+	}
+	return m
+}
@@ -0,0 +1,142 @@
+// Package protocol reads and writes the framing shared by Minecraft Java
+// Edition packets: VarInts, strings and length-prefixed packets.
+package protocol
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// MaxPacketLength bounds packets read from the network so a bad length
+	// prefix cannot allocate unbounded memory.
+	MaxPacketLength int32 = 2 << 20
+	maxVarIntBytes  int   = 5
+)
+
+var ErrVarIntTooBig = errors.New("varint is too big")
+
+// Packet is an uncompressed packet: its ID and the payload that follows it.
+type Packet struct {
+	ID   int32
+	Data []byte
+}
+
+// Reader returns a reader over the packet payload.
+func (p *Packet) Reader() *bytes.Reader {
+	return bytes.NewReader(p.Data)
+}
+
+func ReadVarInt(r io.ByteReader) (int32, error) {
+	var v uint32
+	for i := 0; i < maxVarIntBytes; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint32(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(v), nil
+		}
+	}
+	return 0, ErrVarIntTooBig
+}
+
+func WriteVarInt(w *bytes.Buffer, v int32) {
+	u := uint32(v)
+	for {
+		if u&^0x7f == 0 {
+			w.WriteByte(byte(u))
+			return
+		}
+		w.WriteByte(byte(u&0x7f | 0x80))
+		u >>= 7
+	}
+}
+
+func ReadString(r *bytes.Reader) (string, error) {
+	n, err := ReadVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if n < 0 || int(n) > r.Len() {
+		return "", fmt.Errorf("invalid string length %d", n)
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func WriteString(w *bytes.Buffer, s string) {
+	WriteVarInt(w, int32(len(s)))
+	w.WriteString(s)
+}
+
+func ReadUint16(r io.Reader) (uint16, error) {
+	var v uint16
+	err := binary.Read(r, binary.BigEndian, &v)
+	return v, err
+}
+
+func WriteUint16(w *bytes.Buffer, v uint16) {
+	binary.Write(w, binary.BigEndian, v)
+}
+
+func ReadInt64(r io.Reader) (int64, error) {
+	var v int64
+	err := binary.Read(r, binary.BigEndian, &v)
+	return v, err
+}
+
+func WriteInt64(w *bytes.Buffer, v int64) {
+	binary.Write(w, binary.BigEndian, v)
+}
+
+// ReadPacket reads one length-prefixed packet.
+func ReadPacket(r *bufio.Reader) (*Packet, error) {
+	length, err := ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if length < 1 || length > MaxPacketLength {
+		return nil, fmt.Errorf("invalid packet length %d", length)
+	}
+
+	b := make([]byte, length)
+	_, err = io.ReadFull(r, b)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bytes.NewReader(b)
+	id, err := ReadVarInt(br)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Packet{
+		ID:   id,
+		Data: b[len(b)-br.Len():],
+	}, nil
+}
+
+// WritePacket writes p with its length prefix.
+func WritePacket(w io.Writer, p *Packet) error {
+	body := &bytes.Buffer{}
+	WriteVarInt(body, p.ID)
+	body.Write(p.Data)
+
+	buf := &bytes.Buffer{}
+	WriteVarInt(buf, int32(body.Len()))
+	buf.Write(body.Bytes())
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
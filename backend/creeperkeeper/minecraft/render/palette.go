@@ -0,0 +1,158 @@
+package render
+
+import (
+	"image/color"
+	"strings"
+)
+
+// blockColors are the map colors of common surface blocks, roughly the
+// colors the game uses for them on maps.
+var blockColors = map[string]color.RGBA{
+	"grass_block":       {R: 0x7f, G: 0xb2, B: 0x38, A: 0xff},
+	"short_grass":       {R: 0x7f, G: 0xb2, B: 0x38, A: 0xff},
+	"grass":             {R: 0x7f, G: 0xb2, B: 0x38, A: 0xff},
+	"tall_grass":        {R: 0x7f, G: 0xb2, B: 0x38, A: 0xff},
+	"fern":              {R: 0x6a, G: 0x9a, B: 0x30, A: 0xff},
+	"dirt":              {R: 0x97, G: 0x6d, B: 0x4d, A: 0xff},
+	"coarse_dirt":       {R: 0x97, G: 0x6d, B: 0x4d, A: 0xff},
+	"rooted_dirt":       {R: 0x97, G: 0x6d, B: 0x4d, A: 0xff},
+	"podzol":            {R: 0x81, G: 0x56, B: 0x31, A: 0xff},
+	"mycelium":          {R: 0x7f, G: 0x3f, B: 0xb2, A: 0xff},
+	"mud":               {R: 0x57, G: 0x5c, B: 0x5c, A: 0xff},
+	"dirt_path":         {R: 0x94, G: 0x7a, B: 0x41, A: 0xff},
+	"farmland":          {R: 0x97, G: 0x6d, B: 0x4d, A: 0xff},
+	"sand":              {R: 0xf7, G: 0xe9, B: 0xa3, A: 0xff},
+	"sandstone":         {R: 0xf7, G: 0xe9, B: 0xa3, A: 0xff},
+	"red_sand":          {R: 0xd8, G: 0x7f, B: 0x33, A: 0xff},
+	"gravel":            {R: 0x88, G: 0x88, B: 0x88, A: 0xff},
+	"clay":              {R: 0xa4, G: 0xa8, B: 0xb8, A: 0xff},
+	"stone":             {R: 0x70, G: 0x70, B: 0x70, A: 0xff},
+	"cobblestone":       {R: 0x70, G: 0x70, B: 0x70, A: 0xff},
+	"andesite":          {R: 0x70, G: 0x70, B: 0x70, A: 0xff},
+	"diorite":           {R: 0xff, G: 0xfc, B: 0xf5, A: 0xff},
+	"granite":           {R: 0x97, G: 0x6d, B: 0x4d, A: 0xff},
+	"deepslate":         {R: 0x64, G: 0x64, B: 0x64, A: 0xff},
+	"tuff":              {R: 0x6b, G: 0x6b, B: 0x60, A: 0xff},
+	"calcite":           {R: 0xd1, G: 0xb1, B: 0xa1, A: 0xff},
+	"bedrock":           {R: 0x40, G: 0x40, B: 0x40, A: 0xff},
+	"water":             {R: 0x40, G: 0x40, B: 0xff, A: 0xff},
+	"bubble_column":     {R: 0x40, G: 0x40, B: 0xff, A: 0xff},
+	"kelp":              {R: 0x40, G: 0x40, B: 0xff, A: 0xff},
+	"kelp_plant":        {R: 0x40, G: 0x40, B: 0xff, A: 0xff},
+	"seagrass":          {R: 0x40, G: 0x40, B: 0xff, A: 0xff},
+	"tall_seagrass":     {R: 0x40, G: 0x40, B: 0xff, A: 0xff},
+	"lava":              {R: 0xff, G: 0x00, B: 0x00, A: 0xff},
+	"ice":               {R: 0xa0, G: 0xa0, B: 0xff, A: 0xff},
+	"packed_ice":        {R: 0xa0, G: 0xa0, B: 0xff, A: 0xff},
+	"blue_ice":          {R: 0xa0, G: 0xa0, B: 0xff, A: 0xff},
+	"snow":              {R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	"snow_block":        {R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	"powder_snow":       {R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	"netherrack":        {R: 0x70, G: 0x02, B: 0x00, A: 0xff},
+	"nether_wart_block": {R: 0x99, G: 0x33, B: 0x33, A: 0xff},
+	"crimson_nylium":    {R: 0xbd, G: 0x30, B: 0x31, A: 0xff},
+	"warped_nylium":     {R: 0x16, G: 0x7e, B: 0x86, A: 0xff},
+	"soul_sand":         {R: 0x66, G: 0x4c, B: 0x33, A: 0xff},
+	"soul_soil":         {R: 0x66, G: 0x4c, B: 0x33, A: 0xff},
+	"basalt":            {R: 0x19, G: 0x19, B: 0x19, A: 0xff},
+	"blackstone":        {R: 0x19, G: 0x19, B: 0x19, A: 0xff},
+	"glowstone":         {R: 0xf7, G: 0xe9, B: 0xa3, A: 0xff},
+	"end_stone":         {R: 0xf7, G: 0xe9, B: 0xa3, A: 0xff},
+	"obsidian":          {R: 0x19, G: 0x19, B: 0x19, A: 0xff},
+	"purpur_block":      {R: 0xb2, G: 0x4c, B: 0xd8, A: 0xff},
+	"chorus_plant":      {R: 0x7f, G: 0x3f, B: 0xb2, A: 0xff},
+	"chorus_flower":     {R: 0x7f, G: 0x3f, B: 0xb2, A: 0xff},
+	"terracotta":        {R: 0xd1, G: 0xb1, B: 0xa1, A: 0xff},
+	"moss_block":        {R: 0x66, G: 0x7f, B: 0x33, A: 0xff},
+	"moss_carpet":       {R: 0x66, G: 0x7f, B: 0x33, A: 0xff},
+	"cactus":            {R: 0x00, G: 0x7c, B: 0x00, A: 0xff},
+	"sugar_cane":        {R: 0x00, G: 0x7c, B: 0x00, A: 0xff},
+	"lily_pad":          {R: 0x00, G: 0x7c, B: 0x00, A: 0xff},
+	"vine":              {R: 0x00, G: 0x7c, B: 0x00, A: 0xff},
+	"pumpkin":           {R: 0xd8, G: 0x7f, B: 0x33, A: 0xff},
+	"melon":             {R: 0x7f, G: 0xcc, B: 0x19, A: 0xff},
+	"hay_block":         {R: 0xe5, G: 0xe5, B: 0x33, A: 0xff},
+	"glass":             {R: 0xc0, G: 0xd8, B: 0xe0, A: 0xff},
+	"bricks":            {R: 0x99, G: 0x33, B: 0x33, A: 0xff},
+	"iron_block":        {R: 0xa7, G: 0xa7, B: 0xa7, A: 0xff},
+	"gold_block":        {R: 0xfa, G: 0xee, B: 0x4d, A: 0xff},
+	"diamond_block":     {R: 0x5c, G: 0xdb, B: 0xd5, A: 0xff},
+	"emerald_block":     {R: 0x00, G: 0xd9, B: 0x3a, A: 0xff},
+	"redstone_block":    {R: 0xff, G: 0x00, B: 0x00, A: 0xff},
+	"lapis_block":       {R: 0x4a, G: 0x80, B: 0xff, A: 0xff},
+	"quartz_block":      {R: 0xff, G: 0xfc, B: 0xf5, A: 0xff},
+	"bookshelf":         {R: 0x8f, G: 0x77, B: 0x48, A: 0xff},
+	"torch":             {R: 0xff, G: 0xd8, B: 0x4c, A: 0xff},
+}
+
+// suffixColors color families of blocks by the end of their name, checked
+// in order after blockColors.
+var suffixColors = []struct {
+	suffix string
+	color  color.RGBA
+}{
+	{suffix: "_leaves", color: color.RGBA{R: 0x00, G: 0x7c, B: 0x00, A: 0xff}},
+	{suffix: "_sapling", color: color.RGBA{R: 0x00, G: 0x7c, B: 0x00, A: 0xff}},
+	{suffix: "_log", color: color.RGBA{R: 0x66, G: 0x4c, B: 0x33, A: 0xff}},
+	{suffix: "_wood", color: color.RGBA{R: 0x66, G: 0x4c, B: 0x33, A: 0xff}},
+	{suffix: "_stem", color: color.RGBA{R: 0x5c, G: 0x19, B: 0x1d, A: 0xff}},
+	{suffix: "_planks", color: color.RGBA{R: 0x8f, G: 0x77, B: 0x48, A: 0xff}},
+	{suffix: "_stairs", color: color.RGBA{R: 0x8f, G: 0x77, B: 0x48, A: 0xff}},
+	{suffix: "_slab", color: color.RGBA{R: 0x8f, G: 0x77, B: 0x48, A: 0xff}},
+	{suffix: "_fence", color: color.RGBA{R: 0x8f, G: 0x77, B: 0x48, A: 0xff}},
+	{suffix: "_door", color: color.RGBA{R: 0x8f, G: 0x77, B: 0x48, A: 0xff}},
+	{suffix: "_ore", color: color.RGBA{R: 0x70, G: 0x70, B: 0x70, A: 0xff}},
+	{suffix: "_bricks", color: color.RGBA{R: 0x70, G: 0x70, B: 0x70, A: 0xff}},
+	{suffix: "_wall", color: color.RGBA{R: 0x70, G: 0x70, B: 0x70, A: 0xff}},
+	{suffix: "_terracotta", color: color.RGBA{R: 0x9f, G: 0x52, B: 0x24, A: 0xff}},
+	{suffix: "_flower", color: color.RGBA{R: 0xff, G: 0xd8, B: 0x4c, A: 0xff}},
+	{suffix: "_tulip", color: color.RGBA{R: 0xff, G: 0x66, B: 0x66, A: 0xff}},
+	{suffix: "_mushroom", color: color.RGBA{R: 0x99, G: 0x33, B: 0x33, A: 0xff}},
+	{suffix: "_coral", color: color.RGBA{R: 0xff, G: 0x66, B: 0x99, A: 0xff}},
+	{suffix: "_coral_block", color: color.RGBA{R: 0xff, G: 0x66, B: 0x99, A: 0xff}},
+}
+
+// dyeColors color wool, carpet, concrete, glass and other dyed blocks by
+// their dye prefix. No dye name is a prefix of another.
+var dyeColors = map[string]color.RGBA{
+	"white":      {R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	"orange":     {R: 0xd8, G: 0x7f, B: 0x33, A: 0xff},
+	"magenta":    {R: 0xb2, G: 0x4c, B: 0xd8, A: 0xff},
+	"light_blue": {R: 0x66, G: 0x99, B: 0xd8, A: 0xff},
+	"yellow":     {R: 0xe5, G: 0xe5, B: 0x33, A: 0xff},
+	"lime":       {R: 0x7f, G: 0xcc, B: 0x19, A: 0xff},
+	"pink":       {R: 0xf2, G: 0x7f, B: 0xa5, A: 0xff},
+	"gray":       {R: 0x4c, G: 0x4c, B: 0x4c, A: 0xff},
+	"light_gray": {R: 0x99, G: 0x99, B: 0x99, A: 0xff},
+	"cyan":       {R: 0x4c, G: 0x7f, B: 0x99, A: 0xff},
+	"purple":     {R: 0x7f, G: 0x3f, B: 0xb2, A: 0xff},
+	"blue":       {R: 0x33, G: 0x4c, B: 0xb2, A: 0xff},
+	"brown":      {R: 0x66, G: 0x4c, B: 0x33, A: 0xff},
+	"green":      {R: 0x66, G: 0x7f, B: 0x33, A: 0xff},
+	"red":        {R: 0x99, G: 0x33, B: 0x33, A: 0xff},
+	"black":      {R: 0x19, G: 0x19, B: 0x19, A: 0xff},
+}
+
+var unknownColor = color.RGBA{R: 0x8c, G: 0x8c, B: 0x8c, A: 0xff}
+
+// BlockColor returns the map color for a block name such as
+// minecraft:grass_block.
+func BlockColor(name string) color.RGBA {
+	name = strings.TrimPrefix(name, "minecraft:")
+	if c, ok := blockColors[name]; ok {
+		return c
+	}
+
+	for dye, c := range dyeColors {
+		if strings.HasPrefix(name, dye+"_") {
+			return c
+		}
+	}
+
+	for _, s := range suffixColors {
+		if strings.HasSuffix(name, s.suffix) {
+			return s.color
+		}
+	}
+	return unknownColor
+}
@@ -0,0 +1,120 @@
+// Package render draws top-down maps of a world from its region files.
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/anvil"
+)
+
+// TileSize is the width and height in pixels of a region tile, one pixel
+// per block.
+const TileSize = anvil.RegionChunks * 16
+
+// Tile is a rendered region.
+type Tile struct {
+	Image *image.RGBA
+	// Chunks is how many chunks were drawn, Skipped how many could not be
+	// read and were left transparent.
+	Chunks  int
+	Skipped int
+}
+
+// Options change how a region is drawn.
+type Options struct {
+	// Roof is the y a dimension's ceiling starts at, zero for none. Columns
+	// are drawn from the first open space under it, so the nether shows its
+	// floor rather than bedrock.
+	Roof int
+}
+
+// RenderRegion draws the top-most non-air block of every column in a region
+// file. Blocks higher than their northern neighbour are drawn lighter and
+// lower ones darker, the way the game's maps show relief.
+func RenderRegion(r io.ReaderAt, size int64, opts Options) (*Tile, error) {
+	region, err := anvil.ReadRegion(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	tile := &Tile{Image: image.NewRGBA(image.Rect(0, 0, TileSize, TileSize))}
+	heights := make([]int, TileSize*TileSize)
+	drawn := make([]bool, TileSize*TileSize)
+
+	for _, ch := range region.Chunks {
+		root, err := anvil.ReadChunk(r, ch)
+		if err != nil {
+			tile.Skipped++
+			continue
+		}
+
+		blocks := anvil.ReadBlocks(root)
+		for z := 0; z < 16; z++ {
+			for x := 0; x < 16; x++ {
+				name, y, ok := blocks.Top(x, z)
+				if opts.Roof != 0 {
+					name, y, ok = blocks.TopOpen(x, z, opts.Roof)
+				}
+				if !ok {
+					continue
+				}
+
+				px, pz := ch.X*16+x, ch.Z*16+z
+				tile.Image.SetRGBA(px, pz, BlockColor(name))
+				heights[pz*TileSize+px] = y
+				drawn[pz*TileSize+px] = true
+			}
+		}
+		tile.Chunks++
+	}
+
+	// Chunks are drawn in header order, so relief is shaded once every
+	// column's neighbour is known. Columns without one are shaded as flat.
+	for pz := 0; pz < TileSize; pz++ {
+		for px := 0; px < TileSize; px++ {
+			i := pz*TileSize + px
+			if !drawn[i] {
+				continue
+			}
+
+			f := 220
+			north := i - TileSize
+			if pz > 0 && drawn[north] {
+				switch {
+				case heights[i] > heights[north]:
+					f = 255
+				case heights[i] < heights[north]:
+					f = 180
+				}
+			}
+			tile.Image.SetRGBA(px, pz, shade(tile.Image.RGBAAt(px, pz), f))
+		}
+	}
+
+	return tile, nil
+}
+
+// shade scales a color by f/255.
+func shade(c color.RGBA, f int) color.RGBA {
+	return color.RGBA{
+		R: uint8(int(c.R) * f / 255),
+		G: uint8(int(c.G) * f / 255),
+		B: uint8(int(c.B) * f / 255),
+		A: c.A,
+	}
+}
+
+// PNG encodes a tile.
+func (t *Tile) PNG() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	err := enc.Encode(&buf, t.Image)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
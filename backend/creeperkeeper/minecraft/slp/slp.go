@@ -0,0 +1,268 @@
+// Package slp queries Minecraft Java Edition servers with the Server List
+// Ping protocol, falling back to the legacy ping used before 1.7.
+package slp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/protocol"
+)
+
+const (
+	DefaultPort    int           = 25565
+	DefaultTimeout time.Duration = 3 * time.Second
+
+	// Servers answer status requests regardless of the protocol version in
+	// the handshake; -1 is the convention for "just checking".
+	pingProtocolVersion int32 = -1
+	stateStatus         int32 = 1
+	packetStatus        int32 = 0x00
+	packetPing          int32 = 0x01
+)
+
+type Version struct {
+	Name     string `json:"name"`
+	Protocol int    `json:"protocol"`
+}
+
+type Player struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+type Players struct {
+	Max    int      `json:"max"`
+	Online int      `json:"online"`
+	Sample []Player `json:"sample,omitempty"`
+}
+
+// Status is what a server reports about itself in the server list.
+type Status struct {
+	Version   Version `json:"version"`
+	Players   Players `json:"players"`
+	MOTD      string  `json:"motd"`
+	LatencyMS int64   `json:"latencyMs"`
+	Legacy    bool    `json:"legacy"`
+}
+
+// Query asks the server at host:port for its status, trying the modern
+// protocol first and the legacy ping when that fails.
+func Query(ctx context.Context, host string, port int, timeout time.Duration) (*Status, error) {
+	status, err := query(ctx, host, port, timeout)
+	if err == nil {
+		return status, nil
+	}
+
+	legacy, legacyErr := queryLegacy(ctx, host, port, timeout)
+	if legacyErr != nil {
+		return nil, errors.Join(err, legacyErr)
+	}
+	return legacy, nil
+}
+
+func query(ctx context.Context, host string, port int, timeout time.Duration) (*Status, error) {
+	conn, err := dial(ctx, host, port, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	handshake := &bytes.Buffer{}
+	protocol.WriteVarInt(handshake, pingProtocolVersion)
+	protocol.WriteString(handshake, host)
+	protocol.WriteUint16(handshake, uint16(port))
+	protocol.WriteVarInt(handshake, stateStatus)
+
+	err = protocol.WritePacket(conn, &protocol.Packet{ID: 0x00, Data: handshake.Bytes()})
+	if err != nil {
+		return nil, err
+	}
+
+	err = protocol.WritePacket(conn, &protocol.Packet{ID: packetStatus})
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	p, err := protocol.ReadPacket(r)
+	if err != nil {
+		return nil, err
+	}
+	if p.ID != packetStatus {
+		return nil, fmt.Errorf("unexpected status packet id %d", p.ID)
+	}
+
+	raw, err := protocol.ReadString(p.Reader())
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := parseStatus([]byte(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &bytes.Buffer{}
+	sent := time.Now()
+	protocol.WriteInt64(payload, sent.UnixMilli())
+	err = protocol.WritePacket(conn, &protocol.Packet{ID: packetPing, Data: payload.Bytes()})
+	if err != nil {
+		return nil, err
+	}
+
+	pong, err := protocol.ReadPacket(r)
+	if err != nil {
+		return nil, err
+	}
+	if pong.ID != packetPing {
+		return nil, fmt.Errorf("unexpected pong packet id %d", pong.ID)
+	}
+	status.LatencyMS = time.Since(sent).Milliseconds()
+
+	return status, nil
+}
+
+func parseStatus(raw []byte) (*Status, error) {
+	var res struct {
+		Version     Version         `json:"version"`
+		Players     Players         `json:"players"`
+		Description json.RawMessage `json:"description"`
+	}
+	err := json.Unmarshal(raw, &res)
+	if err != nil {
+		return nil, fmt.Errorf("invalid status response: %w", err)
+	}
+
+	return &Status{
+		Version: res.Version,
+		Players: res.Players,
+		MOTD:    ChatText(res.Description),
+	}, nil
+}
+
+// ChatText flattens a chat component, which may be a plain string or an
+// object with nested extra components, into its text.
+func ChatText(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+
+	var component struct {
+		Text  string            `json:"text"`
+		Extra []json.RawMessage `json:"extra"`
+	}
+	if json.Unmarshal(raw, &component) != nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(component.Text)
+	for _, e := range component.Extra {
+		b.WriteString(ChatText(e))
+	}
+	return b.String()
+}
+
+// queryLegacy uses the 1.4-1.6 ping, which older servers answer with a
+// kick packet describing their status.
+func queryLegacy(ctx context.Context, host string, port int, timeout time.Duration) (*Status, error) {
+	conn, err := dial(ctx, host, port, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	sent := time.Now()
+	_, err = conn.Write([]byte{0xfe, 0x01})
+	if err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	id, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if id != 0xff {
+		return nil, fmt.Errorf("unexpected legacy packet id %#x", id)
+	}
+
+	length, err := protocol.ReadUint16(r)
+	if err != nil {
+		return nil, err
+	}
+
+	units := make([]uint16, length)
+	err = binary.Read(r, binary.BigEndian, units)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	status, err := parseLegacy(string(utf16.Decode(units)))
+	if err != nil {
+		return nil, err
+	}
+	status.LatencyMS = time.Since(sent).Milliseconds()
+	return status, nil
+}
+
+func parseLegacy(s string) (*Status, error) {
+	// 1.4+ servers reply with "§1\x00protocol\x00version\x00motd\x00online\x00max"
+	if strings.HasPrefix(s, "§1\x00") {
+		fields := strings.Split(s, "\x00")
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("invalid legacy status %q", s)
+		}
+		protocolVersion, _ := strconv.Atoi(fields[1])
+		online, _ := strconv.Atoi(fields[4])
+		max, _ := strconv.Atoi(fields[5])
+		return &Status{
+			Version: Version{Name: fields[2], Protocol: protocolVersion},
+			Players: Players{Online: online, Max: max},
+			MOTD:    fields[3],
+			Legacy:  true,
+		}, nil
+	}
+
+	// Beta 1.8 to 1.3 reply with "motd§online§max"
+	fields := strings.Split(s, "§")
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("invalid legacy status %q", s)
+	}
+	online, _ := strconv.Atoi(fields[len(fields)-2])
+	max, _ := strconv.Atoi(fields[len(fields)-1])
+	return &Status{
+		Players: Players{Online: online, Max: max},
+		MOTD:    strings.Join(fields[:len(fields)-2], "§"),
+		Legacy:  true,
+	}, nil
+}
+
+func dial(ctx context.Context, host string, port int, timeout time.Duration) (net.Conn, error) {
+	d := &net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	conn.SetDeadline(deadline)
+
+	return conn, nil
+}
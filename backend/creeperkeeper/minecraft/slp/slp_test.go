@@ -0,0 +1,263 @@
+package slp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/protocol"
+)
+
+func TestChatText(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "string", raw: `"A Minecraft Server"`, want: "A Minecraft Server"},
+		{name: "text", raw: `{"text":"Tamo"}`, want: "Tamo"},
+		{
+			name: "nested extra",
+			raw:  `{"text":"Welcome ","extra":[{"text":"to ","color":"gold"},"the ",{"text":"","extra":[{"text":"server"}]}]}`,
+			want: "Welcome to the server",
+		},
+		{name: "empty", raw: `{}`, want: ""},
+		{name: "invalid", raw: `[1,2]`, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ChatText(json.RawMessage(tt.raw))
+			if got != tt.want {
+				t.Errorf("ChatText(%s) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Status
+		wantErr bool
+	}{
+		{
+			name: "vanilla",
+			raw:  `{"version":{"name":"1.21.4","protocol":769},"players":{"max":20,"online":1,"sample":[{"name":"Steve","id":"8667ba71-b85a-4004-af54-457a9734eed7"}]},"description":{"text":"Hello"}}`,
+			want: Status{
+				Version: Version{Name: "1.21.4", Protocol: 769},
+				Players: Players{Max: 20, Online: 1, Sample: []Player{{Name: "Steve", ID: "8667ba71-b85a-4004-af54-457a9734eed7"}}},
+				MOTD:    "Hello",
+			},
+		},
+		{
+			name: "string description",
+			raw:  `{"version":{"name":"1.12.2","protocol":340},"players":{"max":10,"online":0},"description":"Tamo"}`,
+			want: Status{
+				Version: Version{Name: "1.12.2", Protocol: 340},
+				Players: Players{Max: 10},
+				MOTD:    "Tamo",
+			},
+		},
+		{name: "invalid", raw: `{"version":`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatus([]byte(tt.raw))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertStatus(t, got, &tt.want)
+		})
+	}
+}
+
+func TestParseLegacy(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Status
+		wantErr bool
+	}{
+		{
+			name: "1.4 to 1.6",
+			s:    "§1\x0078\x001.6.4\x00A Minecraft Server\x003\x0020",
+			want: Status{
+				Version: Version{Name: "1.6.4", Protocol: 78},
+				Players: Players{Online: 3, Max: 20},
+				MOTD:    "A Minecraft Server",
+				Legacy:  true,
+			},
+		},
+		{
+			name: "beta 1.8 to 1.3",
+			s:    "A Minecraft Server§3§20",
+			want: Status{
+				Players: Players{Online: 3, Max: 20},
+				MOTD:    "A Minecraft Server",
+				Legacy:  true,
+			},
+		},
+		{
+			name: "section sign in motd",
+			s:    "§aGreen§r server§0§8",
+			want: Status{
+				Players: Players{Online: 0, Max: 8},
+				MOTD:    "§aGreen§r server",
+				Legacy:  true,
+			},
+		},
+		{name: "too few 1.4 fields", s: "§1\x0078\x001.6.4", wantErr: true},
+		{name: "too few fields", s: "A Minecraft Server§3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLegacy(tt.s)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertStatus(t, got, &tt.want)
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	status := `{"version":{"name":"1.21.4","protocol":769},"players":{"max":20,"online":2},"description":{"text":"Hello"}}`
+	host, port := listen(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		for {
+			p, err := protocol.ReadPacket(r)
+			if err != nil {
+				return
+			}
+			switch {
+			case p.ID == packetStatus && len(p.Data) == 0:
+				b := &bytes.Buffer{}
+				protocol.WriteString(b, status)
+				protocol.WritePacket(conn, &protocol.Packet{ID: packetStatus, Data: b.Bytes()})
+			case p.ID == packetPing:
+				protocol.WritePacket(conn, p)
+			}
+		}
+	})
+
+	got, err := Query(context.Background(), host, port, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(t, got, &Status{
+		Version: Version{Name: "1.21.4", Protocol: 769},
+		Players: Players{Max: 20, Online: 2},
+		MOTD:    "Hello",
+	})
+}
+
+func TestQueryLegacyFallback(t *testing.T) {
+	host, port := listen(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		b, err := r.ReadByte()
+		// Servers before 1.7 drop the modern handshake.
+		if err != nil || b != 0xfe {
+			return
+		}
+
+		units := utf16.Encode([]rune("§1\x0078\x001.6.4\x00Old\x001\x0010"))
+		res := &bytes.Buffer{}
+		res.WriteByte(0xff)
+		binary.Write(res, binary.BigEndian, uint16(len(units)))
+		binary.Write(res, binary.BigEndian, units)
+		conn.Write(res.Bytes())
+	})
+
+	got, err := Query(context.Background(), host, port, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(t, got, &Status{
+		Version: Version{Name: "1.6.4", Protocol: 78},
+		Players: Players{Online: 1, Max: 10},
+		MOTD:    "Old",
+		Legacy:  true,
+	})
+}
+
+func TestQueryTimeout(t *testing.T) {
+	host, port := listen(t, func(conn net.Conn) {
+		// Hold the connection open without answering.
+		buf := make([]byte, 64)
+		for {
+			_, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+		}
+	})
+
+	start := time.Now()
+	_, err := Query(context.Background(), host, port, 200*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected a silent server to time out")
+	}
+	// Both the modern and the legacy attempt time out.
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Query took %v, want about twice the 200ms timeout", elapsed)
+	}
+}
+
+// listen serves each connection to 127.0.0.1 with handle and returns the
+// address to query.
+func listen(t *testing.T, handle func(net.Conn)) (string, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, p
+}
+
+// assertStatus compares everything but the measured latency.
+func assertStatus(t *testing.T, got, want *Status) {
+	t.Helper()
+	got.LatencyMS = 0
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if !bytes.Equal(gotJSON, wantJSON) {
+		t.Errorf("status = %s, want %s", gotJSON, wantJSON)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+// ListTasks returns the last task run under each of the server's task keys,
+// such as map renders, with their progress.
+func (h *Handler) ListTasks(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	tasks, err := h.Client.db.Client.ListServerTasks(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, tasks)
+}
+
+// startOperation records a new operation on the server and hands it to the
+// job route to run, responding with the pending operation.
+func (h *Handler) startOperation(w http.ResponseWriter, r *http.Request, serverID string, route string, op *types.Operation) {
+	writeStarted(w, r, op, h.enqueueOperation(r.Context(), serverID, route, op))
+}
+
+// startTask is startOperation for operations that only read the server's
+// stored world or backups, which are recorded as its task under key.
+func (h *Handler) startTask(w http.ResponseWriter, r *http.Request, serverID string, key string, route string, op *types.Operation) {
+	writeStarted(w, r, op, h.enqueueTask(r.Context(), serverID, key, route, op))
+}
+
+func writeStarted(w http.ResponseWriter, r *http.Request, op *types.Operation, err error) {
+	if errors.Is(err, types.ErrOperationInProgress) {
+		writeResponse(w, r, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusAccepted, op)
+}
+
+// enqueueOperation records a new operation on the server and hands it to
+// the job route to run. It fails with types.ErrOperationInProgress when the
+// server is busy, and records the failure on op if the job cannot be queued.
+func (h *Handler) enqueueOperation(ctx context.Context, serverID string, route string, op *types.Operation) error {
+	err := h.Client.db.Client.StartServerOperation(ctx, utils.ToString(h.Client.db.Table), serverID, op)
+	if err != nil {
+		return err
+	}
+	return h.enqueueRun(ctx, route, &operationRun{h: h, op: op, serverID: serverID})
+}
+
+// enqueueTask records a new task on the server under key and hands it to
+// the job route to run. It fails with types.ErrOperationInProgress when
+// another task with the same key is running.
+func (h *Handler) enqueueTask(ctx context.Context, serverID string, key string, route string, op *types.Operation) error {
+	err := h.Client.db.Client.StartServerTask(ctx, utils.ToString(h.Client.db.Table), serverID, key, op)
+	if err != nil {
+		return err
+	}
+	return h.enqueueRun(ctx, route, &operationRun{h: h, op: op, serverID: serverID, task: key})
+}
+
+func (h *Handler) enqueueRun(ctx context.Context, route string, run *operationRun) error {
+	job := &types.OperationJob{
+		ServerID:    utils.String(run.serverID),
+		OperationID: run.op.ID,
+	}
+	if run.task != "" {
+		job.Task = utils.String(run.task)
+	}
+
+	err := h.Client.jobs.Client.Enqueue(ctx, route, job)
+	if err != nil {
+		err = fmt.Errorf("failed to enqueue %s: %w", utils.ToString(run.op.Type), err)
+		run.op.Fail(err)
+		run.save(ctx)
+		return err
+	}
+	return nil
+}
+
+// operationRun tracks a running operation, saving its progress on the server
+// record, or its task row for tasks, as each step starts and finishes.
+type operationRun struct {
+	h        *Handler
+	server   *types.Server
+	op       *types.Operation
+	serverID string
+	// task is the key of the task the operation runs as, if it is one.
+	task    string
+	savedAt time.Time
+}
+
+// progressInterval limits how often progress is written to the server
+// record, since steps can report it for every file.
+const progressInterval = 5 * time.Second
+
+// loadOperation reads the job body and returns the run for the server's
+// current operation. It fails if that operation is not the one the job was
+// enqueued for or has already finished.
+func (h *Handler) loadOperation(r *http.Request) (*operationRun, error) {
+	job := &types.OperationJob{}
+	err := job.UnmarshallRequest(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job body: %w", err)
+	}
+
+	serverID := utils.ToString(job.ServerID)
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	op := server.Operation
+	if job.Task != nil {
+		task, err := h.Client.db.Client.GetServerTask(r.Context(), utils.ToString(h.Client.db.Table), serverID, *job.Task)
+		if err != nil {
+			return nil, err
+		}
+		op = nil
+		if task != nil {
+			op = task.Operation
+		}
+	}
+	if op == nil || utils.ToString(op.ID) != utils.ToString(job.OperationID) {
+		return nil, fmt.Errorf("operation %s is not current on server %s", utils.ToString(job.OperationID), serverID)
+	}
+	if op.Done() {
+		return nil, fmt.Errorf("operation %s has already finished", utils.ToString(op.ID))
+	}
+
+	return &operationRun{
+		h:        h,
+		server:   server,
+		op:       op,
+		serverID: serverID,
+		task:     utils.ToString(job.Task),
+	}, nil
+}
+
+func (o *operationRun) step(ctx context.Context, name string, fn func() error) error {
+	o.op.StartStep(name)
+	o.save(ctx)
+
+	err := fn()
+	o.op.FinishStep(err)
+	o.save(ctx)
+	return err
+}
+
+// progress records how far the current step has got. It is saved at most
+// every progressInterval, and when the step is done.
+func (o *operationRun) progress(ctx context.Context, done int, total int) {
+	o.op.Progress = &types.OperationProgress{Done: done, Total: total}
+	if done < total && time.Since(o.savedAt) < progressInterval {
+		return
+	}
+	o.save(ctx)
+}
+
+// finish marks the operation succeeded when all of its steps have.
+func (o *operationRun) finish(ctx context.Context, err error) {
+	if err != nil {
+		return
+	}
+	o.op.Succeed()
+	o.save(ctx)
+}
+
+func (o *operationRun) save(ctx context.Context) {
+	o.savedAt = time.Now()
+	table := utils.ToString(o.h.Client.db.Table)
+	var err error
+	if o.task != "" {
+		err = o.h.Client.db.Client.UpdateServerTask(ctx, table, o.serverID, o.task, o.op)
+	} else {
+		err = o.h.Client.db.Client.UpdateServerOperation(ctx, table, o.serverID, o.op)
+	}
+	if err != nil {
+		log.Println("failed to record operation progress:", err)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/minecraft/player"
+	"github.com/hnucamendi/creeper-keeper/service/storage"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const (
+	playerDataDir  string = "playerdata/"
+	playerStatsDir string = "stats/"
+	userCacheFile  string = "usercache.json"
+)
+
+// ListPlayers returns every player in the server's stored world. With sort
+// in the query, one of name, playTime, deaths, mobKills or playerKills,
+// players are ordered as a leaderboard, highest first.
+func (h *Handler) ListPlayers(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	order := r.URL.Query().Get("sort")
+	less, ok := playerOrders[order]
+	if order != "" && !ok {
+		writeResponse(w, r, http.StatusBadRequest, "unknown sort: "+order)
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	players, err := h.players(r.Context(), server, "")
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if less != nil {
+		sort.SliceStable(players, func(i, j int) bool {
+			return less(&players[i], &players[j])
+		})
+	}
+
+	writeResponse(w, r, http.StatusOK, players)
+}
+
+func (h *Handler) GetPlayer(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	uuid, ok := player.ParseUUID(r.PathValue("playerID"))
+	if !ok {
+		writeResponse(w, r, http.StatusBadRequest, "playerID must be a UUID")
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	players, err := h.players(r.Context(), server, uuid)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if len(players) == 0 {
+		writeResponse(w, r, http.StatusNotFound, "player not found: "+uuid)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, players[0])
+}
+
+// players reads the player data and statistics synced from the server,
+// only for one player when uuid is set. Players with statistics but no
+// player data are included, as are players with data but no statistics.
+func (h *Handler) players(ctx context.Context, server *types.Server, uuid string) ([]types.Player, error) {
+	bucket := utils.ToString(h.Client.storage.Bucket)
+	serverPrefix := backup.WorldPrefix(utils.ToString(server.Name))
+	levelName, err := h.Client.backup.LevelName(ctx, utils.ToString(server.Name))
+	if err != nil {
+		return nil, err
+	}
+	worldPrefix := serverPrefix + levelName + "/"
+
+	names, err := h.userCache(ctx, bucket, serverPrefix+userCacheFile)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]*types.Player{}
+	get := func(id string) *types.Player {
+		p, ok := found[id]
+		if !ok {
+			p = &types.Player{UUID: utils.String(id)}
+			if name, ok := names[id]; ok {
+				p.Name = utils.String(name)
+			}
+			found[id] = p
+		}
+		return p
+	}
+
+	for _, dir := range []string{playerDataDir, playerStatsDir} {
+		objects, err := h.Client.storage.Client.List(ctx, bucket, worldPrefix+dir+uuid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+		}
+
+		for _, o := range objects {
+			key := utils.ToString(o.Key)
+			id, ok := playerFileID(key, dir)
+			if !ok {
+				continue
+			}
+
+			p := get(id)
+			if dir == playerDataDir {
+				p.State, err = readPlayerFile(ctx, h.Client.storage.Client, bucket, key, player.Read)
+				p.UpdatedAt = utils.String(o.LastModified.UTC().Format(time.RFC3339))
+			} else {
+				p.Stats, err = readPlayerFile(ctx, h.Client.storage.Client, bucket, key, player.ReadStats)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+
+	players := []types.Player{}
+	for _, p := range found {
+		if p.Stats == nil {
+			p.Stats = &player.Stats{}
+		}
+		players = append(players, *p)
+	}
+	byName := playerOrders[types.PlayerSortName]
+	sort.Slice(players, func(i, j int) bool {
+		return byName(&players[i], &players[j])
+	})
+	return players, nil
+}
+
+// playerFileID returns the UUID of a file in playerdata or stats, skipping
+// the .dat_old copies the game keeps.
+func playerFileID(key string, dir string) (string, bool) {
+	ext := ".json"
+	if dir == playerDataDir {
+		ext = ".dat"
+	}
+
+	name := path.Base(key)
+	if !strings.HasSuffix(key, dir+name) || path.Ext(name) != ext {
+		return "", false
+	}
+	return player.ParseUUID(strings.TrimSuffix(name, ext))
+}
+
+func readPlayerFile[T any](ctx context.Context, s storage.Storage, bucket string, key string, read func(io.Reader) (T, error)) (T, error) {
+	body, err := s.Get(ctx, bucket, key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer body.Close()
+	return read(body)
+}
+
+// userCache returns the server's UUID to name mapping. A server that has
+// never had a player join has no usercache.json.
+func (h *Handler) userCache(ctx context.Context, bucket string, key string) (map[string]string, error) {
+	body, err := h.Client.storage.Client.Get(ctx, bucket, key)
+	if errors.Is(err, types.ErrObjectNotFound) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return player.ReadUserCache(body)
+}
+
+// playerOrders compare players for each sort. Statistics sort highest
+// first, players without a name sort after those with one.
+var playerOrders = map[string]func(a, b *types.Player) bool{
+	types.PlayerSortName: func(a, b *types.Player) bool {
+		an, bn := utils.ToString(a.Name), utils.ToString(b.Name)
+		if (an == "") != (bn == "") {
+			return an != ""
+		}
+		if !strings.EqualFold(an, bn) {
+			return strings.ToLower(an) < strings.ToLower(bn)
+		}
+		return utils.ToString(a.UUID) < utils.ToString(b.UUID)
+	},
+	types.PlayerSortPlayTime: func(a, b *types.Player) bool {
+		return a.Stats.PlayTime > b.Stats.PlayTime
+	},
+	types.PlayerSortDeaths: func(a, b *types.Player) bool {
+		return a.Stats.Deaths > b.Stats.Deaths
+	},
+	types.PlayerSortMobKills: func(a, b *types.Player) bool {
+		return a.Stats.MobKills > b.Stats.MobKills
+	},
+	types.PlayerSortPlayerKills: func(a, b *types.Player) bool {
+		return a.Stats.PlayerKills > b.Stats.PlayerKills
+	},
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+func (h *Handler) GetServerProfile(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	profile, err := h.Client.db.Client.GetServerProfile(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if errors.Is(err, types.ErrProfileNotFound) {
+		writeResponse(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, profile)
+}
+
+// CreateServerProfile gives a registered server its profile.
+func (h *Handler) CreateServerProfile(w http.ResponseWriter, r *http.Request) {
+	h.putServerProfile(w, r, true)
+}
+
+// UpdateServerProfile replaces the server's profile as a whole.
+func (h *Handler) UpdateServerProfile(w http.ResponseWriter, r *http.Request) {
+	h.putServerProfile(w, r, false)
+}
+
+func (h *Handler) putServerProfile(w http.ResponseWriter, r *http.Request, create bool) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	profile := &types.ServerProfile{}
+	err := profile.UnmarshallRequest(r.Body)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = profile.Validate()
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	err = h.worldDefaults(r.Context(), server, profile)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	profile.ServerID = utils.String(serverID)
+	profile.UpdatedAt = utils.String(time.Now().UTC().Format(time.RFC3339))
+	err = h.Client.db.Client.PutServerProfile(r.Context(), utils.ToString(h.Client.db.Table), profile, create)
+	if errors.Is(err, types.ErrProfileExists) {
+		writeResponse(w, r, http.StatusConflict, err.Error())
+		return
+	}
+	if errors.Is(err, types.ErrProfileNotFound) {
+		writeResponse(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = h.Client.db.Client.SetServerPorts(r.Context(), utils.ToString(h.Client.db.Table), serverID, profile.Ports)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	code := http.StatusOK
+	if create {
+		code = http.StatusCreated
+	}
+	writeResponse(w, r, code, profile)
+}
+
+// worldDefaults fills in the level name and seed a profile leaves out from
+// the server's stored server.properties, so the container keeps loading the
+// world the server already has.
+func (h *Handler) worldDefaults(ctx context.Context, server *types.Server, profile *types.ServerProfile) error {
+	if profile.LevelName != nil && profile.Seed != nil {
+		return nil
+	}
+
+	f, err := h.Client.backup.StoredProperties(ctx, utils.ToString(server.Name))
+	if err != nil {
+		return fmt.Errorf("failed to read stored properties: %w", err)
+	}
+
+	if profile.LevelName == nil {
+		levelName, ok := f.Get("level-name")
+		if !ok || levelName == "" {
+			levelName = backup.DefaultLevelName
+		}
+		profile.LevelName = utils.String(levelName)
+	}
+	if seed, ok := f.Get("level-seed"); profile.Seed == nil && ok && seed != "" {
+		profile.Seed = utils.String(seed)
+	}
+	return nil
+}
+
+func (h *Handler) DeleteServerProfile(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	err := h.Client.db.Client.DeleteServerProfile(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if errors.Is(err, types.ErrProfileNotFound) {
+		writeResponse(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = h.Client.db.Client.SetServerPorts(r.Context(), utils.ToString(h.Client.db.Table), serverID, nil)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, "server profile deleted")
+}
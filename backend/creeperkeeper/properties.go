@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/properties"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+// GetProperties returns the server's server.properties, from the instance
+// when it is running and from the stored world when it is not.
+func (h *Handler) GetProperties(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	f, live, err := h.readProperties(r.Context(), server)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, &types.ServerProperties{
+		ServerID:   server.ID,
+		Live:       live,
+		Properties: properties.Public(f),
+	})
+}
+
+// UpdateProperties changes keys in the server's server.properties, keeping
+// the rest of the file as it is. A running server only reads the file when
+// it starts, so restart can be asked for to apply the changes straight away.
+// The container leaves the file alone apart from the server profile's
+// difficulty, which it writes before every start, so difficulty can only be
+// changed here when the profile does not set it.
+func (h *Handler) UpdateProperties(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	update := &types.PropertiesUpdate{}
+	err := update.UnmarshallRequest(r.Body)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	values, err := update.Values()
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(values) == 0 {
+		writeResponse(w, r, http.StatusBadRequest, "properties must be provided")
+		return
+	}
+
+	problems := []string{}
+	for key, value := range values {
+		values[key], err = properties.ValidateServer(key, value)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		writeResponse(w, r, http.StatusBadRequest, strings.Join(problems, "; "))
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	profile, err := h.Client.db.Client.GetServerProfile(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil && !errors.Is(err, types.ErrProfileNotFound) {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// The container writes the profile's difficulty over the file on start.
+	if _, ok := values["difficulty"]; ok && profile != nil && profile.Difficulty != nil {
+		writeResponse(w, r, http.StatusConflict, "difficulty is set by the server profile, change it there")
+		return
+	}
+
+	f, live, err := h.readProperties(r.Context(), server)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for key, value := range values {
+		f.Set(key, value)
+	}
+
+	err = h.writeDataFile(r.Context(), server, properties.ServerFile, f.Bytes(), live)
+	if errors.Is(err, errNoStoredWorld) {
+		writeResponse(w, r, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	res := &types.ServerProperties{
+		ServerID:   server.ID,
+		Live:       live,
+		Properties: properties.Public(f),
+	}
+
+	if live && utils.ToBool(update.Restart) {
+		cmd, err := h.restartMinecraft(r.Context(), server)
+		if err != nil {
+			writeResponse(w, r, http.StatusInternalServerError, fmt.Errorf("properties updated, but failed to restart: %w", err).Error())
+			return
+		}
+		res.RestartCommandID = cmd.ID
+	}
+
+	writeResponse(w, r, http.StatusOK, res)
+}
+
+// readProperties reads server.properties. A server that has not written the
+// file yet has an empty one.
+func (h *Handler) readProperties(ctx context.Context, server *types.Server) (*properties.File, bool, error) {
+	b, live, err := h.readDataFile(ctx, server, properties.ServerFile)
+	if err != nil {
+		return nil, live, err
+	}
+
+	f, err := properties.Parse(bytes.NewReader(b))
+	return f, live, err
+}
+
+// restartMinecraft warns players, saves the world and restarts the
+// container without waiting for it. The returned command can be polled to
+// see when the restart is done.
+func (h *Handler) restartMinecraft(ctx context.Context, server *types.Server) (*types.Command, error) {
+	_, err := h.Client.console.Client.Exec(ctx, server, "say Server restarting to apply new settings")
+	if err != nil {
+		return nil, fmt.Errorf("failed to warn players: %w", err)
+	}
+
+	_, err = h.Client.console.Client.Exec(ctx, server, "save-all flush")
+	if err != nil {
+		return nil, fmt.Errorf("failed to save world: %w", err)
+	}
+
+	return h.Client.systemsmanager.Client.Send(ctx, utils.ToString(server.ID), []string{
+		utils.Concat("sudo docker restart -t 120 ", utils.ToString(server.Name)),
+	})
+}
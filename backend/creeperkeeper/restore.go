@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const restoreJobRoute string = "/creeperkeeper/jobs/restore"
+
+// RestoreServer starts replacing the server's world with a backup or any
+// archive in the world bucket. The restore runs as a job and its progress is
+// kept on the server record.
+func (h *Handler) RestoreServer(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	req := &types.RestoreRequest{}
+	err := req.UnmarshallRequest(r.Body)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if (req.BackupID == nil) == (req.Archive == nil) {
+		writeResponse(w, r, http.StatusBadRequest, "exactly one of backupID or archive must be provided")
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	params := map[string]string{}
+	if req.BackupID != nil {
+		_, err = h.Client.backup.Get(r.Context(), serverID, utils.ToString(req.BackupID))
+		if errors.Is(err, backup.ErrNotFound) {
+			writeResponse(w, r, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeResponse(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		params["backupID"] = utils.ToString(req.BackupID)
+	}
+	if req.Archive != nil {
+		params["archive"] = utils.ToString(req.Archive)
+	}
+	if req.VersionID != nil {
+		params["versionID"] = utils.ToString(req.VersionID)
+	}
+
+	h.startOperation(w, r, serverID, restoreJobRoute, types.NewOperation(types.OperationRestore, params))
+}
+
+// RestoreJob runs a restore started by RestoreServer: stop the server, take
+// a safety backup of the current world, swap in the selected archive and
+// start the server again, which syncs the restored world on boot.
+func (h *Handler) RestoreJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.loadOperation(r)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.restore(r.Context(), run)
+	run.finish(r.Context(), err)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, run.op)
+}
+
+func (h *Handler) restore(ctx context.Context, run *operationRun) error {
+	server := run.server
+	serverID := run.serverID
+	serverName := utils.ToString(server.Name)
+	params := run.op.Params
+
+	var m *backup.Manifest
+	archive := params["archive"]
+	if backupID, ok := params["backupID"]; ok {
+		err := run.step(ctx, "load backup", func() error {
+			var err error
+			m, err = h.Client.backup.Get(ctx, serverID, backupID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		archive = m.Archive
+	}
+
+	if utils.ToBool(server.IsRunning) {
+		err := run.step(ctx, "stop server", func() error {
+			return h.gracefulStop(ctx, server)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err := run.step(ctx, "safety backup", func() error {
+		safety, err := h.Client.backup.Create(ctx, &backup.Manifest{
+			ServerID:   serverID,
+			ServerName: serverName,
+			Trigger:    backup.TriggerPreRestore,
+		})
+		if errors.Is(err, backup.ErrNoWorld) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		run.op.Result = map[string]string{"safetyBackupID": safety.ID}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = run.step(ctx, "restore world", func() error {
+		return h.Client.backup.Restore(ctx, serverName, archive, params["versionID"], m)
+	})
+	if err != nil {
+		return err
+	}
+
+	return run.step(ctx, "start server", func() error {
+		err := h.Client.compute.Client.StartServer(ctx, serverID)
+		if err != nil {
+			return fmt.Errorf("failed to start instance: %w", err)
+		}
+		return nil
+	})
+}
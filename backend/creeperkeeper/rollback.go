@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/minecraft/anvil"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const rollbackJobRoute string = "/creeperkeeper/jobs/rollback"
+
+// RollbackRegion starts rolling back part of a dimension to an earlier
+// version of its region files, for undoing griefing without losing
+// progress elsewhere in the world.
+func (h *Handler) RollbackRegion(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	req := &types.RollbackRequest{}
+	err := req.UnmarshallRequest(r.Body)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, ok := anvil.DimensionDir(*req.Dimension); !ok {
+		writeResponse(w, r, http.StatusBadRequest, "unknown dimension: "+*req.Dimension)
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	params := map[string]string{
+		"dimension": *req.Dimension,
+		"minX":      strconv.Itoa(*req.MinX),
+		"minZ":      strconv.Itoa(*req.MinZ),
+		"maxX":      strconv.Itoa(*req.MaxX),
+		"maxZ":      strconv.Itoa(*req.MaxZ),
+	}
+	if req.AsOf != nil {
+		params["asOf"] = *req.AsOf
+	}
+	if req.VersionID != nil {
+		params["versionID"] = *req.VersionID
+	}
+
+	h.startOperation(w, r, serverID, rollbackJobRoute, types.NewOperation(types.OperationRollback, params))
+}
+
+// RollbackJob runs a rollback started by RollbackRegion: stop the server so
+// its world is synced, take a safety backup, roll back the region files and
+// start the server again if it was running.
+func (h *Handler) RollbackJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.loadOperation(r)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.rollback(r.Context(), run)
+	run.finish(r.Context(), err)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, run.op)
+}
+
+func (h *Handler) rollback(ctx context.Context, run *operationRun) error {
+	server := run.server
+	serverID := run.serverID
+	serverName := utils.ToString(server.Name)
+	params := run.op.Params
+
+	box, asOf, err := rollbackParams(params)
+	if err != nil {
+		return err
+	}
+
+	wasRunning := utils.ToBool(server.IsRunning)
+	if wasRunning {
+		err = run.step(ctx, "stop server", func() error {
+			return h.gracefulStop(ctx, server)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	run.op.Result = map[string]string{}
+	err = run.step(ctx, "safety backup", func() error {
+		safety, err := h.Client.backup.Create(ctx, &backup.Manifest{
+			ServerID:   serverID,
+			ServerName: serverName,
+			Trigger:    backup.TriggerPreRollback,
+		})
+		if err != nil {
+			return err
+		}
+		run.op.Result["safetyBackupID"] = safety.ID
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = run.step(ctx, "roll back regions", func() error {
+		report, err := h.Client.backup.Rollback(ctx, serverName, params["dimension"], box, asOf, params["versionID"])
+		if err != nil {
+			return err
+		}
+
+		run.op.Result["asOf"] = report.AsOf.Format(time.RFC3339)
+		run.op.Result["files"] = strconv.Itoa(len(report.Files))
+		run.op.Result["chunksRestored"] = strconv.Itoa(report.ChunksRestored)
+		run.op.Result["chunksRemoved"] = strconv.Itoa(report.ChunksRemoved)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if !wasRunning {
+		return nil
+	}
+
+	return run.step(ctx, "start server", func() error {
+		err := h.Client.compute.Client.StartServer(ctx, serverID)
+		if err != nil {
+			return fmt.Errorf("failed to start instance: %w", err)
+		}
+		return nil
+	})
+}
+
+func rollbackParams(params map[string]string) (backup.Box, time.Time, error) {
+	coords := map[string]int{}
+	for _, name := range []string{"minX", "minZ", "maxX", "maxZ"} {
+		v, err := strconv.Atoi(params[name])
+		if err != nil {
+			return backup.Box{}, time.Time{}, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		coords[name] = v
+	}
+
+	box := backup.Box{
+		MinX: coords["minX"],
+		MinZ: coords["minZ"],
+		MaxX: coords["maxX"],
+		MaxZ: coords["maxZ"],
+	}
+
+	var asOf time.Time
+	if s, ok := params["asOf"]; ok {
+		var err error
+		asOf, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return backup.Box{}, time.Time{}, fmt.Errorf("invalid asOf: %w", err)
+		}
+	}
+	return box, asOf, nil
+}
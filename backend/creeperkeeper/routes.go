@@ -9,5 +9,53 @@ func loadRoutes(mux *http.ServeMux, h *Handler) {
 	mux.HandleFunc("GET /creeperkeeper/server/list", h.ListServers)
 	mux.HandleFunc("POST /creeperkeeper/server/start", h.StartServer)
 	mux.HandleFunc("POST /creeperkeeper/server/stop", h.StopServer)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/state", h.UpdateServerState)
+	mux.HandleFunc("PATCH /creeperkeeper/servers/{serverID}/settings", h.UpdateServerSettings)
 	mux.HandleFunc("GET /creeperkeeper/server/ping/{serverID}", h.Ping)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/commands/{commandID}", h.GetCommand)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/backups", h.ListBackups)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/backups", h.CreateBackup)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/backups/{backupID}", h.GetBackup)
+	mux.HandleFunc("DELETE /creeperkeeper/servers/{serverID}/backups/{backupID}", h.DeleteBackup)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/backups/prune", h.PruneServerBackups)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/backups/{backupID}/prune-chunks", h.PruneChunks)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/backups/{backupID}/map", h.GetMap)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/backups/{backupID}/map", h.RenderMap)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/download", h.DownloadWorld)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/downloads", h.ListDownloads)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/downloads/{downloadID}", h.GetDownload)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/uploads", h.CreateUpload)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/uploads/{uploadID}/import", h.ImportUpload)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/tasks", h.ListTasks)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/world/stats", h.GetWorldStats)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/world/stats", h.AnalyzeWorld)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/profile", h.GetServerProfile)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/profile", h.CreateServerProfile)
+	mux.HandleFunc("PUT /creeperkeeper/servers/{serverID}/profile", h.UpdateServerProfile)
+	mux.HandleFunc("DELETE /creeperkeeper/servers/{serverID}/profile", h.DeleteServerProfile)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/container", h.GetContainerSpec)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/upgrade", h.UpgradeServer)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/properties", h.GetProperties)
+	mux.HandleFunc("PATCH /creeperkeeper/servers/{serverID}/properties", h.UpdateProperties)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/access/{list}", h.GetAccessList)
+	mux.HandleFunc("PATCH /creeperkeeper/servers/{serverID}/access/{list}", h.UpdateAccessList)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/players", h.ListPlayers)
+	mux.HandleFunc("GET /creeperkeeper/servers/{serverID}/players/{playerID}", h.GetPlayer)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/restore", h.RestoreServer)
+	mux.HandleFunc("POST /creeperkeeper/servers/{serverID}/rollback", h.RollbackRegion)
+
+	mux.HandleFunc("POST /creeperkeeper/jobs/idle-shutdown", h.IdleShutdown)
+	mux.HandleFunc("POST /creeperkeeper/jobs/stop", h.StopJob)
+	mux.HandleFunc("POST /creeperkeeper/jobs/backup", h.BackupJob)
+	mux.HandleFunc("POST /creeperkeeper/jobs/download", h.DownloadJob)
+	mux.HandleFunc("POST /creeperkeeper/jobs/restore", h.RestoreJob)
+	mux.HandleFunc("POST /creeperkeeper/jobs/import", h.ImportJob)
+	mux.HandleFunc("POST /creeperkeeper/jobs/world-stats", h.WorldStatsJob)
+	mux.HandleFunc("POST /creeperkeeper/jobs/prune-chunks", h.PruneChunksJob)
+	mux.HandleFunc("POST /creeperkeeper/jobs/rollback", h.RollbackJob)
+	mux.HandleFunc("POST /creeperkeeper/jobs/render-map", h.RenderMapJob)
+	mux.HandleFunc("POST /creeperkeeper/jobs/sync-access", h.SyncAccessJob)
+	mux.HandleFunc("POST /creeperkeeper/jobs/upgrade", h.UpgradeJob)
+	mux.HandleFunc("POST /creeperkeeper/jobs/prune-backups", h.PruneBackups)
+	mux.HandleFunc("POST /creeperkeeper/jobs/scheduled-backups", h.ScheduledBackups)
 }
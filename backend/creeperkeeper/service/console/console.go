@@ -0,0 +1,79 @@
+package console
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/hnucamendi/creeper-keeper/service/console/rcon"
+	"github.com/hnucamendi/creeper-keeper/service/console/ssm"
+	"github.com/hnucamendi/creeper-keeper/service/systemsmanager"
+	"github.com/hnucamendi/creeper-keeper/types"
+)
+
+type Console interface {
+	Exec(ctx context.Context, server *types.Server, command string) (string, error)
+}
+
+type Client struct {
+	Client   Console
+	fallback Console
+	direct   Console
+}
+
+type Opts func(*Client)
+
+// WithSystemsManager sends commands through rcon-cli on the instance.
+func WithSystemsManager(sm *systemsmanager.Client) Opts {
+	return func(c *Client) {
+		c.fallback = &ssm.Console{SystemsManager: sm.Client}
+	}
+}
+
+// WithRCON sends commands straight to the server's RCON port, falling back
+// to the systems manager console when the port cannot be reached.
+func WithRCON(password string) Opts {
+	return func(c *Client) {
+		if password == "" {
+			return
+		}
+		c.direct = &rcon.Console{
+			Password: password,
+			Timeout:  rcon.DefaultTimeout,
+		}
+	}
+}
+
+func NewConsole(fn ...Opts) *Client {
+	c := &Client{}
+	for _, f := range fn {
+		f(c)
+	}
+
+	switch {
+	case c.direct != nil && c.fallback != nil:
+		c.Client = &directFirst{direct: c.direct, fallback: c.fallback}
+	case c.direct != nil:
+		c.Client = c.direct
+	default:
+		c.Client = c.fallback
+	}
+
+	return c
+}
+
+type directFirst struct {
+	direct   Console
+	fallback Console
+}
+
+func (d *directFirst) Exec(ctx context.Context, server *types.Server, command string) (string, error) {
+	out, err := d.direct.Exec(ctx, server, command)
+	if errors.Is(err, rcon.ErrUnavailable) {
+		if server.HasRCON() {
+			log.Println("rcon unavailable, falling back to systems manager:", err)
+		}
+		return d.fallback.Exec(ctx, server, command)
+	}
+	return out, err
+}
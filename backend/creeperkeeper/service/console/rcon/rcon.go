@@ -0,0 +1,222 @@
+package rcon
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const (
+	DefaultPort    int           = 25575
+	DefaultTimeout time.Duration = 5 * time.Second
+
+	typeResponse     int32 = 0
+	typeCommand      int32 = 2
+	typeAuthResponse int32 = 2
+	typeAuth         int32 = 3
+
+	// Minecraft rejects requests over 1446 bytes and splits responses into
+	// packets of at most 4096 bytes of body.
+	maxCommandLength  int = 1446
+	maxResponseLength int = 4096
+	headerLength      int = 10
+)
+
+var (
+	ErrAuth        = errors.New("rcon authentication failed")
+	ErrUnavailable = errors.New("rcon unavailable")
+)
+
+type packet struct {
+	id   int32
+	typ  int32
+	body []byte
+}
+
+// Client is a connection to a server speaking the Source RCON protocol.
+type Client struct {
+	conn    net.Conn
+	timeout time.Duration
+	lastID  int32
+}
+
+// Dial connects to addr and authenticates with password.
+func Dial(ctx context.Context, addr string, password string, timeout time.Duration) (*Client, error) {
+	d := &net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		conn:    conn,
+		timeout: timeout,
+	}
+
+	err = c.auth(ctx, password)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Command runs cmd on the server and returns its response, joining responses
+// that were split over several packets.
+func (c *Client) Command(ctx context.Context, cmd string) (string, error) {
+	if len(cmd) > maxCommandLength {
+		return "", fmt.Errorf("command is %d bytes, limit is %d", len(cmd), maxCommandLength)
+	}
+
+	c.setDeadline(ctx)
+
+	id := c.nextID()
+	err := c.write(packet{id: id, typ: typeCommand, body: []byte(cmd)})
+	if err != nil {
+		return "", err
+	}
+
+	// The server answers requests in order, so the reply to this empty
+	// packet marks the end of the command response.
+	end := c.nextID()
+	err = c.write(packet{id: end, typ: typeResponse})
+	if err != nil {
+		return "", err
+	}
+
+	var res strings.Builder
+	for {
+		p, err := c.read()
+		if err != nil {
+			return "", err
+		}
+
+		switch p.id {
+		case id:
+			res.Write(p.body)
+		case end:
+			return res.String(), nil
+		default:
+			return "", fmt.Errorf("unexpected response id %d", p.id)
+		}
+	}
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) auth(ctx context.Context, password string) error {
+	c.setDeadline(ctx)
+
+	id := c.nextID()
+	err := c.write(packet{id: id, typ: typeAuth, body: []byte(password)})
+	if err != nil {
+		return err
+	}
+
+	for {
+		p, err := c.read()
+		if err != nil {
+			return err
+		}
+
+		// Some servers send an empty response value ahead of the auth response
+		if p.typ != typeAuthResponse {
+			continue
+		}
+
+		if p.id == -1 {
+			return ErrAuth
+		}
+
+		if p.id != id {
+			return fmt.Errorf("unexpected auth response id %d", p.id)
+		}
+
+		return nil
+	}
+}
+
+func (c *Client) nextID() int32 {
+	c.lastID++
+	return c.lastID
+}
+
+func (c *Client) setDeadline(ctx context.Context) {
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.conn.SetDeadline(deadline)
+}
+
+func (c *Client) write(p packet) error {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, int32(len(p.body)+headerLength))
+	binary.Write(buf, binary.LittleEndian, p.id)
+	binary.Write(buf, binary.LittleEndian, p.typ)
+	buf.Write(p.body)
+	buf.Write([]byte{0, 0})
+
+	_, err := c.conn.Write(buf.Bytes())
+	return err
+}
+
+func (c *Client) read() (packet, error) {
+	var length int32
+	err := binary.Read(c.conn, binary.LittleEndian, &length)
+	if err != nil {
+		return packet{}, err
+	}
+
+	if length < int32(headerLength) || length > int32(maxResponseLength+headerLength) {
+		return packet{}, fmt.Errorf("invalid packet length %d", length)
+	}
+
+	buf := make([]byte, length)
+	_, err = io.ReadFull(c.conn, buf)
+	if err != nil {
+		return packet{}, err
+	}
+
+	return packet{
+		id:   int32(binary.LittleEndian.Uint32(buf[0:4])),
+		typ:  int32(binary.LittleEndian.Uint32(buf[4:8])),
+		body: buf[8 : length-2],
+	}, nil
+}
+
+// Console runs commands over a direct RCON connection to the server's
+// public IP and RCON port. Failures to connect or authenticate wrap
+// ErrUnavailable.
+type Console struct {
+	Password string
+	Timeout  time.Duration
+}
+
+func (c *Console) Exec(ctx context.Context, server *types.Server, command string) (string, error) {
+	if !server.HasRCON() {
+		return "", fmt.Errorf("%w: not set up on server %s", ErrUnavailable, utils.ToString(server.ID))
+	}
+
+	addr := net.JoinHostPort(utils.ToString(server.IP), strconv.Itoa(server.RCONPort()))
+	client, err := Dial(ctx, addr, c.Password, c.Timeout)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	defer client.Close()
+
+	return client.Command(ctx, command)
+}
@@ -0,0 +1,268 @@
+package rcon
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const testPassword = "hunter2"
+
+// fakeServer speaks enough RCON to stand in for Minecraft: it checks the
+// password, splits long responses into packets of at most
+// maxResponseLength bytes and echoes empty packets back. With silent set it
+// accepts connections and never answers.
+type fakeServer struct {
+	ln        net.Listener
+	responses map[string]string
+	silent    bool
+}
+
+func newFakeServer(t *testing.T, responses map[string]string, silent bool) *fakeServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeServer{ln: ln, responses: responses, silent: silent}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		p, err := readTestPacket(r)
+		if err != nil {
+			return
+		}
+		if s.silent {
+			continue
+		}
+
+		switch p.typ {
+		case typeAuth:
+			// Like Source servers, send an empty response value first.
+			writeTestPacket(conn, packet{id: p.id, typ: typeResponse})
+			id := p.id
+			if string(p.body) != testPassword {
+				id = -1
+			}
+			writeTestPacket(conn, packet{id: id, typ: typeAuthResponse})
+		case typeCommand:
+			res := s.responses[string(p.body)]
+			for len(res) > maxResponseLength {
+				writeTestPacket(conn, packet{id: p.id, typ: typeResponse, body: []byte(res[:maxResponseLength])})
+				res = res[maxResponseLength:]
+			}
+			writeTestPacket(conn, packet{id: p.id, typ: typeResponse, body: []byte(res)})
+		default:
+			writeTestPacket(conn, packet{id: p.id, typ: typeResponse})
+		}
+	}
+}
+
+func readTestPacket(r io.Reader) (packet, error) {
+	var length int32
+	err := binary.Read(r, binary.LittleEndian, &length)
+	if err != nil {
+		return packet{}, err
+	}
+	buf := make([]byte, length)
+	_, err = io.ReadFull(r, buf)
+	if err != nil {
+		return packet{}, err
+	}
+	return packet{
+		id:   int32(binary.LittleEndian.Uint32(buf[0:4])),
+		typ:  int32(binary.LittleEndian.Uint32(buf[4:8])),
+		body: buf[8 : length-2],
+	}, nil
+}
+
+func writeTestPacket(w io.Writer, p packet) {
+	buf := make([]byte, 12, 14+len(p.body))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(p.body)+headerLength))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(p.id))
+	binary.LittleEndian.PutUint32(buf[8:12], uint32(p.typ))
+	buf = append(buf, p.body...)
+	buf = append(buf, 0, 0)
+	w.Write(buf)
+}
+
+func TestCommand(t *testing.T) {
+	long := strings.Repeat("0123456789", 1000)
+	s := newFakeServer(t, map[string]string{
+		"list":      "There are 0 of a max of 20 players online: ",
+		"save-all":  "",
+		"long-list": long,
+	}, false)
+
+	c, err := Dial(context.Background(), s.addr(), testPassword, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{command: "list", want: "There are 0 of a max of 20 players online: "},
+		{command: "save-all", want: ""},
+		// Spans three packets, the first two full.
+		{command: "long-list", want: long},
+		// Responses stay matched to their commands on a reused connection.
+		{command: "list", want: "There are 0 of a max of 20 players online: "},
+	}
+	for _, tt := range tests {
+		got, err := c.Command(context.Background(), tt.command)
+		if err != nil {
+			t.Fatalf("Command(%q): %v", tt.command, err)
+		}
+		if got != tt.want {
+			t.Errorf("Command(%q) = %d bytes, want %d bytes", tt.command, len(got), len(tt.want))
+		}
+	}
+}
+
+func TestCommandTooLong(t *testing.T) {
+	s := newFakeServer(t, nil, false)
+	c, err := Dial(context.Background(), s.addr(), testPassword, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	_, err = c.Command(context.Background(), strings.Repeat("a", maxCommandLength+1))
+	if err == nil {
+		t.Fatal("expected an error for an oversized command")
+	}
+}
+
+func TestDialAuthFailure(t *testing.T) {
+	s := newFakeServer(t, nil, false)
+	_, err := Dial(context.Background(), s.addr(), "wrong", time.Second)
+	if !errors.Is(err, ErrAuth) {
+		t.Fatalf("Dial with a wrong password = %v, want ErrAuth", err)
+	}
+}
+
+func TestDialTimeout(t *testing.T) {
+	s := newFakeServer(t, nil, true)
+
+	start := time.Now()
+	_, err := Dial(context.Background(), s.addr(), testPassword, 200*time.Millisecond)
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("Dial to a silent server = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Dial took %v, want about the 200ms timeout", elapsed)
+	}
+}
+
+func TestDialContextDeadline(t *testing.T) {
+	s := newFakeServer(t, nil, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := Dial(ctx, s.addr(), testPassword, time.Minute)
+	if err == nil {
+		t.Fatal("expected the context deadline to end the dial")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Dial took %v, want about the 100ms context deadline", elapsed)
+	}
+}
+
+func TestConsoleExec(t *testing.T) {
+	s := newFakeServer(t, map[string]string{"list": "There are 1 of a max of 20 players online: Steve"}, false)
+	host, port, err := net.SplitHostPort(s.addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rconPort, _ := strconv.Atoi(port)
+	gamePort := types.DefaultGamePort
+
+	configured := &types.Server{
+		ID:    utils.String("i-123"),
+		IP:    utils.String(host),
+		Ports: &types.ServerPorts{Game: &gamePort, RCON: &rconPort},
+	}
+	unconfigured := &types.Server{
+		ID: utils.String("i-456"),
+		IP: utils.String(host),
+	}
+
+	tests := []struct {
+		name     string
+		console  *Console
+		server   *types.Server
+		want     string
+		wantErrs []error
+	}{
+		{
+			name:    "configured",
+			console: &Console{Password: testPassword, Timeout: time.Second},
+			server:  configured,
+			want:    "There are 1 of a max of 20 players online: Steve",
+		},
+		{
+			name:     "wrong password",
+			console:  &Console{Password: "wrong", Timeout: time.Second},
+			server:   configured,
+			wantErrs: []error{ErrUnavailable, ErrAuth},
+		},
+		{
+			name:     "not set up",
+			console:  &Console{Password: testPassword, Timeout: time.Second},
+			server:   unconfigured,
+			wantErrs: []error{ErrUnavailable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.console.Exec(context.Background(), tt.server, "list")
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("Exec error = %v, want %v", err, want)
+				}
+			}
+			if len(tt.wantErrs) == 0 && err != nil {
+				t.Fatalf("Exec: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Exec = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
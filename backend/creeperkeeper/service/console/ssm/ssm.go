@@ -0,0 +1,29 @@
+package ssm
+
+import (
+	"context"
+
+	"github.com/hnucamendi/creeper-keeper/service/systemsmanager"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+// Console runs commands through rcon-cli inside the server's container.
+type Console struct {
+	SystemsManager systemsmanager.SystemsManager
+}
+
+func (c *Console) Exec(ctx context.Context, server *types.Server, command string) (string, error) {
+	cmd := utils.Concat("sudo docker exec -i ", utils.ToString(server.Name), " rcon-cli ", utils.ShellQuote(command))
+	result, err := c.SystemsManager.Run(ctx, utils.ToString(server.ID), []string{cmd})
+	if err != nil {
+		return "", err
+	}
+
+	err = result.Err()
+	if err != nil {
+		return "", err
+	}
+
+	return utils.ToString(result.Stdout), nil
+}
@@ -15,10 +15,28 @@ const (
 )
 
 type Database interface {
-	RegisterServer(ctx context.Context, tableName string, serverID string, serverType string, serverIP string, serverName string, serverIsRunning bool, serverLastUpdated string) (bool, error)
+	RegisterServer(ctx context.Context, tableName string, serverID string, serverType string, serverIP string, serverName string, serverIsRunning bool, serverLastUpdated string, serverState string) (bool, error)
 	ListServers(ctx context.Context, tableName string) ([]types.Server, error)
 	ListServer(ctx context.Context, tableName string, serverID string) (*types.Server, error)
 	UpsertServer(ctx context.Context, tableName string, serverID string, serverIP string, serverName string) error
+	UpdateServerState(ctx context.Context, tableName string, serverID string, serverState string) error
+	UpdateServerSettings(ctx context.Context, tableName string, serverID string, settings *types.ServerSettings) error
+	SetServerEmptySince(ctx context.Context, tableName string, serverID string, emptySince *string) error
+	UpdateServerLastBackup(ctx context.Context, tableName string, serverID string, lastBackupAt string) error
+	RecordDownload(ctx context.Context, tableName string, download *types.Download) error
+	ListDownloads(ctx context.Context, tableName string, serverID string) ([]types.Download, error)
+	StartServerOperation(ctx context.Context, tableName string, serverID string, op *types.Operation) error
+	UpdateServerOperation(ctx context.Context, tableName string, serverID string, op *types.Operation) error
+	StartServerTask(ctx context.Context, tableName string, serverID string, key string, op *types.Operation) error
+	UpdateServerTask(ctx context.Context, tableName string, serverID string, key string, op *types.Operation) error
+	GetServerTask(ctx context.Context, tableName string, serverID string, key string) (*types.Task, error)
+	ListServerTasks(ctx context.Context, tableName string, serverID string) ([]types.Task, error)
+	GetAccessList(ctx context.Context, tableName string, serverID string, list string) (*types.AccessList, error)
+	PutAccessList(ctx context.Context, tableName string, list *types.AccessList) error
+	GetServerProfile(ctx context.Context, tableName string, serverID string) (*types.ServerProfile, error)
+	PutServerProfile(ctx context.Context, tableName string, profile *types.ServerProfile, create bool) error
+	DeleteServerProfile(ctx context.Context, tableName string, serverID string) error
+	SetServerPorts(ctx context.Context, tableName string, serverID string, ports *types.ServerPorts) error
 }
 
 type Client struct {
@@ -2,7 +2,8 @@ package dynamo
 
 import (
 	"context"
-
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -17,34 +18,39 @@ type DynamoAPI interface {
 	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
 	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
 	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
 }
 
 type Client struct {
 	*dynamodb.Client
 }
 
-func (db *Client) RegisterServer(ctx context.Context, tableName string, serverID string, serverType string, serverIP string, serverName string, serverIsRunning bool, serverLastUpdated string) (bool, error) {
-	_, err := db.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(tableName),
-		Item: map[string]types.AttributeValue{
-			"PK": &types.AttributeValueMemberS{
-				Value: serverID,
-			},
-			"SK": &types.AttributeValueMemberS{
-				Value: "serverdetails",
-			},
-			"ServerIP": &types.AttributeValueMemberS{
+func (db *Client) RegisterServer(ctx context.Context, tableName string, serverID string, serverType string, serverIP string, serverName string, serverIsRunning bool, serverLastUpdated string, serverState string) (bool, error) {
+	_, err := db.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(tableName),
+		Key:              serverKey(serverID),
+		UpdateExpression: aws.String("SET ServerIP = :ip, ServerName = :name, LastUpdated = :lastUpdated, IsRunning = :isRunning, #state = :state"),
+		ExpressionAttributeNames: map[string]string{
+			"#state": "State",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":ip": &types.AttributeValueMemberS{
 				Value: serverIP,
 			},
-			"ServerName": &types.AttributeValueMemberS{
+			":name": &types.AttributeValueMemberS{
 				Value: serverName,
 			},
-			"LastUpdated": &types.AttributeValueMemberS{
+			":lastUpdated": &types.AttributeValueMemberS{
 				Value: serverLastUpdated,
 			},
-			"IsRunning": &types.AttributeValueMemberBOOL{
+			":isRunning": &types.AttributeValueMemberBOOL{
 				Value: serverIsRunning,
 			},
+			":state": &types.AttributeValueMemberS{
+				Value: serverState,
+			},
 		},
 	})
 	if err != nil {
@@ -53,21 +59,39 @@ func (db *Client) RegisterServer(ctx context.Context, tableName string, serverID
 	return true, nil
 }
 
+// ListServers scans for server details rows, skipping the other rows kept
+// under each server.
 func (db *Client) ListServers(ctx context.Context, tableName string) ([]cktypes.Server, error) {
-	input := &dynamodb.ScanInput{TableName: aws.String(tableName)}
-
-	out, err := db.Client.Scan(ctx, input)
-	if err != nil {
-		return nil, err
+	input := &dynamodb.ScanInput{
+		TableName:        aws.String(tableName),
+		FilterExpression: aws.String("SK = :sk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":sk": &types.AttributeValueMemberS{
+				Value: "serverdetails",
+			},
+		},
 	}
 
-	var servers []cktypes.Server
-	err = attributevalue.UnmarshalListOfMaps(out.Items, &servers)
-	if err != nil {
+	// Other rows share the table, so a page may hold few servers
+	servers := []cktypes.Server{}
+	p := dynamodb.NewScanPaginator(db.Client, input)
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []cktypes.Server
+		err = attributevalue.UnmarshalListOfMaps(out.Items, &page)
+		if err != nil {
+			return nil, err
+		}
+		servers = append(servers, page...)
 	}
 
 	return servers, nil
 }
+
 func (db *Client) ListServer(ctx context.Context, tableName string, serverID string) (*cktypes.Server, error) {
 	input := &dynamodb.GetItemInput{
 		TableName: aws.String(tableName),
@@ -92,42 +116,640 @@ func (db *Client) ListServer(ctx context.Context, tableName string, serverID str
 }
 
 func (db *Client) UpsertServer(ctx context.Context, tableName string, serverID string, serverIP string, serverName string) error {
-	zone, err := time.LoadLocation("America/New_York")
+	lastUpdated, err := now()
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:        aws.String(tableName),
+		Key:              serverKey(serverID),
+		UpdateExpression: aws.String("SET ServerIP = :ip, ServerName = :name, LastUpdated = :lastUpdated, IsRunning = :isRunning, #state = :state REMOVE EmptySince"),
+		ExpressionAttributeNames: map[string]string{
+			"#state": "State",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":ip": &types.AttributeValueMemberS{
+				Value: serverIP,
+			},
+			":name": &types.AttributeValueMemberS{
+				Value: serverName,
+			},
+			":lastUpdated": &types.AttributeValueMemberS{
+				Value: lastUpdated,
+			},
+			":isRunning": &types.AttributeValueMemberBOOL{
+				Value: false,
+			},
+			":state": &types.AttributeValueMemberS{
+				Value: cktypes.StateStopped,
+			},
+		},
+	}
+	_, err = db.Client.UpdateItem(ctx, input)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateServerState records the Minecraft server's state. Becoming ready also
+// stamps ReadyAt, in RFC3339 UTC. It fails with ErrServerNotFound rather than
+// creating a row for an unknown server.
+func (db *Client) UpdateServerState(ctx context.Context, tableName string, serverID string, serverState string) error {
+	lastUpdated, err := now()
+	if err != nil {
+		return err
+	}
+
+	update := "SET #state = :state, LastUpdated = :lastUpdated"
+	values := map[string]types.AttributeValue{
+		":state": &types.AttributeValueMemberS{
+			Value: serverState,
+		},
+		":lastUpdated": &types.AttributeValueMemberS{
+			Value: lastUpdated,
+		},
+	}
+	if serverState == cktypes.StateReady {
+		update += ", ReadyAt = :readyAt"
+		values[":readyAt"] = &types.AttributeValueMemberS{
+			Value: time.Now().UTC().Format(time.RFC3339),
+		}
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(tableName),
+		Key:                 serverKey(serverID),
+		UpdateExpression:    aws.String(update),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+		ExpressionAttributeNames: map[string]string{
+			"#state": "State",
+		},
+		ExpressionAttributeValues: values,
+	}
+	_, err = db.Client.UpdateItem(ctx, input)
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return cktypes.ErrServerNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateServerSettings sets the settings that are present and leaves the
+// rest untouched. It fails with ErrServerNotFound for an unknown server.
+func (db *Client) UpdateServerSettings(ctx context.Context, tableName string, serverID string, settings *cktypes.ServerSettings) error {
+	item, err := attributevalue.MarshalMap(settings)
+	if err != nil {
+		return err
+	}
+
+	if len(item) == 0 {
+		return nil
+	}
+
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+	sets := []string{}
+	for name, value := range item {
+		names["#"+name] = name
+		values[":"+name] = value
+		sets = append(sets, "#"+name+" = :"+name)
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(tableName),
+		Key:                       serverKey(serverID),
+		UpdateExpression:          aws.String("SET " + strings.Join(sets, ", ")),
+		ConditionExpression:       aws.String("attribute_exists(PK)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+	_, err = db.Client.UpdateItem(ctx, input)
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return cktypes.ErrServerNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// SetServerEmptySince records when the server was first seen without players.
+// A nil emptySince clears it. It fails with ErrServerNotFound for an unknown
+// server.
+func (db *Client) SetServerEmptySince(ctx context.Context, tableName string, serverID string, emptySince *string) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(tableName),
+		Key:                 serverKey(serverID),
+		UpdateExpression:    aws.String("REMOVE EmptySince"),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	}
+
+	if emptySince != nil {
+		input.UpdateExpression = aws.String("SET EmptySince = :emptySince")
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":emptySince": &types.AttributeValueMemberS{
+				Value: *emptySince,
+			},
+		}
+	}
+
+	_, err := db.Client.UpdateItem(ctx, input)
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return cktypes.ErrServerNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateServerLastBackup records when the last scheduled backup was taken.
+// It fails with ErrServerNotFound for an unknown server.
+func (db *Client) UpdateServerLastBackup(ctx context.Context, tableName string, serverID string, lastBackupAt string) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(tableName),
+		Key:                 serverKey(serverID),
+		UpdateExpression:    aws.String("SET LastBackupAt = :lastBackupAt"),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":lastBackupAt": &types.AttributeValueMemberS{
+				Value: lastBackupAt,
+			},
+		},
+	}
+	_, err := db.Client.UpdateItem(ctx, input)
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return cktypes.ErrServerNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// RecordDownload stores an audit row for a world download. The row's sort
+// key orders a server's downloads by time.
+func (db *Client) RecordDownload(ctx context.Context, tableName string, download *cktypes.Download) error {
+	download.SK = aws.String(cktypes.DownloadSKPrefix + aws.ToString(download.CreatedAt) + "#" + aws.ToString(download.ID))
+
+	item, err := attributevalue.MarshalMap(download)
 	if err != nil {
 		return err
 	}
-	lastUpdated := time.Now().In(zone).Format(time.DateTime)
 
 	input := &dynamodb.PutItemInput{
 		TableName: aws.String(tableName),
-		Item: map[string]types.AttributeValue{
-			"PK": &types.AttributeValueMemberS{
+		Item:      item,
+	}
+	_, err = db.Client.PutItem(ctx, input)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListDownloads returns the server's download audit rows, newest first.
+func (db *Client) ListDownloads(ctx context.Context, tableName string, serverID string) ([]cktypes.Download, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{
 				Value: serverID,
 			},
-			"SK": &types.AttributeValueMemberS{
-				Value: "serverdetails",
+			":sk": &types.AttributeValueMemberS{
+				Value: cktypes.DownloadSKPrefix,
 			},
-			"ServerIP": &types.AttributeValueMemberS{
-				Value: serverIP,
+		},
+		ScanIndexForward: aws.Bool(false),
+	}
+
+	downloads := []cktypes.Download{}
+	p := dynamodb.NewQueryPaginator(db.Client, input)
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []cktypes.Download
+		err = attributevalue.UnmarshalListOfMaps(out.Items, &page)
+		if err != nil {
+			return nil, err
+		}
+		downloads = append(downloads, page...)
+	}
+	return downloads, nil
+}
+
+// StartServerOperation records op as the server's current operation unless
+// another one is still in progress. An unfinished operation that has not been
+// updated within OperationStaleAfter is taken over.
+func (db *Client) StartServerOperation(ctx context.Context, tableName string, serverID string, op *cktypes.Operation) error {
+	value, err := attributevalue.Marshal(op)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(tableName),
+		Key:                 serverKey(serverID),
+		UpdateExpression:    aws.String("SET #operation = :operation"),
+		ConditionExpression: aws.String("attribute_exists(PK) AND (attribute_not_exists(#operation) OR #operation.#status IN (:succeeded, :failed) OR #operation.#updatedAt < :staleBefore)"),
+		ExpressionAttributeNames: map[string]string{
+			"#operation": "Operation",
+			"#status":    "Status",
+			"#updatedAt": "UpdatedAt",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":operation": value,
+			":succeeded": &types.AttributeValueMemberS{
+				Value: cktypes.OperationSucceeded,
 			},
-			"ServerName": &types.AttributeValueMemberS{
-				Value: serverName,
+			":failed": &types.AttributeValueMemberS{
+				Value: cktypes.OperationFailed,
 			},
-			"LastUpdated": &types.AttributeValueMemberS{
-				Value: lastUpdated,
+			// RFC3339 UTC times sort as strings
+			":staleBefore": &types.AttributeValueMemberS{
+				Value: time.Now().UTC().Add(-cktypes.OperationStaleAfter).Format(time.RFC3339),
 			},
-			"IsRunning": &types.AttributeValueMemberBOOL{
-				Value: false,
+		},
+	}
+	_, err = db.Client.UpdateItem(ctx, input)
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return cktypes.ErrOperationInProgress
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateServerOperation saves the progress of op, as long as it is still the
+// server's current operation.
+func (db *Client) UpdateServerOperation(ctx context.Context, tableName string, serverID string, op *cktypes.Operation) error {
+	value, err := attributevalue.Marshal(op)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(tableName),
+		Key:                 serverKey(serverID),
+		UpdateExpression:    aws.String("SET #operation = :operation"),
+		ConditionExpression: aws.String("#operation.#id = :id"),
+		ExpressionAttributeNames: map[string]string{
+			"#operation": "Operation",
+			"#id":        "ID",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":operation": value,
+			":id": &types.AttributeValueMemberS{
+				Value: aws.ToString(op.ID),
+			},
+		},
+	}
+	_, err = db.Client.UpdateItem(ctx, input)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// StartServerTask records op as the server's task under key unless another
+// task with that key is still in progress. An unfinished task that has not
+// been updated within OperationStaleAfter is taken over.
+func (db *Client) StartServerTask(ctx context.Context, tableName string, serverID string, key string, op *cktypes.Operation) error {
+	item, err := attributevalue.MarshalMap(&cktypes.Task{
+		ServerID:  aws.String(serverID),
+		SK:        aws.String(cktypes.TaskSKPrefix + key),
+		Key:       aws.String(key),
+		Operation: op,
+	})
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) OR #operation.#status IN (:succeeded, :failed) OR #operation.#updatedAt < :staleBefore"),
+		ExpressionAttributeNames: map[string]string{
+			"#operation": "Operation",
+			"#status":    "Status",
+			"#updatedAt": "UpdatedAt",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":succeeded": &types.AttributeValueMemberS{
+				Value: cktypes.OperationSucceeded,
+			},
+			":failed": &types.AttributeValueMemberS{
+				Value: cktypes.OperationFailed,
+			},
+			":staleBefore": &types.AttributeValueMemberS{
+				Value: time.Now().UTC().Add(-cktypes.OperationStaleAfter).Format(time.RFC3339),
 			},
 		},
 	}
 	_, err = db.Client.PutItem(ctx, input)
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return cktypes.ErrOperationInProgress
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateServerTask saves the progress of op, as long as it is still the
+// server's task under key.
+func (db *Client) UpdateServerTask(ctx context.Context, tableName string, serverID string, key string, op *cktypes.Operation) error {
+	value, err := attributevalue.Marshal(op)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(tableName),
+		Key:                 taskKey(serverID, key),
+		UpdateExpression:    aws.String("SET #operation = :operation"),
+		ConditionExpression: aws.String("#operation.#id = :id"),
+		ExpressionAttributeNames: map[string]string{
+			"#operation": "Operation",
+			"#id":        "ID",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":operation": value,
+			":id": &types.AttributeValueMemberS{
+				Value: aws.ToString(op.ID),
+			},
+		},
+	}
+	_, err = db.Client.UpdateItem(ctx, input)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// GetServerTask returns the server's task under key, or nil when there is
+// none.
+func (db *Client) GetServerTask(ctx context.Context, tableName string, serverID string, key string) (*cktypes.Task, error) {
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key:       taskKey(serverID, key),
+	}
+	out, err := db.Client.GetItem(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var task cktypes.Task
+	err = attributevalue.UnmarshalMap(out.Item, &task)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// ListServerTasks returns the last task run under each of the server's task
+// keys.
+func (db *Client) ListServerTasks(ctx context.Context, tableName string, serverID string) ([]cktypes.Task, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{
+				Value: serverID,
+			},
+			":sk": &types.AttributeValueMemberS{
+				Value: cktypes.TaskSKPrefix,
+			},
+		},
+	}
+
+	tasks := []cktypes.Task{}
+	p := dynamodb.NewQueryPaginator(db.Client, input)
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var page []cktypes.Task
+		err = attributevalue.UnmarshalListOfMaps(out.Items, &page)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page...)
+	}
+	return tasks, nil
+}
+
+// GetAccessList returns a server's stored access list, or nil when none has
+// been stored.
+func (db *Client) GetAccessList(ctx context.Context, tableName string, serverID string, list string) (*cktypes.AccessList, error) {
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{
+				Value: serverID,
+			},
+			"SK": &types.AttributeValueMemberS{
+				Value: cktypes.AccessSKPrefix + list,
+			},
+		},
+	}
+	out, err := db.Client.GetItem(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+
+	var accessList cktypes.AccessList
+	err = attributevalue.UnmarshalMap(out.Item, &accessList)
+	if err != nil {
+		return nil, err
+	}
+	accessList.Stored = true
+	return &accessList, nil
+}
+
+func (db *Client) PutAccessList(ctx context.Context, tableName string, list *cktypes.AccessList) error {
+	list.SK = aws.String(cktypes.AccessSKPrefix + aws.ToString(list.List))
+
+	item, err := attributevalue.MarshalMap(list)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item:      item,
+	}
+	_, err = db.Client.PutItem(ctx, input)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetServerProfile returns the server's profile, or ErrProfileNotFound.
+func (db *Client) GetServerProfile(ctx context.Context, tableName string, serverID string) (*cktypes.ServerProfile, error) {
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key:       profileKey(serverID),
+	}
+	out, err := db.Client.GetItem(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, cktypes.ErrProfileNotFound
+	}
+
+	var profile cktypes.ServerProfile
+	err = attributevalue.UnmarshalMap(out.Item, &profile)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// PutServerProfile creates the server's profile when create is set, failing
+// with ErrProfileExists if it has one, and otherwise replaces it, failing
+// with ErrProfileNotFound if it has none.
+func (db *Client) PutServerProfile(ctx context.Context, tableName string, profile *cktypes.ServerProfile, create bool) error {
+	profile.SK = aws.String(cktypes.ProfileSK)
+
+	item, err := attributevalue.MarshalMap(profile)
+	if err != nil {
+		return err
+	}
+
+	condition, conflict := "attribute_exists(PK)", cktypes.ErrProfileNotFound
+	if create {
+		condition, conflict = "attribute_not_exists(PK)", cktypes.ErrProfileExists
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(tableName),
+		Item:                item,
+		ConditionExpression: aws.String(condition),
+	}
+	_, err = db.Client.PutItem(ctx, input)
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return conflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (db *Client) DeleteServerProfile(ctx context.Context, tableName string, serverID string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName:           aws.String(tableName),
+		Key:                 profileKey(serverID),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	}
+	_, err := db.Client.DeleteItem(ctx, input)
+	if err != nil {
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return cktypes.ErrProfileNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// SetServerPorts copies the profile's ports onto the server record, where the
+// status and console clients read them. Nil ports clear them.
+func (db *Client) SetServerPorts(ctx context.Context, tableName string, serverID string, ports *cktypes.ServerPorts) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(tableName),
+		Key:                 serverKey(serverID),
+		UpdateExpression:    aws.String("REMOVE Ports"),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	}
+
+	if ports != nil {
+		value, err := attributevalue.Marshal(ports)
+		if err != nil {
+			return err
+		}
+		input.UpdateExpression = aws.String("SET Ports = :ports")
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":ports": value,
+		}
+	}
+
+	_, err := db.Client.UpdateItem(ctx, input)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func profileKey(serverID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{
+			Value: serverID,
+		},
+		"SK": &types.AttributeValueMemberS{
+			Value: cktypes.ProfileSK,
+		},
+	}
+}
+
+func serverKey(serverID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{
+			Value: serverID,
+		},
+		"SK": &types.AttributeValueMemberS{
+			Value: "serverdetails",
+		},
+	}
+}
+
+func taskKey(serverID string, key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{
+			Value: serverID,
+		},
+		"SK": &types.AttributeValueMemberS{
+			Value: cktypes.TaskSKPrefix + key,
+		},
+	}
+}
+
+func now() (string, error) {
+	zone, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return "", err
+	}
+	return time.Now().In(zone).Format(time.DateTime), nil
+}
+
 func NewDatabase() (*Client, error) {
 	cfg, err := config.LoadDefaultConfig(context.Background())
 	if err != nil {
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/hnucamendi/creeper-keeper/service/jobs/lambda"
+)
+
+// Jobs runs work that outlives an API request by sending it to one of the
+// job routes asynchronously.
+type Jobs interface {
+	Enqueue(ctx context.Context, route string, body any) error
+}
+
+type Client struct {
+	Client Jobs
+}
+
+func NewJobs() *Client {
+	c := &Client{}
+	j, err := lambda.NewJobs()
+	if err != nil {
+		c.Client = nil
+	}
+	c.Client = j
+
+	return c
+}
@@ -0,0 +1,80 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdaTypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+type LambdaAPI interface {
+	Invoke(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error)
+}
+
+// Client enqueues jobs by invoking this function asynchronously with an API
+// Gateway shaped event, so jobs are routed like any other request.
+type Client struct {
+	*lambda.Client
+	FunctionName string
+}
+
+func (c *Client) Enqueue(ctx context.Context, route string, body any) error {
+	if c.FunctionName == "" {
+		return errors.New("function name is not set, cannot enqueue " + route)
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	event := events.APIGatewayV2HTTPRequest{
+		Version:  "2.0",
+		RouteKey: "POST " + route,
+		RawPath:  route,
+		Headers: map[string]string{
+			"content-type": "application/json",
+		},
+		Body: string(b),
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method: "POST",
+				Path:   route,
+			},
+		},
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	input := &lambda.InvokeInput{
+		FunctionName:   aws.String(c.FunctionName),
+		InvocationType: lambdaTypes.InvocationTypeEvent,
+		Payload:        payload,
+	}
+	_, err = c.Client.Invoke(ctx, input)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func NewJobs() (*Client, error) {
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Client:       lambda.NewFromConfig(cfg),
+		FunctionName: os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+	}, nil
+}
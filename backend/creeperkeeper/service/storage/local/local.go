@@ -0,0 +1,176 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+// Client stores objects as files under Root/<bucket>/<key>.
+type Client struct {
+	Root string
+}
+
+func (c *Client) Put(ctx context.Context, bucket string, key string, body io.ReadSeeker) error {
+	path, err := c.path(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	// Write beside the target and rename so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, body)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (c *Client) Get(ctx context.Context, bucket string, key string) (io.ReadCloser, error) {
+	path, err := c.path(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, types.ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// GetVersion only supports the current version, local storage keeps no history.
+func (c *Client) GetVersion(ctx context.Context, bucket string, key string, versionID string) (io.ReadCloser, error) {
+	if versionID != "" {
+		return nil, errors.New("local storage does not keep object versions")
+	}
+	return c.Get(ctx, bucket, key)
+}
+
+func (c *Client) List(ctx context.Context, bucket string, prefix string) ([]types.Object, error) {
+	root := filepath.Join(c.Root, bucket)
+	objects := []types.Object{}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".put-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, types.Object{
+			Key:          utils.String(key),
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return *objects[i].Key < *objects[j].Key
+	})
+	return objects, nil
+}
+
+// ListVersions fails, local storage keeps no history.
+func (c *Client) ListVersions(ctx context.Context, bucket string, prefix string) ([]types.Object, error) {
+	return nil, errors.New("local storage does not keep object versions")
+}
+
+func (c *Client) Delete(ctx context.Context, bucket string, key string) error {
+	path, err := c.path(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// PresignGet returns a file URL, local objects need no signature.
+func (c *Client) PresignGet(ctx context.Context, bucket string, key string, expires time.Duration) (string, error) {
+	path, err := c.path(bucket, key)
+	if err != nil {
+		return "", err
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(), nil
+}
+
+// PresignPut returns a file URL, the caller writes the file directly.
+func (c *Client) PresignPut(ctx context.Context, bucket string, key string, expires time.Duration) (string, error) {
+	return c.PresignGet(ctx, bucket, key, expires)
+}
+
+// path maps a key to a file, refusing keys that would escape the bucket.
+func (c *Client) path(bucket string, key string) (string, error) {
+	if !fs.ValidPath(key) {
+		return "", errors.New("invalid object key: " + key)
+	}
+	return filepath.Join(c.Root, bucket, filepath.FromSlash(key)), nil
+}
+
+func NewStorage(root string) *Client {
+	return &Client{
+		Root: root,
+	}
+}
@@ -0,0 +1,192 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/hnucamendi/creeper-keeper/types"
+)
+
+// uploadPartSize allows objects up to the 10,000 part limit times 64MB,
+// about 625GB, while keeping the parts buffered in memory small.
+const uploadPartSize int64 = 64 * 1024 * 1024
+
+type S3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+}
+
+type Client struct {
+	*s3.Client
+}
+
+// Put uploads body in parts when it is larger than one part, since world
+// archives can exceed the 5GB a single PutObject accepts.
+func (c *Client) Put(ctx context.Context, bucket string, key string, body io.ReadSeeker) error {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	_, err := manager.NewUploader(c.Client, func(u *manager.Uploader) {
+		u.PartSize = uploadPartSize
+	}).Upload(ctx, input)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) Get(ctx context.Context, bucket string, key string) (io.ReadCloser, error) {
+	return c.GetVersion(ctx, bucket, key, "")
+}
+
+// GetVersion reads a specific version of an object from a versioned bucket.
+// An empty versionID reads the current version.
+func (c *Client) GetVersion(ctx context.Context, bucket string, key string, versionID string) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+	out, err := c.Client.GetObject(ctx, input)
+	if err != nil {
+		var noSuchKey *s3Types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, types.ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (c *Client) List(ctx context.Context, bucket string, prefix string) ([]types.Object, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}
+
+	objects := []types.Object{}
+	p := s3.NewListObjectsV2Paginator(c.Client, input)
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range out.Contents {
+			objects = append(objects, types.Object{
+				Key:          o.Key,
+				Size:         aws.ToInt64(o.Size),
+				LastModified: aws.ToTime(o.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// ListVersions lists every version of the objects under prefix, including
+// delete markers, newest first for each key.
+func (c *Client) ListVersions(ctx context.Context, bucket string, prefix string) ([]types.Object, error) {
+	input := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}
+
+	versions := []types.Object{}
+	p := s3.NewListObjectVersionsPaginator(c.Client, input)
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range out.Versions {
+			versions = append(versions, types.Object{
+				Key:          v.Key,
+				Size:         aws.ToInt64(v.Size),
+				LastModified: aws.ToTime(v.LastModified),
+				VersionID:    v.VersionId,
+			})
+		}
+		for _, d := range out.DeleteMarkers {
+			versions = append(versions, types.Object{
+				Key:          d.Key,
+				LastModified: aws.ToTime(d.LastModified),
+				VersionID:    d.VersionId,
+				DeleteMarker: true,
+			})
+		}
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		if *versions[i].Key != *versions[j].Key {
+			return *versions[i].Key < *versions[j].Key
+		}
+		return versions[i].LastModified.After(versions[j].LastModified)
+	})
+	return versions, nil
+}
+
+func (c *Client) Delete(ctx context.Context, bucket string, key string) error {
+	input := &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	_, err := c.Client.DeleteObject(ctx, input)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// PresignGet returns a URL that downloads the object without credentials
+// until it expires.
+func (c *Client) PresignGet(ctx context.Context, bucket string, key string, expires time.Duration) (string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	req, err := s3.NewPresignClient(c.Client).PresignGetObject(ctx, input, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+// PresignPut returns a URL that uploads the object without credentials
+// until it expires.
+func (c *Client) PresignPut(ctx context.Context, bucket string, key string, expires time.Duration) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	req, err := s3.NewPresignClient(c.Client).PresignPutObject(ctx, input, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+func NewStorage() (*Client, error) {
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Client: s3.NewFromConfig(cfg),
+	}, nil
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/service/storage/local"
+	"github.com/hnucamendi/creeper-keeper/service/storage/s3"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+type StorageClient string
+
+const (
+	S3 StorageClient = "S3"
+)
+
+type Storage interface {
+	Put(ctx context.Context, bucket string, key string, body io.ReadSeeker) error
+	Get(ctx context.Context, bucket string, key string) (io.ReadCloser, error)
+	GetVersion(ctx context.Context, bucket string, key string, versionID string) (io.ReadCloser, error)
+	List(ctx context.Context, bucket string, prefix string) ([]types.Object, error)
+	ListVersions(ctx context.Context, bucket string, prefix string) ([]types.Object, error)
+	Delete(ctx context.Context, bucket string, key string) error
+	PresignGet(ctx context.Context, bucket string, key string, expires time.Duration) (string, error)
+	PresignPut(ctx context.Context, bucket string, key string, expires time.Duration) (string, error)
+}
+
+type Client struct {
+	Client Storage
+	Bucket *string
+}
+
+type Opts func(*Client)
+
+func WithBucket(bucket string) Opts {
+	return func(c *Client) {
+		c.Bucket = utils.String(bucket)
+	}
+}
+
+func WithClient(s StorageClient) Opts {
+	return func(c *Client) {
+		switch s {
+		case S3:
+			st, err := s3.NewStorage()
+			if err != nil {
+				c.Client = nil
+			}
+			c.Client = st
+		default:
+			c.Client = nil
+		}
+	}
+}
+
+// WithLocal keeps objects on the local filesystem under root, one directory
+// per bucket.
+func WithLocal(root string) Opts {
+	return func(c *Client) {
+		c.Client = local.NewStorage(root)
+	}
+}
+
+func NewStorage(fn ...Opts) *Client {
+	c := &Client{}
+	for _, f := range fn {
+		f(c)
+	}
+	return c
+}
@@ -2,22 +2,33 @@ package ssm
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	ssmTypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/hnucamendi/creeper-keeper/types"
 )
 
+const defaultWaitTimeout time.Duration = 10 * time.Minute
+
+// pollInterval is how long Wait sleeps between polls of a command.
+var pollInterval time.Duration = 2 * time.Second
+
 type SSMAPI interface {
 	SendCommand(ctx context.Context, params *ssm.SendCommandInput, optFns ...func(*ssm.Options)) (*ssm.SendCommandOutput, error)
+	GetCommandInvocation(ctx context.Context, params *ssm.GetCommandInvocationInput, optFns ...func(*ssm.Options)) (*ssm.GetCommandInvocationOutput, error)
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
 }
 
 type Client struct {
-	*ssm.Client
+	Client SSMAPI
 }
 
-func (c *Client) Send(ctx context.Context, serverID string, commands []string) error {
+func (c *Client) Send(ctx context.Context, serverID string, commands []string) (*types.Command, error) {
 	cmdInput := &ssm.SendCommandInput{
 		DocumentName: aws.String("AWS-RunShellScript"),
 		InstanceIds:  []string{serverID},
@@ -30,11 +41,95 @@ func (c *Client) Send(ctx context.Context, serverID string, commands []string) e
 			"workingDirectory": {"/home/ec2-user"},
 		},
 	}
-	_, err := c.Client.SendCommand(ctx, cmdInput)
+	out, err := c.Client.SendCommand(ctx, cmdInput)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Command{
+		ID:       out.Command.CommandId,
+		ServerID: aws.String(serverID),
+	}, nil
+}
+
+// GetCommand polls the invocation of a command once. An invocation that SSM
+// has not made visible yet is reported as pending.
+func (c *Client) GetCommand(ctx context.Context, command *types.Command) (*types.CommandResult, error) {
+	input := &ssm.GetCommandInvocationInput{
+		CommandId:  command.ID,
+		InstanceId: command.ServerID,
+	}
+
+	out, err := c.Client.GetCommandInvocation(ctx, input)
+	if err != nil {
+		var notFound *ssmTypes.InvocationDoesNotExist
+		if errors.As(err, &notFound) {
+			return &types.CommandResult{
+				ID:       command.ID,
+				ServerID: command.ServerID,
+				Status:   types.CommandPending,
+			}, nil
+		}
+		return nil, err
+	}
+
+	return &types.CommandResult{
+		ID:       command.ID,
+		ServerID: command.ServerID,
+		Status:   types.CommandStatus(out.Status),
+		ExitCode: out.ResponseCode,
+		Stdout:   aws.String(strings.TrimSpace(aws.ToString(out.StandardOutputContent))),
+		Stderr:   aws.String(strings.TrimSpace(aws.ToString(out.StandardErrorContent))),
+	}, nil
+}
+
+// Wait polls the command until it reaches a terminal status or the context
+// deadline passes. Contexts without a deadline wait at most ten minutes.
+func (c *Client) Wait(ctx context.Context, command *types.Command) (*types.CommandResult, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultWaitTimeout)
+		defer cancel()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+
+		result, err := c.GetCommand(ctx, command)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Terminal() {
+			return result, nil
+		}
+	}
+}
+
+// Run sends the commands and waits for their result.
+func (c *Client) Run(ctx context.Context, serverID string, commands []string) (*types.CommandResult, error) {
+	command, err := c.Send(ctx, serverID, commands)
+	if err != nil {
+		return nil, err
+	}
+	return c.Wait(ctx, command)
+}
+
+// GetParameter returns the decrypted value of a parameter store entry.
+func (c *Client) GetParameter(ctx context.Context, name string) (*string, error) {
+	input := &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	}
+
+	out, err := c.Client.GetParameter(ctx, input)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return out.Parameter.Value, nil
 }
 
 func NewSSM() (*Client, error) {
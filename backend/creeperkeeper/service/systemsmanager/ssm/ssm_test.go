@@ -0,0 +1,145 @@
+package ssm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmTypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/hnucamendi/creeper-keeper/types"
+)
+
+// fakeSSM answers each poll of a command with the next invocation, repeating
+// the last one once they run out.
+type fakeSSM struct {
+	invocations []invocation
+	polls       int
+	sent        *ssm.SendCommandInput
+}
+
+type invocation struct {
+	status   ssmTypes.CommandInvocationStatus
+	exitCode int32
+	stderr   string
+	err      error
+}
+
+func (f *fakeSSM) SendCommand(ctx context.Context, params *ssm.SendCommandInput, optFns ...func(*ssm.Options)) (*ssm.SendCommandOutput, error) {
+	f.sent = params
+	return &ssm.SendCommandOutput{Command: &ssmTypes.Command{CommandId: aws.String("cmd-1")}}, nil
+}
+
+func (f *fakeSSM) GetCommandInvocation(ctx context.Context, params *ssm.GetCommandInvocationInput, optFns ...func(*ssm.Options)) (*ssm.GetCommandInvocationOutput, error) {
+	i := f.invocations[min(f.polls, len(f.invocations)-1)]
+	f.polls++
+	if i.err != nil {
+		return nil, i.err
+	}
+	return &ssm.GetCommandInvocationOutput{
+		Status:                i.status,
+		ResponseCode:          i.exitCode,
+		StandardOutputContent: aws.String("out\n"),
+		StandardErrorContent:  aws.String(i.stderr),
+	}, nil
+}
+
+func (f *fakeSSM) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestWait(t *testing.T) {
+	pollInterval = time.Millisecond
+	t.Cleanup(func() { pollInterval = 2 * time.Second })
+
+	notVisible := invocation{err: &ssmTypes.InvocationDoesNotExist{}}
+	running := invocation{status: ssmTypes.CommandInvocationStatusInProgress}
+	errThrottled := errors.New("throttled")
+
+	tests := []struct {
+		name        string
+		invocations []invocation
+		timeout     time.Duration
+		wantStatus  types.CommandStatus
+		wantPolls   int
+		wantErr     error
+		wantFailed  bool
+	}{
+		{
+			name:        "success",
+			invocations: []invocation{notVisible, running, {status: ssmTypes.CommandInvocationStatusSuccess}},
+			wantStatus:  types.CommandSuccess,
+			wantPolls:   3,
+		},
+		{
+			name:        "non-zero exit",
+			invocations: []invocation{running, {status: ssmTypes.CommandInvocationStatusFailed, exitCode: 1, stderr: "no space left\n"}},
+			wantStatus:  types.CommandFailed,
+			wantPolls:   2,
+			wantFailed:  true,
+		},
+		{
+			name:        "unknown status keeps polling",
+			invocations: []invocation{{status: "Rebooting"}, {status: ssmTypes.CommandInvocationStatusSuccess}},
+			wantStatus:  types.CommandSuccess,
+			wantPolls:   2,
+		},
+		{
+			name:        "timeout",
+			invocations: []invocation{running},
+			timeout:     20 * time.Millisecond,
+			wantErr:     context.DeadlineExceeded,
+		},
+		{
+			name:        "poll error",
+			invocations: []invocation{running, {err: errThrottled}},
+			wantPolls:   2,
+			wantErr:     errThrottled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSSM{invocations: tt.invocations}
+			c := &Client{Client: fake}
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			result, err := c.Run(ctx, "i-123", []string{"echo hi"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Run = %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantPolls > 0 && fake.polls != tt.wantPolls {
+					t.Errorf("polled %d times, want %d", fake.polls, tt.wantPolls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if fake.polls != tt.wantPolls {
+				t.Errorf("polled %d times, want %d", fake.polls, tt.wantPolls)
+			}
+			if aws.ToString(result.ID) != "cmd-1" || aws.ToString(result.ServerID) != "i-123" {
+				t.Errorf("result is for command %s on %s, want cmd-1 on i-123", aws.ToString(result.ID), aws.ToString(result.ServerID))
+			}
+			if aws.ToString(result.Stdout) != "out" {
+				t.Errorf("Stdout = %q, want it trimmed to out", aws.ToString(result.Stdout))
+			}
+			if (result.Err() != nil) != tt.wantFailed {
+				t.Errorf("Err() = %v, want failed %v", result.Err(), tt.wantFailed)
+			}
+		})
+	}
+}
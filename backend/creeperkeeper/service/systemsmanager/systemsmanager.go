@@ -4,10 +4,15 @@ import (
 	"context"
 
 	"github.com/hnucamendi/creeper-keeper/service/systemsmanager/ssm"
+	"github.com/hnucamendi/creeper-keeper/types"
 )
 
 type SystemsManager interface {
-	Send(ctx context.Context, serverID string, commands []string) error
+	Send(ctx context.Context, serverID string, commands []string) (*types.Command, error)
+	GetCommand(ctx context.Context, command *types.Command) (*types.CommandResult, error)
+	Wait(ctx context.Context, command *types.Command) (*types.CommandResult, error)
+	Run(ctx context.Context, serverID string, commands []string) (*types.CommandResult, error)
+	GetParameter(ctx context.Context, name string) (*string, error)
 }
 
 type Client struct {
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const stopJobRoute string = "/creeperkeeper/jobs/stop"
+
+const (
+	instancePollInterval time.Duration = 10 * time.Second
+	instanceStopTimeout  time.Duration = 5 * time.Minute
+)
+
+// stopCountdown is broadcast to players before the server goes down. Each
+// warning is followed by its wait before the next step runs.
+var stopCountdown = []struct {
+	message string
+	wait    time.Duration
+}{
+	{message: "Server stopping in 30 seconds", wait: 20 * time.Second},
+	{message: "Server stopping in 10 seconds", wait: 10 * time.Second},
+}
+
+// StopJob runs a stop started by StopServer. A failed stop leaves the server
+// up and the failure on the operation.
+func (h *Handler) StopJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.loadOperation(r)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = run.step(r.Context(), "stop server", func() error {
+		return h.gracefulStop(r.Context(), run.server)
+	})
+	run.finish(r.Context(), err)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, run.op)
+}
+
+// gracefulStop warns players, saves and stops the Minecraft server, syncs the
+// world to S3 and only then stops the instance, returning once it has stopped
+// and is marked stopped. When the world cannot be synced the container is
+// started again so the server stays up.
+func (h *Handler) gracefulStop(ctx context.Context, server *types.Server) error {
+	serverID := utils.ToString(server.ID)
+	serverName := utils.ToString(server.Name)
+
+	for _, c := range stopCountdown {
+		_, err := h.Client.console.Client.Exec(ctx, server, utils.Concat("say ", c.message))
+		if err != nil {
+			return fmt.Errorf("failed to warn players: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.wait):
+		}
+	}
+
+	_, err := h.Client.console.Client.Exec(ctx, server, "save-all flush")
+	if err != nil {
+		return fmt.Errorf("failed to save world: %w", err)
+	}
+
+	// The connection may close before the server acknowledges stop, so the
+	// container exiting is what confirms the server is down.
+	_, err = h.Client.console.Client.Exec(ctx, server, "stop")
+	if err != nil {
+		log.Println("stop command did not complete cleanly:", err)
+	}
+
+	err = h.run(ctx, serverID, []string{utils.Concat("timeout 120 sudo docker wait ", serverName)})
+	if err != nil {
+		return h.restartAfter(ctx, server, fmt.Errorf("failed to stop minecraft server: %w", err))
+	}
+
+	err = h.run(ctx, serverID, []string{syncWorldCommand(serverName)})
+	if err != nil {
+		return h.restartAfter(ctx, server, fmt.Errorf("failed to sync world: %w", err))
+	}
+
+	return h.stopInstance(ctx, server)
+}
+
+// stopInstance stops the server's instance without saving or syncing the
+// world, and waits until it has stopped so it can be started again.
+func (h *Handler) stopInstance(ctx context.Context, server *types.Server) error {
+	serverID := utils.ToString(server.ID)
+
+	err := h.Client.compute.Client.StopServer(ctx, serverID)
+	if err != nil {
+		return fmt.Errorf("failed to stop instance: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, instanceStopTimeout)
+	defer cancel()
+
+	for {
+		status, err := h.Client.compute.Client.GetServerStatus(ctx, serverID)
+		if err != nil {
+			return err
+		}
+		if utils.ToString(status) == "STOPPED" {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("instance did not stop within %v", instanceStopTimeout)
+		case <-time.After(instancePollInterval):
+		}
+	}
+
+	err = h.Client.db.Client.UpsertServer(ctx, utils.ToString(h.Client.db.Table), serverID, utils.ToString(server.IP), utils.ToString(server.Name))
+	if err != nil {
+		return fmt.Errorf("failed to record server stopped: %w", err)
+	}
+	return nil
+}
+
+// restartAfter starts the minecraft container again after a failed stop step
+// and returns the original failure.
+func (h *Handler) restartAfter(ctx context.Context, server *types.Server, cause error) error {
+	err := h.run(ctx, utils.ToString(server.ID), []string{utils.Concat("sudo docker start ", utils.ToString(server.Name))})
+	if err != nil {
+		return fmt.Errorf("%w, and failed to restart minecraft server: %v", cause, err)
+	}
+	return fmt.Errorf("%w, minecraft server restarted", cause)
+}
+
+// run sends commands to the server and fails unless they exit with code zero.
+func (h *Handler) run(ctx context.Context, serverID string, commands []string) error {
+	result, err := h.Client.systemsmanager.Client.Run(ctx, serverID, commands)
+	if err != nil {
+		return err
+	}
+	return result.Err()
+}
+
+func syncWorldCommand(serverName string) string {
+	return utils.Concat("sudo aws s3 sync --delete data s3://", worldBucket, "/", serverName, "/")
+}
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/access"
+)
+
+// AccessSKPrefix is the sort key prefix of a server's access list rows,
+// followed by the list name.
+const AccessSKPrefix string = "access#"
+
+// Where an access list change was applied.
+const (
+	AccessAppliedLive    string = "live"
+	AccessAppliedStored  string = "stored"
+	AccessAppliedPending string = "pending"
+)
+
+// AccessList is the desired contents of one of a server's access lists.
+// It is kept in the table so it can be applied again to a new instance.
+type AccessList struct {
+	ServerID  *string        `json:"serverID" dynamodbav:"PK"`
+	SK        *string        `json:"-" dynamodbav:"SK"`
+	List      *string        `json:"list" dynamodbav:"List"`
+	Entries   []access.Entry `json:"entries" dynamodbav:"Entries"`
+	UpdatedAt *string        `json:"updatedAt,omitempty" dynamodbav:"UpdatedAt"`
+	// Stored is false when no list has been saved yet and the entries were
+	// read from the server's files instead.
+	Stored bool `json:"stored" dynamodbav:"-"`
+	// Applied says where the last change went: to the running server, to
+	// the stored world, or nowhere yet until the server next starts.
+	Applied *string `json:"applied,omitempty" dynamodbav:"-"`
+}
+
+// AccessListUpdate adds entries to a list, replacing any with the same name
+// or IP, and removes entries by player name, UUID or IP.
+type AccessListUpdate struct {
+	Add    []access.Entry `json:"add"`
+	Remove []string       `json:"remove"`
+}
+
+func (u *AccessListUpdate) UnmarshallRequest(b io.ReadCloser) error {
+	return json.NewDecoder(b).Decode(u)
+}
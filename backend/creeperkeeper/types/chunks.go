@@ -0,0 +1,32 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ChunkFilter selects chunks to prune from a backup. A chunk is pruned when
+// either rule matches it.
+type ChunkFilter struct {
+	// MinInhabitedTicks prunes chunks players have spent fewer than this
+	// many ticks in, summed over all players. Zero disables the rule.
+	MinInhabitedTicks int64 `json:"minInhabitedTicks"`
+	// Radius prunes overworld chunks whose centre is more than this many
+	// blocks from spawn. Zero disables the rule.
+	Radius int64 `json:"radius"`
+}
+
+func (f *ChunkFilter) UnmarshallRequest(b io.ReadCloser) error {
+	return json.NewDecoder(b).Decode(f)
+}
+
+func (f *ChunkFilter) Validate() error {
+	if f.MinInhabitedTicks < 0 || f.Radius < 0 {
+		return errors.New("minInhabitedTicks and radius must not be negative")
+	}
+	if f.MinInhabitedTicks == 0 && f.Radius == 0 {
+		return errors.New("one of minInhabitedTicks or radius must be set")
+	}
+	return nil
+}
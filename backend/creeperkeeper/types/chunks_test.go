@@ -0,0 +1,26 @@
+package types
+
+import "testing"
+
+func TestChunkFilterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  ChunkFilter
+		wantErr bool
+	}{
+		{name: "inhabited", filter: ChunkFilter{MinInhabitedTicks: 1200}},
+		{name: "radius", filter: ChunkFilter{Radius: 2048}},
+		{name: "both", filter: ChunkFilter{MinInhabitedTicks: 1200, Radius: 2048}},
+		{name: "empty", filter: ChunkFilter{}, wantErr: true},
+		{name: "negative inhabited", filter: ChunkFilter{MinInhabitedTicks: -1, Radius: 2048}, wantErr: true},
+		{name: "negative radius", filter: ChunkFilter{Radius: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+type CommandStatus string
+
+const (
+	CommandPending    CommandStatus = "Pending"
+	CommandInProgress CommandStatus = "InProgress"
+	CommandDelayed    CommandStatus = "Delayed"
+	CommandSuccess    CommandStatus = "Success"
+	CommandCancelled  CommandStatus = "Cancelled"
+	CommandTimedOut   CommandStatus = "TimedOut"
+	CommandFailed     CommandStatus = "Failed"
+	CommandCancelling CommandStatus = "Cancelling"
+)
+
+// Command is a handle to a command sent to a server.
+type Command struct {
+	ID       *string `json:"commandID"`
+	ServerID *string `json:"serverID"`
+}
+
+// CommandResult is the state and output of a command on a server.
+type CommandResult struct {
+	ID       *string       `json:"commandID"`
+	ServerID *string       `json:"serverID"`
+	Status   CommandStatus `json:"status"`
+	ExitCode int32         `json:"exitCode"`
+	Stdout   *string       `json:"stdout"`
+	Stderr   *string       `json:"stderr"`
+}
+
+// Terminal reports whether the command has stopped running.
+func (r *CommandResult) Terminal() bool {
+	switch r.Status {
+	case CommandSuccess, CommandCancelled, CommandTimedOut, CommandFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Err returns an error unless the command succeeded with exit code zero.
+func (r *CommandResult) Err() error {
+	if r.Status == CommandSuccess && r.ExitCode == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("command finished with status %s and exit code %d", r.Status, r.ExitCode)
+	if r.Stderr != nil && *r.Stderr != "" {
+		msg += ": " + *r.Stderr
+	}
+	return errors.New(msg)
+}
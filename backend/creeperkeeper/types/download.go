@@ -0,0 +1,21 @@
+package types
+
+// DownloadSKPrefix is the sort key prefix of download audit rows, which are
+// stored under the server's partition key next to its details.
+const DownloadSKPrefix string = "download#"
+
+// Download is a world archive handed out through a presigned URL. Each one is
+// recorded for auditing; the URL itself is never stored.
+type Download struct {
+	ServerID    *string `json:"serverID" dynamodbav:"PK"`
+	SK          *string `json:"-" dynamodbav:"SK"`
+	ID          *string `json:"downloadID" dynamodbav:"DownloadID"`
+	Archive     *string `json:"archive" dynamodbav:"Archive"`
+	Size        int64   `json:"size" dynamodbav:"Size"`
+	SHA256      *string `json:"sha256" dynamodbav:"SHA256"`
+	URL         *string `json:"url,omitempty" dynamodbav:"-"`
+	CreatedAt   *string `json:"createdAt" dynamodbav:"CreatedAt"`
+	ExpiresAt   *string `json:"expiresAt" dynamodbav:"ExpiresAt"`
+	RequestedBy *string `json:"requestedBy" dynamodbav:"RequestedBy"`
+	SourceIP    *string `json:"sourceIP" dynamodbav:"SourceIP"`
+}
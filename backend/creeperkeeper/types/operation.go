@@ -0,0 +1,180 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const (
+	OperationPending   string = "PENDING"
+	OperationRunning   string = "RUNNING"
+	OperationSucceeded string = "SUCCEEDED"
+	OperationFailed    string = "FAILED"
+)
+
+const (
+	OperationStop     string = "stop"
+	OperationBackup   string = "backup"
+	OperationDownload string = "download"
+	OperationRestore  string = "restore"
+	OperationImport   string = "import"
+	OperationStats    string = "world-stats"
+	OperationPrune    string = "prune-chunks"
+	OperationRollback string = "rollback"
+	OperationMap      string = "render-map"
+	OperationUpgrade  string = "upgrade"
+)
+
+// OperationStaleAfter is how long an unfinished operation can go without an
+// update before another may take over the server. Jobs run for at most 15
+// minutes and are not retried, so an operation this quiet died with its job,
+// or is an upgrade whose boot never reported back.
+const OperationStaleAfter time.Duration = 30 * time.Minute
+
+var ErrOperationInProgress = errors.New("another operation is in progress on this server")
+
+type OperationStep struct {
+	Name      *string `json:"name" dynamodbav:"Name"`
+	Status    *string `json:"status" dynamodbav:"Status"`
+	Error     *string `json:"error,omitempty" dynamodbav:"Error,omitempty"`
+	UpdatedAt *string `json:"updatedAt" dynamodbav:"UpdatedAt"`
+}
+
+// OperationProgress counts the units of work, such as region files, the
+// current step has done out of its total.
+type OperationProgress struct {
+	Done  int `json:"done" dynamodbav:"Done"`
+	Total int `json:"total" dynamodbav:"Total"`
+}
+
+// Operation is a long running change to a server, such as a restore. The
+// latest operation is kept on the server record with its progress.
+type Operation struct {
+	ID     *string           `json:"operationID" dynamodbav:"ID"`
+	Type   *string           `json:"type" dynamodbav:"Type"`
+	Status *string           `json:"status" dynamodbav:"Status"`
+	Params map[string]string `json:"params,omitempty" dynamodbav:"Params,omitempty"`
+	Steps  []OperationStep   `json:"steps" dynamodbav:"Steps"`
+	// Progress is set by steps that report how far along they are.
+	Progress *OperationProgress `json:"progress,omitempty" dynamodbav:"Progress,omitempty"`
+	// Result holds what a finished operation produced, such as a backup ID.
+	Result    map[string]string `json:"result,omitempty" dynamodbav:"Result,omitempty"`
+	Error     *string           `json:"error,omitempty" dynamodbav:"Error,omitempty"`
+	StartedAt *string           `json:"startedAt" dynamodbav:"StartedAt"`
+	UpdatedAt *string           `json:"updatedAt" dynamodbav:"UpdatedAt"`
+}
+
+// OperationJob is the body sent to a job route for a server, naming the
+// operation to run when the job runs one. Task is set when the operation is
+// one of the server's tasks rather than its current operation.
+type OperationJob struct {
+	ServerID    *string `json:"serverID"`
+	OperationID *string `json:"operationID"`
+	Task        *string `json:"task,omitempty"`
+}
+
+func (j *OperationJob) UnmarshallRequest(b io.ReadCloser) error {
+	return json.NewDecoder(b).Decode(j)
+}
+
+func NewOperation(opType string, params map[string]string) *Operation {
+	now := time.Now().UTC()
+	ts := utils.String(now.Format(time.RFC3339))
+	return &Operation{
+		ID:        utils.String(strconv.FormatInt(now.UnixNano(), 36)),
+		Type:      utils.String(opType),
+		Status:    utils.String(OperationPending),
+		Params:    params,
+		Steps:     []OperationStep{},
+		StartedAt: ts,
+		UpdatedAt: ts,
+	}
+}
+
+// Done reports whether the operation has finished, successfully or not.
+func (o *Operation) Done() bool {
+	status := utils.ToString(o.Status)
+	return status == OperationSucceeded || status == OperationFailed
+}
+
+// Active reports whether the operation is unfinished and has been updated
+// within OperationStaleAfter.
+func (o *Operation) Active() bool {
+	if o == nil || o.Done() {
+		return false
+	}
+
+	updatedAt, err := time.Parse(time.RFC3339, utils.ToString(o.UpdatedAt))
+	if err != nil {
+		return true
+	}
+	return time.Since(updatedAt) < OperationStaleAfter
+}
+
+// StartStep marks the operation running and appends a running step.
+func (o *Operation) StartStep(name string) {
+	ts := utils.String(time.Now().UTC().Format(time.RFC3339))
+	o.Status = utils.String(OperationRunning)
+	o.UpdatedAt = ts
+	o.Steps = append(o.Steps, OperationStep{
+		Name:      utils.String(name),
+		Status:    utils.String(OperationRunning),
+		UpdatedAt: ts,
+	})
+}
+
+// FinishStep records the outcome of the current step. A failed step fails
+// the whole operation.
+func (o *Operation) FinishStep(err error) {
+	ts := utils.String(time.Now().UTC().Format(time.RFC3339))
+	o.UpdatedAt = ts
+	if len(o.Steps) == 0 {
+		return
+	}
+
+	step := &o.Steps[len(o.Steps)-1]
+	step.UpdatedAt = ts
+	step.Status = utils.String(OperationSucceeded)
+	if err != nil {
+		step.Status = utils.String(OperationFailed)
+		step.Error = utils.String(err.Error())
+		o.Fail(err)
+	}
+}
+
+func (o *Operation) Fail(err error) {
+	o.Status = utils.String(OperationFailed)
+	o.Error = utils.String(err.Error())
+	o.UpdatedAt = utils.String(time.Now().UTC().Format(time.RFC3339))
+}
+
+func (o *Operation) Succeed() {
+	o.Status = utils.String(OperationSucceeded)
+	o.UpdatedAt = utils.String(time.Now().UTC().Format(time.RFC3339))
+}
+
+// RestoreRequest selects the archive to restore: a backup of the server, or
+// any archive key in the world bucket, optionally at a specific S3 version.
+type RestoreRequest struct {
+	BackupID  *string `json:"backupID"`
+	Archive   *string `json:"archive"`
+	VersionID *string `json:"versionID"`
+}
+
+func (rr *RestoreRequest) UnmarshallRequest(b io.ReadCloser) error {
+	return json.NewDecoder(b).Decode(rr)
+}
+
+// UpgradeRequest names the game version to move the server to.
+type UpgradeRequest struct {
+	Version *string `json:"version"`
+}
+
+func (ur *UpgradeRequest) UnmarshallRequest(b io.ReadCloser) error {
+	return json.NewDecoder(b).Decode(ur)
+}
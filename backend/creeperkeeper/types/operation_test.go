@@ -0,0 +1,35 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+func TestOperationActive(t *testing.T) {
+	at := func(d time.Duration) *string {
+		return utils.String(time.Now().Add(-d).UTC().Format(time.RFC3339))
+	}
+
+	tests := []struct {
+		name string
+		op   *Operation
+		want bool
+	}{
+		{name: "none", op: nil},
+		{name: "running", op: &Operation{Status: utils.String(OperationRunning), UpdatedAt: at(time.Minute)}, want: true},
+		{name: "pending", op: &Operation{Status: utils.String(OperationPending), UpdatedAt: at(0)}, want: true},
+		{name: "succeeded", op: &Operation{Status: utils.String(OperationSucceeded), UpdatedAt: at(0)}},
+		{name: "failed", op: &Operation{Status: utils.String(OperationFailed), UpdatedAt: at(0)}},
+		{name: "stale", op: &Operation{Status: utils.String(OperationRunning), UpdatedAt: at(OperationStaleAfter + time.Minute)}},
+		{name: "bad time", op: &Operation{Status: utils.String(OperationRunning), UpdatedAt: utils.String("soon")}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.op.Active(); got != tt.want {
+				t.Errorf("Active() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package types
+
+import (
+	"github.com/hnucamendi/creeper-keeper/minecraft/player"
+)
+
+// Player sort orders for listing players as a leaderboard.
+const (
+	PlayerSortName        string = "name"
+	PlayerSortPlayTime    string = "playTime"
+	PlayerSortDeaths      string = "deaths"
+	PlayerSortMobKills    string = "mobKills"
+	PlayerSortPlayerKills string = "playerKills"
+)
+
+// Player combines a player's saved state and statistics from a server's
+// stored world. Name is empty when the player is not in the usercache.
+type Player struct {
+	UUID *string `json:"uuid"`
+	Name *string `json:"name,omitempty"`
+	// State is nil when the player has statistics but no player data.
+	State *player.Data  `json:"state"`
+	Stats *player.Stats `json:"stats"`
+	// UpdatedAt is when the player's data was last synced from the server.
+	UpdatedAt *string `json:"updatedAt"`
+}
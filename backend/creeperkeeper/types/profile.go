@@ -0,0 +1,216 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+// ProfileSK is the sort key of a server's profile, stored under the same
+// partition key as its details.
+const ProfileSK string = "serverprofile"
+
+// Server types, as the itzg/minecraft-server image names them in TYPE.
+const (
+	ServerTypeVanilla    string = "VANILLA"
+	ServerTypePaper      string = "PAPER"
+	ServerTypePurpur     string = "PURPUR"
+	ServerTypeSpigot     string = "SPIGOT"
+	ServerTypeFabric     string = "FABRIC"
+	ServerTypeQuilt      string = "QUILT"
+	ServerTypeForge      string = "FORGE"
+	ServerTypeNeoForge   string = "NEOFORGE"
+	ServerTypeFTB        string = "FTBA"
+	ServerTypeCurseForge string = "AUTO_CURSEFORGE"
+	ServerTypeModrinth   string = "MODRINTH"
+)
+
+// VersionLatest follows the newest release of the game.
+const VersionLatest string = "LATEST"
+
+const (
+	DefaultGamePort int = 25565
+	DefaultRCONPort int = 25575
+
+	// MaxMemoryGB bounds the heap a profile can ask for.
+	MaxMemoryGB int = 64
+)
+
+var (
+	ErrProfileExists   = errors.New("server already has a profile")
+	ErrProfileNotFound = errors.New("server has no profile")
+
+	serverTypes = []string{
+		ServerTypeVanilla, ServerTypePaper, ServerTypePurpur, ServerTypeSpigot,
+		ServerTypeFabric, ServerTypeQuilt, ServerTypeForge, ServerTypeNeoForge,
+		ServerTypeFTB, ServerTypeCurseForge, ServerTypeModrinth,
+	}
+	// modpackTypes install a modpack and need its ID.
+	modpackTypes = []string{ServerTypeFTB, ServerTypeCurseForge, ServerTypeModrinth}
+
+	versionPattern   = regexp.MustCompile(`^(\d+\.\d+(\.\d+)?(-(pre|rc)\d+)?|\d{2}w\d{2}[a-z])$`)
+	modpackPattern   = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+	jvmFlagPattern   = regexp.MustCompile(`^-[A-Za-z0-9:+=._/,%-]+$`)
+	imageTagPattern  = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]{0,127}$`)
+	levelNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9 _.-]{0,63}$`)
+	difficulties     = []string{"peaceful", "easy", "normal", "hard"}
+	heapFlagPrefixes = []string{"-Xmx", "-Xms"}
+)
+
+// ServerPorts are the host ports the container publishes. They take effect
+// when the container is next recreated, and the instance's security group
+// has to allow them.
+type ServerPorts struct {
+	// Game is the port players connect to.
+	Game *int `json:"game" dynamodbav:"Game"`
+	RCON *int `json:"rcon" dynamodbav:"RCON"`
+}
+
+// ServerProfile describes what a server runs: the server software and game
+// version, the modpack if any, and how the JVM and container are set up.
+type ServerProfile struct {
+	ServerID *string `json:"serverID" dynamodbav:"PK"`
+	SK       *string `json:"-" dynamodbav:"SK"`
+	Type     *string `json:"type" dynamodbav:"Type"`
+	// Version is a game version such as 1.21.1, or LATEST.
+	Version *string `json:"version" dynamodbav:"Version"`
+	// ModpackID and ModpackVersionID pick the modpack of modpack types: FTB
+	// pack and version IDs, or CurseForge and Modrinth project slugs and
+	// version or file IDs.
+	ModpackID        *string `json:"modpackID,omitempty" dynamodbav:"ModpackID,omitempty"`
+	ModpackVersionID *string `json:"modpackVersionID,omitempty" dynamodbav:"ModpackVersionID,omitempty"`
+	MemoryGB         *int    `json:"memoryGB" dynamodbav:"MemoryGB"`
+	// JVMFlags are passed to the JVM as well as the heap size from MemoryGB.
+	JVMFlags []string `json:"jvmFlags" dynamodbav:"JVMFlags"`
+	// Difficulty is written to server.properties before every start, so it
+	// cannot be changed through the properties endpoint while it is set.
+	Difficulty *string      `json:"difficulty,omitempty" dynamodbav:"Difficulty,omitempty"`
+	Ports      *ServerPorts `json:"ports" dynamodbav:"Ports"`
+	// LevelName is the world directory in the data directory, and Seed
+	// generates the world when that directory is empty. Both default to
+	// what the server's stored server.properties has when the profile is
+	// saved, so an existing world keeps loading.
+	LevelName *string `json:"levelName" dynamodbav:"LevelName"`
+	Seed      *string `json:"seed,omitempty" dynamodbav:"Seed,omitempty"`
+	// LastDisconnectCommands are run over RCON when the last player leaves.
+	LastDisconnectCommands []string `json:"lastDisconnectCommands,omitempty" dynamodbav:"LastDisconnectCommands,omitempty"`
+	// ImageTag pins the itzg/minecraft-server tag. Without it the tag is
+	// picked from the Java release the game version needs.
+	ImageTag  *string `json:"imageTag,omitempty" dynamodbav:"ImageTag,omitempty"`
+	UpdatedAt *string `json:"updatedAt" dynamodbav:"UpdatedAt"`
+}
+
+func (p *ServerProfile) UnmarshallRequest(b io.ReadCloser) error {
+	return json.NewDecoder(b).Decode(p)
+}
+
+// Validate checks the profile and fills in defaults for the version, JVM
+// flags and ports.
+func (p *ServerProfile) Validate() error {
+	if p.Type == nil {
+		return errors.New("type must be provided")
+	}
+	*p.Type = strings.ToUpper(*p.Type)
+	if !slices.Contains(serverTypes, *p.Type) {
+		return fmt.Errorf("type must be one of %s", strings.Join(serverTypes, ", "))
+	}
+
+	if p.Version == nil || strings.EqualFold(*p.Version, VersionLatest) {
+		latest := VersionLatest
+		p.Version = &latest
+	} else if !versionPattern.MatchString(*p.Version) {
+		return fmt.Errorf("version %q is not a game version", *p.Version)
+	}
+
+	if p.IsModpack() {
+		if p.ModpackID == nil || !modpackPattern.MatchString(*p.ModpackID) {
+			return fmt.Errorf("%s servers need a modpackID", *p.Type)
+		}
+		if p.ModpackVersionID != nil && !modpackPattern.MatchString(*p.ModpackVersionID) {
+			return fmt.Errorf("modpackVersionID %q is not valid", *p.ModpackVersionID)
+		}
+	} else if p.ModpackID != nil || p.ModpackVersionID != nil {
+		return fmt.Errorf("%s servers do not use a modpack", *p.Type)
+	}
+
+	if p.MemoryGB == nil || *p.MemoryGB < 1 || *p.MemoryGB > MaxMemoryGB {
+		return fmt.Errorf("memoryGB must be from 1 to %d", MaxMemoryGB)
+	}
+
+	if p.JVMFlags == nil {
+		p.JVMFlags = []string{}
+	}
+	for _, flag := range p.JVMFlags {
+		if !jvmFlagPattern.MatchString(flag) {
+			return fmt.Errorf("jvm flag %q is not valid", flag)
+		}
+		for _, prefix := range heapFlagPrefixes {
+			if strings.HasPrefix(flag, prefix) {
+				return fmt.Errorf("jvm flag %q sets the heap, use memoryGB instead", flag)
+			}
+		}
+	}
+
+	if p.Difficulty != nil {
+		*p.Difficulty = strings.ToLower(*p.Difficulty)
+		if !slices.Contains(difficulties, *p.Difficulty) {
+			return fmt.Errorf("difficulty must be one of %s", strings.Join(difficulties, ", "))
+		}
+	}
+
+	if p.LevelName != nil && (!levelNamePattern.MatchString(*p.LevelName) || strings.Trim(*p.LevelName, ".") == "") {
+		return fmt.Errorf("level name %q is not valid", *p.LevelName)
+	}
+
+	if p.Seed != nil && (len(*p.Seed) > 64 || strings.ContainsAny(*p.Seed, "\r\n")) {
+		return errors.New("seed must be a single line of at most 64 characters")
+	}
+
+	for _, command := range p.LastDisconnectCommands {
+		if strings.TrimSpace(command) == "" || strings.ContainsAny(command, "\r\n") {
+			return fmt.Errorf("last disconnect command %q is not valid", command)
+		}
+	}
+
+	if p.ImageTag != nil && !imageTagPattern.MatchString(*p.ImageTag) {
+		return fmt.Errorf("image tag %q is not valid", *p.ImageTag)
+	}
+
+	return p.validatePorts()
+}
+
+// IsModpack reports whether the profile installs a modpack, which picks the
+// game version itself.
+func (p *ServerProfile) IsModpack() bool {
+	return slices.Contains(modpackTypes, utils.ToString(p.Type))
+}
+
+func (p *ServerProfile) validatePorts() error {
+	if p.Ports == nil {
+		p.Ports = &ServerPorts{}
+	}
+	if p.Ports.Game == nil {
+		game := DefaultGamePort
+		p.Ports.Game = &game
+	}
+	if p.Ports.RCON == nil {
+		rcon := DefaultRCONPort
+		p.Ports.RCON = &rcon
+	}
+
+	for name, port := range map[string]int{"game": *p.Ports.Game, "rcon": *p.Ports.RCON} {
+		if port < 1024 || port > 65535 {
+			return fmt.Errorf("%s port must be from 1024 to 65535", name)
+		}
+	}
+	if *p.Ports.Game == *p.Ports.RCON {
+		return errors.New("game and rcon ports must differ")
+	}
+	return nil
+}
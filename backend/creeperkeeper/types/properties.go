@@ -0,0 +1,51 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ServerProperties is a server's server.properties. Live is set when it was
+// read from the running server rather than the stored world.
+type ServerProperties struct {
+	ServerID   *string           `json:"serverID"`
+	Live       bool              `json:"live"`
+	Properties map[string]string `json:"properties"`
+	// RestartCommandID is the command restarting the server to apply
+	// changes, when one was asked for.
+	RestartCommandID *string `json:"restartCommandID,omitempty"`
+}
+
+// PropertiesUpdate sets server properties. Values may be given as JSON
+// strings, numbers or booleans. Restart restarts a running server so the
+// changes take effect.
+type PropertiesUpdate struct {
+	Properties map[string]any `json:"properties"`
+	Restart    *bool          `json:"restart"`
+}
+
+func (p *PropertiesUpdate) UnmarshallRequest(b io.ReadCloser) error {
+	d := json.NewDecoder(b)
+	d.UseNumber()
+	return d.Decode(p)
+}
+
+// Values returns the properties as the strings written to the file.
+func (p *PropertiesUpdate) Values() (map[string]string, error) {
+	values := map[string]string{}
+	for key, v := range p.Properties {
+		switch v := v.(type) {
+		case string:
+			values[key] = v
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		case json.Number:
+			values[key] = v.String()
+		default:
+			return nil, fmt.Errorf("%s must be a string, number or boolean", key)
+		}
+	}
+	return values, nil
+}
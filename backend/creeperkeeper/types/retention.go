@@ -0,0 +1,33 @@
+package types
+
+import (
+	"errors"
+	"slices"
+)
+
+// RetentionPolicy decides which backups of a server are kept. The newest
+// backup in each of the latest Hourly hours, Daily days, Weekly ISO weeks and
+// Monthly months is kept, along with every Protected backup and the newest
+// backup overall. Everything else is pruned. A server without a policy keeps
+// all of its backups.
+type RetentionPolicy struct {
+	Hourly    int      `json:"hourly" dynamodbav:"Hourly"`
+	Daily     int      `json:"daily" dynamodbav:"Daily"`
+	Weekly    int      `json:"weekly" dynamodbav:"Weekly"`
+	Monthly   int      `json:"monthly" dynamodbav:"Monthly"`
+	Protected []string `json:"protected" dynamodbav:"Protected"`
+}
+
+func (p *RetentionPolicy) Validate() error {
+	if p.Hourly < 0 || p.Daily < 0 || p.Weekly < 0 || p.Monthly < 0 {
+		return errors.New("retention counts must not be negative")
+	}
+	if p.Hourly == 0 && p.Daily == 0 && p.Weekly == 0 && p.Monthly == 0 {
+		return errors.New("retention policy must keep backups for at least one of hourly, daily, weekly or monthly")
+	}
+	return nil
+}
+
+func (p *RetentionPolicy) IsProtected(backupID string) bool {
+	return slices.Contains(p.Protected, backupID)
+}
@@ -0,0 +1,25 @@
+package types
+
+import "testing"
+
+func TestRetentionPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetentionPolicy
+		wantErr bool
+	}{
+		{name: "daily", policy: RetentionPolicy{Daily: 7}},
+		{name: "all tiers", policy: RetentionPolicy{Hourly: 24, Daily: 7, Weekly: 4, Monthly: 12}},
+		{name: "empty", policy: RetentionPolicy{}, wantErr: true},
+		{name: "only protected", policy: RetentionPolicy{Protected: []string{"20250101T000000Z"}}, wantErr: true},
+		{name: "negative", policy: RetentionPolicy{Daily: 7, Weekly: -1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// maxRollbackBlocks bounds each side of a rollback box, 16 regions.
+const maxRollbackBlocks int = 16 * 512
+
+// RollbackWindow is how far back a region can be rolled back. The world
+// bucket expires older object versions after the same number of days.
+const RollbackWindow time.Duration = 30 * 24 * time.Hour
+
+// RollbackRequest selects part of a dimension to roll back, by block
+// coordinates inclusive, and the point in time to take it from: either a
+// time, or the S3 version of one of the box's region files.
+type RollbackRequest struct {
+	Dimension *string `json:"dimension"`
+	MinX      *int    `json:"minX"`
+	MinZ      *int    `json:"minZ"`
+	MaxX      *int    `json:"maxX"`
+	MaxZ      *int    `json:"maxZ"`
+	AsOf      *string `json:"asOf"`
+	VersionID *string `json:"versionID"`
+}
+
+func (rr *RollbackRequest) UnmarshallRequest(b io.ReadCloser) error {
+	return json.NewDecoder(b).Decode(rr)
+}
+
+func (rr *RollbackRequest) Validate() error {
+	if rr.Dimension == nil || rr.MinX == nil || rr.MinZ == nil || rr.MaxX == nil || rr.MaxZ == nil {
+		return errors.New("dimension, minX, minZ, maxX and maxZ must be provided")
+	}
+	if *rr.MinX > *rr.MaxX || *rr.MinZ > *rr.MaxZ {
+		return errors.New("min coordinates must not be greater than max coordinates")
+	}
+	if *rr.MaxX-*rr.MinX >= maxRollbackBlocks || *rr.MaxZ-*rr.MinZ >= maxRollbackBlocks {
+		return errors.New("rollback box is too large")
+	}
+	if (rr.AsOf == nil) == (rr.VersionID == nil) {
+		return errors.New("exactly one of asOf or versionID must be provided")
+	}
+	if rr.AsOf != nil {
+		asOf, err := time.Parse(time.RFC3339, *rr.AsOf)
+		if err != nil {
+			return errors.New("asOf must be an RFC3339 time")
+		}
+		if time.Since(asOf) > RollbackWindow {
+			return errors.New("asOf must be within the last 30 days")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+func TestRollbackRequestValidate(t *testing.T) {
+	zero, ten, tooFar := 0, 10, maxRollbackBlocks
+	recent := utils.String(time.Now().Add(-time.Hour).UTC().Format(time.RFC3339))
+	expired := utils.String(time.Now().Add(-RollbackWindow - time.Hour).UTC().Format(time.RFC3339))
+
+	box := func(asOf *string, versionID *string) RollbackRequest {
+		return RollbackRequest{
+			Dimension: utils.String("overworld"),
+			MinX:      &zero,
+			MinZ:      &zero,
+			MaxX:      &ten,
+			MaxZ:      &ten,
+			AsOf:      asOf,
+			VersionID: versionID,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		req     RollbackRequest
+		wantErr bool
+	}{
+		{name: "as of", req: box(recent, nil)},
+		{name: "version", req: box(nil, utils.String("3HL4kqtJlcpXroDTDmJ"))},
+		{name: "both", req: box(recent, utils.String("3HL4kqtJlcpXroDTDmJ")), wantErr: true},
+		{name: "neither", req: box(nil, nil), wantErr: true},
+		{name: "bad time", req: box(utils.String("yesterday"), nil), wantErr: true},
+		{name: "outside window", req: box(expired, nil), wantErr: true},
+		{name: "missing dimension", req: func() RollbackRequest { r := box(recent, nil); r.Dimension = nil; return r }(), wantErr: true},
+		{name: "min past max", req: func() RollbackRequest { r := box(recent, nil); r.MinX = &ten; r.MaxX = &zero; return r }(), wantErr: true},
+		{name: "too large", req: func() RollbackRequest { r := box(recent, nil); r.MaxZ = &tooFar; return r }(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,16 +2,101 @@ package types
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/level"
+	"github.com/hnucamendi/creeper-keeper/minecraft/slp"
+)
+
+// Server states track the Minecraft server itself, which becomes ready some
+// time after the instance is running.
+const (
+	StateStarting   string = "STARTING"
+	StateReady      string = "READY"
+	StateBootFailed string = "BOOT_FAILED"
+	StateStopped    string = "STOPPED"
 )
 
+var ErrServerNotFound = errors.New("server not found")
+
 type Server struct {
-	ID          *string `json:"serverID" dynamodbav:"PK"`
-	SK          *string `json:"row" dynamodbav:"SK"`
-	IP          *string `json:"serverIP" dynamodbav:"ServerIP"`
-	Name        *string `json:"serverName" dynamodbav:"ServerName"`
-	LastUpdated *string `json:"lastUpdated" dynamodbav:"LastUpdated"`
-	IsRunning   *bool   `json:"isRunning" dynamodbav:"IsRunning"`
+	ID          *string    `json:"serverID" dynamodbav:"PK"`
+	SK          *string    `json:"row" dynamodbav:"SK"`
+	IP          *string    `json:"serverIP" dynamodbav:"ServerIP"`
+	Name        *string    `json:"serverName" dynamodbav:"ServerName"`
+	LastUpdated *string    `json:"lastUpdated" dynamodbav:"LastUpdated"`
+	IsRunning   *bool      `json:"isRunning" dynamodbav:"IsRunning"`
+	State       *string    `json:"state" dynamodbav:"State"`
+	ReadyAt     *string    `json:"readyAt" dynamodbav:"ReadyAt"`
+	EmptySince  *string    `json:"emptySince" dynamodbav:"EmptySince"`
+	Operation   *Operation `json:"operation,omitempty" dynamodbav:"Operation,omitempty"`
+	// LastBackupAt is when the last scheduled backup was taken, RFC3339 UTC.
+	LastBackupAt *string `json:"lastBackupAt,omitempty" dynamodbav:"LastBackupAt,omitempty"`
+	// Ports are copied from the server's profile. Servers without one run
+	// the container terraform created, on the default game port.
+	Ports *ServerPorts `json:"ports,omitempty" dynamodbav:"Ports,omitempty"`
+	// World is read from level.dat when the server is listed, not stored.
+	World *level.Info `json:"world,omitempty" dynamodbav:"-"`
+	ServerSettings
+}
+
+// ServerSettings are the user editable options stored on a server record.
+type ServerSettings struct {
+	// IdleTimeoutMinutes is how long a server may sit empty before it is
+	// stopped. Unset or zero disables idle shutdown.
+	IdleTimeoutMinutes *int `json:"idleTimeoutMinutes,omitempty" dynamodbav:"IdleTimeoutMinutes,omitempty"`
+	// Retention is the backup retention policy, replaced as a whole.
+	Retention *RetentionPolicy `json:"retention,omitempty" dynamodbav:"Retention,omitempty"`
+	// BackupSchedule is a standard five field cron expression, in UTC, for
+	// backups taken while the server is running.
+	BackupSchedule *string `json:"backupSchedule,omitempty" dynamodbav:"BackupSchedule,omitempty"`
+}
+
+func (s *ServerSettings) UnmarshallRequest(b io.ReadCloser) error {
+	return json.NewDecoder(b).Decode(s)
+}
+
+// IdleTimeout returns the configured idle timeout. A zero duration, which
+// servers without one get, means idle shutdown is disabled.
+func (ck *Server) IdleTimeout() time.Duration {
+	if ck.IdleTimeoutMinutes == nil {
+		return 0
+	}
+	return time.Duration(*ck.IdleTimeoutMinutes) * time.Minute
+}
+
+// GamePort is the port players, and status pings, connect to.
+func (ck *Server) GamePort() int {
+	if ck.Ports == nil || ck.Ports.Game == nil {
+		return DefaultGamePort
+	}
+	return *ck.Ports.Game
+}
+
+// HasRCON reports whether the server's RCON can be used. Only containers
+// rendered from a profile are given the RCON password.
+func (ck *Server) HasRCON() bool {
+	return ck.Ports != nil
+}
+
+// RCONPort is the port the server's RCON listens on.
+func (ck *Server) RCONPort() int {
+	if ck.Ports == nil || ck.Ports.RCON == nil {
+		return DefaultRCONPort
+	}
+	return *ck.Ports.RCON
+}
+
+// ValidState reports whether state is one of the known server states.
+func ValidState(state string) bool {
+	switch state {
+	case StateStarting, StateReady, StateBootFailed, StateStopped:
+		return true
+	default:
+		return false
+	}
 }
 
 func (ck *Server) UnmarshallRequest(b io.ReadCloser) error {
@@ -22,3 +107,12 @@ func (ck *Server) UnmarshallRequest(b io.ReadCloser) error {
 
 	return nil
 }
+
+// ServerPing combines the instance state with what the Minecraft server
+// reports in the server list. Status is nil when the server did not answer.
+type ServerPing struct {
+	State       *string     `json:"state"`
+	Status      *slp.Status `json:"status,omitempty"`
+	StatusError *string     `json:"statusError,omitempty"`
+	World       *level.Info `json:"world,omitempty"`
+}
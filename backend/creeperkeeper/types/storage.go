@@ -0,0 +1,19 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// Object describes a stored object. VersionID is only set by storage that
+// keeps object versions.
+type Object struct {
+	Key          *string   `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	VersionID    *string   `json:"versionID,omitempty"`
+	// DeleteMarker is set on versions recording that the object was deleted.
+	DeleteMarker bool `json:"deleteMarker,omitempty"`
+}
@@ -0,0 +1,26 @@
+package types
+
+// TaskSKPrefix is the sort key prefix of task rows, which are stored under
+// the server's partition key next to its details.
+const TaskSKPrefix string = "task#"
+
+// Task is an operation that only reads the server's stored world or
+// backups, such as rendering a map. Tasks are kept in their own rows, keyed
+// by what they work on, so they neither wait for nor hold up the operation
+// on the server record. Only one task runs per key at a time.
+type Task struct {
+	ServerID  *string    `json:"serverID" dynamodbav:"PK"`
+	SK        *string    `json:"-" dynamodbav:"SK"`
+	Key       *string    `json:"task" dynamodbav:"Task"`
+	Operation *Operation `json:"operation" dynamodbav:"Operation"`
+}
+
+// WorldTask is the key of a task working on the server's stored world.
+func WorldTask(opType string) string {
+	return opType + "#world"
+}
+
+// BackupTask is the key of a task working on one of the server's backups.
+func BackupTask(opType string, backupID string) string {
+	return opType + "#" + backupID
+}
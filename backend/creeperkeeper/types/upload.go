@@ -0,0 +1,9 @@
+package types
+
+// Upload is a presigned link for uploading a world zip to import.
+type Upload struct {
+	ID        *string `json:"uploadID"`
+	Key       *string `json:"key"`
+	URL       *string `json:"url"`
+	ExpiresAt *string `json:"expiresAt"`
+}
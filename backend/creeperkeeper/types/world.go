@@ -0,0 +1,16 @@
+package types
+
+import (
+	"github.com/hnucamendi/creeper-keeper/minecraft/anvil"
+)
+
+// WorldStats describes the region files of a server's stored world.
+type WorldStats struct {
+	ServerID             *string                `json:"serverID"`
+	GeneratedAt          *string                `json:"generatedAt"`
+	Dimensions           []anvil.DimensionStats `json:"dimensions"`
+	LeastRecentlyTouched []anvil.Area           `json:"leastRecentlyTouched"`
+	// Errors lists region files that could not be read. They are counted in
+	// disk usage but not in chunk counts.
+	Errors []string `json:"errors,omitempty"`
+}
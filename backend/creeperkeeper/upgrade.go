@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/minecraft/level"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const upgradeJobRoute string = "/creeperkeeper/jobs/upgrade"
+
+// upgradeWaitStep is left running while the server boots on the new version,
+// and finished when the boot reports whether the server became ready.
+const upgradeWaitStep string = "wait for ready"
+
+// UpgradeServer starts moving the server to another game version. The world
+// is backed up first, and the upgrade is rolled back to that backup and the
+// previous version unless the server comes up ready with its world upgraded.
+func (h *Handler) UpgradeServer(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	req := &types.UpgradeRequest{}
+	err := req.UnmarshallRequest(r.Body)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if req.Version == nil {
+		writeResponse(w, r, http.StatusBadRequest, "version must be provided")
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	profile, err := h.Client.db.Client.GetServerProfile(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if errors.Is(err, types.ErrProfileNotFound) {
+		writeResponse(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The container only sets VERSION for servers without a modpack.
+	if profile.IsModpack() {
+		writeResponse(w, r, http.StatusBadRequest, *profile.Type+" servers run the version their modpack picks, change modpackVersionID in the profile instead")
+		return
+	}
+
+	previous := utils.ToString(profile.Version)
+	profile.Version = req.Version
+	err = profile.Validate()
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if *profile.Version == previous {
+		writeResponse(w, r, http.StatusBadRequest, "server is already on version "+previous)
+		return
+	}
+
+	params := map[string]string{"version": *profile.Version}
+	h.startOperation(w, r, serverID, upgradeJobRoute, types.NewOperation(types.OperationUpgrade, params))
+}
+
+// UpgradeJob runs an upgrade started by UpgradeServer in two parts, since the
+// boot can take longer than a job may run. The first backs the world up,
+// changes the version and starts the server, leaving the operation waiting.
+// The boot reporting its state runs the job again to check the upgraded world
+// or roll the upgrade back.
+func (h *Handler) UpgradeJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.loadOperation(r)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch {
+	case len(run.op.Steps) == 0:
+		err = h.upgrade(r.Context(), run)
+	case upgradeWaiting(run.op):
+		err = h.verifyUpgrade(r.Context(), run)
+		run.finish(r.Context(), err)
+	default:
+		writeResponse(w, r, http.StatusBadRequest, "upgrade is not waiting on the server: "+utils.ToString(run.op.ID))
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, run.op)
+}
+
+func (h *Handler) upgrade(ctx context.Context, run *operationRun) error {
+	server := run.server
+	serverID := run.serverID
+	serverName := utils.ToString(server.Name)
+	run.op.Result = map[string]string{}
+
+	if utils.ToBool(server.IsRunning) {
+		err := run.step(ctx, "stop server", func() error {
+			return h.gracefulStop(ctx, server)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var info *level.Info
+	err := run.step(ctx, "read world version", func() error {
+		var err error
+		info, err = h.storedWorldInfo(ctx, server)
+		if err != nil {
+			return err
+		}
+		if info == nil {
+			return errors.New("server has no stored world to upgrade")
+		}
+		run.op.Result["previousDataVersion"] = strconv.FormatInt(info.DataVersion, 10)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = run.step(ctx, "snapshot world", func() error {
+		m, err := h.Client.backup.Create(ctx, &backup.Manifest{
+			ServerID:   serverID,
+			ServerName: serverName,
+			MCVersion:  info.Version,
+			Trigger:    backup.TriggerPreUpgrade,
+		})
+		if err != nil {
+			return err
+		}
+		run.op.Result["safetyBackupID"] = m.ID
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = run.step(ctx, "change version", func() error {
+		profile, err := h.Client.db.Client.GetServerProfile(ctx, utils.ToString(h.Client.db.Table), serverID)
+		if err != nil {
+			return err
+		}
+		run.op.Result["previousVersion"] = utils.ToString(profile.Version)
+		return h.setProfileVersion(ctx, profile, run.op.Params["version"])
+	})
+	if err != nil {
+		return err
+	}
+
+	// The boot renders the container from the changed profile, which
+	// recreates it on the new version.
+	err = run.step(ctx, "start server", func() error {
+		err := h.Client.compute.Client.StartServer(ctx, serverID)
+		if err != nil {
+			return fmt.Errorf("failed to start instance: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return h.rollbackUpgrade(ctx, run, err)
+	}
+
+	run.op.StartStep(upgradeWaitStep)
+	run.save(ctx)
+	return nil
+}
+
+// verifyUpgrade finishes the wait on the boot and checks that the server
+// upgraded the world, rolling the upgrade back if either failed.
+func (h *Handler) verifyUpgrade(ctx context.Context, run *operationRun) error {
+	server := run.server
+
+	var err error
+	if state := utils.ToString(server.State); state != types.StateReady {
+		err = fmt.Errorf("server did not become ready on version %s, its boot ended %s", run.op.Params["version"], state)
+	}
+	run.op.FinishStep(err)
+	run.save(ctx)
+	if err != nil {
+		return h.rollbackUpgrade(ctx, run, err)
+	}
+
+	err = run.step(ctx, "check world version", func() error {
+		// level.dat only carries the new DataVersion once the world is saved.
+		_, err := h.Client.console.Client.Exec(ctx, server, "save-all flush")
+		if err != nil {
+			return fmt.Errorf("failed to save world: %w", err)
+		}
+
+		info, err := h.liveWorldInfo(ctx, server)
+		if err != nil {
+			return err
+		}
+
+		previous, err := strconv.ParseInt(run.op.Result["previousDataVersion"], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid previous DataVersion: %w", err)
+		}
+
+		run.op.Result["dataVersion"] = strconv.FormatInt(info.DataVersion, 10)
+		if info.DataVersion <= previous {
+			return fmt.Errorf("world DataVersion %d did not advance past %d", info.DataVersion, previous)
+		}
+		return nil
+	})
+	if err != nil {
+		return h.rollbackUpgrade(ctx, run, err)
+	}
+
+	return nil
+}
+
+// rollbackUpgrade puts back the world backed up before the upgrade and the
+// previous version, then starts the server again. The operation fails with
+// cause either way, along with any failure to roll back.
+func (h *Handler) rollbackUpgrade(ctx context.Context, run *operationRun, cause error) error {
+	err := h.restoreUpgrade(ctx, run)
+	if err != nil {
+		cause = fmt.Errorf("%w, and failed to roll back: %v", cause, err)
+	} else {
+		run.op.Result["rolledBack"] = "true"
+	}
+
+	// The rollback steps mark the operation running again.
+	run.op.Fail(cause)
+	run.save(ctx)
+	return cause
+}
+
+func (h *Handler) restoreUpgrade(ctx context.Context, run *operationRun) error {
+	serverID := run.serverID
+	serverName := utils.ToString(run.server.Name)
+
+	// The world on disk is replaced by the snapshot on the next boot, so the
+	// instance is stopped without syncing it.
+	err := run.step(ctx, "stop instance", func() error {
+		return h.stopInstance(ctx, run.server)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = run.step(ctx, "restore snapshot", func() error {
+		m, err := h.Client.backup.Get(ctx, serverID, run.op.Result["safetyBackupID"])
+		if err != nil {
+			return err
+		}
+		return h.Client.backup.Restore(ctx, serverName, m.Archive, "", m)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = run.step(ctx, "restore version", func() error {
+		profile, err := h.Client.db.Client.GetServerProfile(ctx, utils.ToString(h.Client.db.Table), serverID)
+		if err != nil {
+			return err
+		}
+		return h.setProfileVersion(ctx, profile, run.op.Result["previousVersion"])
+	})
+	if err != nil {
+		return err
+	}
+
+	return run.step(ctx, "start server", func() error {
+		err := h.Client.compute.Client.StartServer(ctx, serverID)
+		if err != nil {
+			return fmt.Errorf("failed to start instance: %w", err)
+		}
+		return nil
+	})
+}
+
+func (h *Handler) setProfileVersion(ctx context.Context, profile *types.ServerProfile, version string) error {
+	profile.Version = utils.String(version)
+	profile.UpdatedAt = utils.String(time.Now().UTC().Format(time.RFC3339))
+	return h.Client.db.Client.PutServerProfile(ctx, utils.ToString(h.Client.db.Table), profile, false)
+}
+
+// upgradeWaiting reports whether op is an upgrade waiting on the boot.
+func upgradeWaiting(op *types.Operation) bool {
+	if op == nil || utils.ToString(op.Type) != types.OperationUpgrade || op.Done() || len(op.Steps) == 0 {
+		return false
+	}
+	step := op.Steps[len(op.Steps)-1]
+	return utils.ToString(step.Name) == upgradeWaitStep && utils.ToString(step.Status) == types.OperationRunning
+}
+
+// resumeUpgrade queues the rest of an upgrade waiting on the server once its
+// boot has ended.
+func (h *Handler) resumeUpgrade(ctx context.Context, serverID string) {
+	server, err := h.Client.db.Client.ListServer(ctx, utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		log.Println("failed to check for an upgrade on", serverID, err)
+		return
+	}
+
+	if !upgradeWaiting(server.Operation) {
+		return
+	}
+
+	err = h.Client.jobs.Client.Enqueue(ctx, upgradeJobRoute, &types.OperationJob{
+		ServerID:    utils.String(serverID),
+		OperationID: server.Operation.ID,
+	})
+	if err != nil {
+		log.Println("failed to enqueue upgrade check for", serverID, err)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const (
+	uploadExpiry   time.Duration = time.Hour
+	importJobRoute string        = "/creeperkeeper/jobs/import"
+)
+
+// CreateUpload returns a short lived link for uploading a world zip. Once the
+// upload is done it is imported with ImportUpload.
+func (h *Handler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	uploadID := backup.NewUploadID()
+	key := backup.UploadKey(serverID, uploadID)
+	url, err := h.Client.storage.Client.PresignPut(r.Context(), utils.ToString(h.Client.storage.Bucket), key, uploadExpiry)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusCreated, &types.Upload{
+		ID:        utils.String(uploadID),
+		Key:       utils.String(key),
+		URL:       utils.String(url),
+		ExpiresAt: utils.String(time.Now().UTC().Add(uploadExpiry).Format(time.RFC3339)),
+	})
+}
+
+// ImportUpload starts importing an uploaded world zip as the server's world.
+// The server must be stopped, or stopping it would sync its old world back
+// over the import.
+func (h *Handler) ImportUpload(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	uploadID := r.PathValue("uploadID")
+	if serverID == "" || uploadID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID and uploadID must be provided")
+		return
+	}
+
+	if !fs.ValidPath(uploadID) || strings.Contains(uploadID, "/") {
+		writeResponse(w, r, http.StatusBadRequest, "invalid uploadID: "+uploadID)
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	if utils.ToBool(server.IsRunning) {
+		writeResponse(w, r, http.StatusConflict, "server must be stopped to import a world")
+		return
+	}
+
+	params := map[string]string{
+		"uploadID": uploadID,
+	}
+	h.startOperation(w, r, serverID, importJobRoute, types.NewOperation(types.OperationImport, params))
+}
+
+// ImportJob runs an import started by ImportUpload: validate the upload, take
+// a safety backup of the current world and stage the new one in its place.
+func (h *Handler) ImportJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.loadOperation(r)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = h.importWorld(r.Context(), run)
+	run.finish(r.Context(), err)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, run.op)
+}
+
+func (h *Handler) importWorld(ctx context.Context, run *operationRun) error {
+	serverID := run.serverID
+	serverName := utils.ToString(run.server.Name)
+	key := backup.UploadKey(serverID, run.op.Params["uploadID"])
+
+	var imp *backup.Import
+	err := run.step(ctx, "validate upload", func() error {
+		var err error
+		imp, err = h.Client.backup.OpenImport(ctx, key)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer imp.Close()
+
+	err = run.step(ctx, "safety backup", func() error {
+		safety, err := h.Client.backup.Create(ctx, &backup.Manifest{
+			ServerID:   serverID,
+			ServerName: serverName,
+			Trigger:    backup.TriggerPreImport,
+		})
+		if errors.Is(err, backup.ErrNoWorld) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		run.op.Result = map[string]string{"safetyBackupID": safety.ID}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = run.step(ctx, "stage world", func() error {
+		return h.Client.backup.Stage(ctx, serverName, imp)
+	})
+	if err != nil {
+		return err
+	}
+
+	return run.step(ctx, "remove upload", func() error {
+		return h.Client.storage.Client.Delete(ctx, utils.ToString(h.Client.storage.Bucket), key)
+	})
+}
@@ -0,0 +1,9 @@
+package utils
+
+import "strings"
+
+// ShellQuote wraps str in single quotes so a shell passes it through as one
+// literal argument.
+func ShellQuote(str string) string {
+	return "'" + strings.ReplaceAll(str, "'", `'\''`) + "'"
+}
@@ -21,3 +21,14 @@ func ToBool(b *bool) bool {
 func Bool(b bool) *bool {
 	return &b
 }
+
+func ToInt(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+func Int(i int) *int {
+	return &i
+}
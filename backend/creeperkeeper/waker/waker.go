@@ -0,0 +1,344 @@
+// Package waker answers Minecraft clients on behalf of stopped servers. Status
+// pings get a "sleeping" MOTD and login attempts start the server and kick the
+// player with a message to retry once it is up.
+package waker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/protocol"
+	"github.com/hnucamendi/creeper-keeper/service/compute"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const (
+	stateStatus int32 = 1
+	stateLogin  int32 = 2
+
+	packetHandshake   int32 = 0x00
+	packetStatus      int32 = 0x00
+	packetPing        int32 = 0x01
+	packetLoginStart  int32 = 0x00
+	packetDisconnect  int32 = 0x00
+	legacyPingPrefix  byte  = 0xfe
+	connectionTimeout       = 10 * time.Second
+
+	// StartCooldown keeps repeated login attempts from hammering the compute
+	// API while a server boots.
+	StartCooldown time.Duration = 30 * time.Second
+)
+
+const (
+	SleepingMOTD   string = "Sleeping, join to wake"
+	StartingMOTD   string = "Starting, please wait"
+	StoppingMOTD   string = "Shutting down, join again shortly to wake"
+	StartingKick   string = "Server is starting, retry in 60s"
+	StoppingKick   string = "Server is still shutting down, rejoin in a minute to wake it"
+	UnknownKick    string = "No server is configured for this address"
+	StartErrorKick string = "Server failed to start, try again later"
+	OperationKick  string = "Server is busy with an operation, try again once it finishes"
+)
+
+// ErrStopping is returned when a player tries to wake a server whose
+// instance is still stopping, which cannot be started until it has stopped.
+var ErrStopping = errors.New("server is still stopping")
+
+// ServerReader reads the server records the API keeps.
+type ServerReader interface {
+	ListServer(ctx context.Context, tableName string, serverID string) (*types.Server, error)
+}
+
+type Server struct {
+	Compute compute.Compute
+	// DB and Table are where the server records are read from, so servers
+	// busy with an operation are not started underneath it.
+	DB    ServerReader
+	Table string
+	// Servers maps the hostname players connect with to a server ID.
+	Servers map[string]string
+	// DefaultServerID is used for hostnames that are not mapped.
+	DefaultServerID string
+
+	mu          sync.Mutex
+	lastStarted map[string]time.Time
+	starting    map[string]bool
+}
+
+// ListenAndServe accepts connections on addr until ctx is done.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+			err := s.handle(ctx, conn)
+			if err != nil {
+				log.Println("waker:", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+func (s *Server) handle(ctx context.Context, conn net.Conn) error {
+	conn.SetDeadline(time.Now().Add(connectionTimeout))
+	r := bufio.NewReader(conn)
+
+	first, err := r.Peek(1)
+	if err != nil {
+		return err
+	}
+	if first[0] == legacyPingPrefix {
+		return errors.New("legacy ping is not supported")
+	}
+
+	p, err := protocol.ReadPacket(r)
+	if err != nil {
+		return err
+	}
+	if p.ID != packetHandshake {
+		return fmt.Errorf("expected handshake, got packet %d", p.ID)
+	}
+
+	h, err := readHandshake(p)
+	if err != nil {
+		return err
+	}
+
+	serverID, ok := s.Servers[h.host]
+	if !ok {
+		serverID = s.DefaultServerID
+	}
+
+	switch h.nextState {
+	case stateStatus:
+		return s.status(ctx, conn, r, h, serverID)
+	case stateLogin:
+		return s.login(ctx, conn, r, serverID)
+	default:
+		return fmt.Errorf("unknown next state %d", h.nextState)
+	}
+}
+
+type handshake struct {
+	protocolVersion int32
+	host            string
+	nextState       int32
+}
+
+func readHandshake(p *protocol.Packet) (*handshake, error) {
+	br := p.Reader()
+	version, err := protocol.ReadVarInt(br)
+	if err != nil {
+		return nil, err
+	}
+	host, err := protocol.ReadString(br)
+	if err != nil {
+		return nil, err
+	}
+	_, err = protocol.ReadUint16(br)
+	if err != nil {
+		return nil, err
+	}
+	next, err := protocol.ReadVarInt(br)
+	if err != nil {
+		return nil, err
+	}
+
+	// Forge appends markers after a NUL and some clients keep the trailing
+	// dot of a fully qualified name.
+	host, _, _ = strings.Cut(host, "\x00")
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	return &handshake{
+		protocolVersion: version,
+		host:            host,
+		nextState:       next,
+	}, nil
+}
+
+func (s *Server) status(ctx context.Context, conn net.Conn, r *bufio.Reader, h *handshake, serverID string) error {
+	p, err := protocol.ReadPacket(r)
+	if err != nil {
+		return err
+	}
+	if p.ID != packetStatus {
+		return fmt.Errorf("expected status request, got packet %d", p.ID)
+	}
+
+	motd := UnknownKick
+	if serverID != "" {
+		motd = SleepingMOTD
+		state, err := s.Compute.GetServerStatus(ctx, serverID)
+		if err == nil {
+			switch utils.ToString(state) {
+			case "STOPPED":
+			case "STOPPING":
+				motd = StoppingMOTD
+			default:
+				motd = StartingMOTD
+			}
+		}
+	}
+
+	// Echoing the client's protocol keeps it from flagging the server as
+	// outdated, so players see the MOTD rather than a version error.
+	res, err := json.Marshal(map[string]any{
+		"version": map[string]any{
+			"name":     "CreeperKeeper",
+			"protocol": h.protocolVersion,
+		},
+		"players": map[string]any{
+			"max":    0,
+			"online": 0,
+		},
+		"description": map[string]any{
+			"text": motd,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	body := &bytes.Buffer{}
+	protocol.WriteString(body, string(res))
+	err = protocol.WritePacket(conn, &protocol.Packet{ID: packetStatus, Data: body.Bytes()})
+	if err != nil {
+		return err
+	}
+
+	ping, err := protocol.ReadPacket(r)
+	if err != nil {
+		// Clients may close after reading the status
+		return nil
+	}
+	if ping.ID != packetPing {
+		return fmt.Errorf("expected ping, got packet %d", ping.ID)
+	}
+	return protocol.WritePacket(conn, ping)
+}
+
+func (s *Server) login(ctx context.Context, conn net.Conn, r *bufio.Reader, serverID string) error {
+	p, err := protocol.ReadPacket(r)
+	if err != nil {
+		return err
+	}
+	if p.ID != packetLoginStart {
+		return fmt.Errorf("expected login start, got packet %d", p.ID)
+	}
+
+	player, _ := protocol.ReadString(p.Reader())
+
+	if serverID == "" {
+		return disconnect(conn, UnknownKick)
+	}
+
+	err = s.start(ctx, serverID)
+	if errors.Is(err, ErrStopping) {
+		return disconnect(conn, StoppingKick)
+	}
+	if errors.Is(err, types.ErrOperationInProgress) {
+		return disconnect(conn, OperationKick)
+	}
+	if err != nil {
+		log.Printf("waker: %s failed to start %s: %v", player, serverID, err)
+		return disconnect(conn, StartErrorKick)
+	}
+
+	log.Printf("waker: %s woke %s", player, serverID)
+	return disconnect(conn, StartingKick)
+}
+
+// start boots the server unless it is already on its way up, another login
+// is starting it, or it was started within the cooldown. The cooldown only
+// begins once a start succeeds, so a failed or refused start can be retried
+// by joining again. A server still stopping fails with ErrStopping, and one
+// busy with an operation with types.ErrOperationInProgress.
+func (s *Server) start(ctx context.Context, serverID string) error {
+	s.mu.Lock()
+	if s.lastStarted == nil {
+		s.lastStarted = map[string]time.Time{}
+		s.starting = map[string]bool{}
+	}
+	if s.starting[serverID] || time.Since(s.lastStarted[serverID]) < StartCooldown {
+		s.mu.Unlock()
+		return nil
+	}
+	s.starting[serverID] = true
+	s.mu.Unlock()
+
+	err := s.startInstance(ctx, serverID)
+
+	s.mu.Lock()
+	delete(s.starting, serverID)
+	if err == nil {
+		s.lastStarted[serverID] = time.Now()
+	}
+	s.mu.Unlock()
+
+	return err
+}
+
+func (s *Server) startInstance(ctx context.Context, serverID string) error {
+	server, err := s.DB.ListServer(ctx, s.Table, serverID)
+	if err != nil {
+		return err
+	}
+	if server.Operation.Active() {
+		return types.ErrOperationInProgress
+	}
+
+	state, err := s.Compute.GetServerStatus(ctx, serverID)
+	if err != nil {
+		return err
+	}
+
+	switch utils.ToString(state) {
+	case "STOPPED":
+		return s.Compute.StartServer(ctx, serverID)
+	case "STOPPING":
+		return ErrStopping
+	default:
+		return nil
+	}
+}
+
+func disconnect(conn net.Conn, reason string) error {
+	msg, err := json.Marshal(map[string]string{"text": reason})
+	if err != nil {
+		return err
+	}
+
+	body := &bytes.Buffer{}
+	protocol.WriteString(body, string(msg))
+	return protocol.WritePacket(conn, &protocol.Packet{ID: packetDisconnect, Data: body.Bytes()})
+}
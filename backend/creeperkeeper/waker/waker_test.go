@@ -0,0 +1,272 @@
+package waker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/hnucamendi/creeper-keeper/minecraft/protocol"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+// fakeCompute reports a fixed state for every instance and records the
+// starts it is asked for.
+type fakeCompute struct {
+	mu        sync.Mutex
+	state     string
+	statusErr error
+	startErr  error
+	starts    int
+}
+
+func (c *fakeCompute) GetServerStatus(ctx context.Context, serverID string) (*string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.statusErr != nil {
+		return nil, c.statusErr
+	}
+	return utils.String(c.state), nil
+}
+
+func (c *fakeCompute) StartServer(ctx context.Context, serverID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.starts++
+	if c.startErr != nil {
+		return c.startErr
+	}
+	c.state = "PENDING"
+	return nil
+}
+
+func (c *fakeCompute) StopServer(ctx context.Context, serverID string) error {
+	return errors.New("not implemented")
+}
+
+// fakeDB returns the same server record for every server ID.
+type fakeDB struct {
+	operation *types.Operation
+	err       error
+}
+
+func (d *fakeDB) ListServer(ctx context.Context, tableName string, serverID string) (*types.Server, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &types.Server{ID: utils.String(serverID), Operation: d.operation}, nil
+}
+
+// finishedOperation is a stop that has already succeeded.
+func finishedOperation() *types.Operation {
+	op := types.NewOperation(types.OperationStop, nil)
+	op.Status = utils.String(types.OperationSucceeded)
+	return op
+}
+
+func TestStart(t *testing.T) {
+	errAPI := errors.New("api unavailable")
+
+	tests := []struct {
+		name      string
+		compute   *fakeCompute
+		db        *fakeDB
+		wantErr   error
+		wantStart int
+		// wantCooldown is whether a second join within the cooldown skips
+		// the compute API.
+		wantCooldown bool
+	}{
+		{name: "stopped", compute: &fakeCompute{state: "STOPPED"}, wantStart: 1, wantCooldown: true},
+		{name: "running", compute: &fakeCompute{state: "RUNNING"}, wantCooldown: true},
+		{name: "pending", compute: &fakeCompute{state: "PENDING"}, wantCooldown: true},
+		{name: "stopping", compute: &fakeCompute{state: "STOPPING"}, wantErr: ErrStopping},
+		{name: "status fails", compute: &fakeCompute{statusErr: errAPI}, wantErr: errAPI},
+		{name: "start fails", compute: &fakeCompute{state: "STOPPED", startErr: errAPI}, wantErr: errAPI, wantStart: 1},
+		{name: "finished operation", compute: &fakeCompute{state: "STOPPED"}, db: &fakeDB{operation: finishedOperation()}, wantStart: 1, wantCooldown: true},
+		{name: "operation in progress", compute: &fakeCompute{state: "STOPPED"}, db: &fakeDB{operation: types.NewOperation(types.OperationRestore, nil)}, wantErr: types.ErrOperationInProgress},
+		{name: "record fails", compute: &fakeCompute{state: "STOPPED"}, db: &fakeDB{err: errAPI}, wantErr: errAPI},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := tt.db
+			if db == nil {
+				db = &fakeDB{}
+			}
+			s := &Server{Compute: tt.compute, DB: db}
+
+			err := s.start(context.Background(), "i-123")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("start = %v, want %v", err, tt.wantErr)
+			}
+			if tt.compute.starts != tt.wantStart {
+				t.Errorf("StartServer called %d times, want %d", tt.compute.starts, tt.wantStart)
+			}
+
+			err = s.start(context.Background(), "i-123")
+			if tt.wantCooldown {
+				if err != nil || tt.compute.starts != tt.wantStart {
+					t.Errorf("second start = %v with %d starts, want the cooldown to skip it", err, tt.compute.starts)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("second start = %v, want it retried and failing with %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStartRetriesAfterStopping(t *testing.T) {
+	c := &fakeCompute{state: "STOPPING"}
+	s := &Server{Compute: c, DB: &fakeDB{}}
+
+	err := s.start(context.Background(), "i-123")
+	if !errors.Is(err, ErrStopping) {
+		t.Fatalf("start = %v, want ErrStopping", err)
+	}
+
+	c.state = "STOPPED"
+	err = s.start(context.Background(), "i-123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.starts != 1 {
+		t.Errorf("StartServer called %d times, want 1", c.starts)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     string
+		operation *types.Operation
+		serverID  string
+		want      string
+	}{
+		{name: "stopped", state: "STOPPED", serverID: "i-123", want: StartingKick},
+		{name: "stopping", state: "STOPPING", serverID: "i-123", want: StoppingKick},
+		{name: "operation in progress", state: "STOPPED", operation: types.NewOperation(types.OperationImport, nil), serverID: "i-123", want: OperationKick},
+		{name: "unknown host", state: "STOPPED", want: UnknownKick},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				Compute: &fakeCompute{state: tt.state},
+				DB:      &fakeDB{operation: tt.operation},
+				Servers: map[string]string{"mc.example.com": tt.serverID},
+			}
+
+			login := &bytes.Buffer{}
+			protocol.WriteString(login, "Steve")
+			p := exchange(t, s, "mc.example.com", stateLogin, &protocol.Packet{ID: packetLoginStart, Data: login.Bytes()})
+
+			if got := chatText(t, p); got != tt.want {
+				t.Errorf("kicked with %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    string
+		serverID string
+		want     string
+	}{
+		{name: "stopped", state: "STOPPED", serverID: "i-123", want: SleepingMOTD},
+		{name: "stopping", state: "STOPPING", serverID: "i-123", want: StoppingMOTD},
+		{name: "pending", state: "PENDING", serverID: "i-123", want: StartingMOTD},
+		{name: "unknown host", state: "STOPPED", want: UnknownKick},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				Compute: &fakeCompute{state: tt.state},
+				Servers: map[string]string{"mc.example.com": tt.serverID},
+			}
+
+			p := exchange(t, s, "MC.example.com.", stateStatus, &protocol.Packet{ID: packetStatus})
+
+			raw, err := protocol.ReadString(p.Reader())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res struct {
+				Description struct {
+					Text string `json:"text"`
+				} `json:"description"`
+			}
+			err = json.Unmarshal([]byte(raw), &res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Description.Text != tt.want {
+				t.Errorf("MOTD = %q, want %q", res.Description.Text, tt.want)
+			}
+		})
+	}
+}
+
+// exchange connects to s with a handshake for host and next state, sends p
+// and returns the packet s answers with.
+func exchange(t *testing.T, s *Server, host string, next int32, p *protocol.Packet) *protocol.Packet {
+	t.Helper()
+	client, server := net.Pipe()
+	defer client.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		defer server.Close()
+		done <- s.handle(context.Background(), server)
+	}()
+
+	handshake := &bytes.Buffer{}
+	protocol.WriteVarInt(handshake, 769)
+	protocol.WriteString(handshake, host)
+	protocol.WriteUint16(handshake, 25565)
+	protocol.WriteVarInt(handshake, next)
+
+	err := protocol.WritePacket(client, &protocol.Packet{ID: packetHandshake, Data: handshake.Bytes()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = protocol.WritePacket(client, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := protocol.ReadPacket(bufio.NewReader(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+
+	err = <-done
+	if err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	return res
+}
+
+func chatText(t *testing.T, p *protocol.Packet) string {
+	t.Helper()
+	raw, err := protocol.ReadString(p.Reader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg struct {
+		Text string `json:"text"`
+	}
+	err = json.Unmarshal([]byte(raw), &msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg.Text
+}
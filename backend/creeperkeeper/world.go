@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/minecraft/level"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+	"golang.org/x/exp/rand"
+)
+
+const transferPrefix string = "transfers/"
+
+// levelDat is level.dat relative to the server's data directory.
+func levelDat(levelName string) string {
+	return levelName + "/level.dat"
+}
+
+// storedWorldInfo reads level.dat from the world last synced to S3. It
+// returns nil when the server has no stored world.
+func (h *Handler) storedWorldInfo(ctx context.Context, server *types.Server) (*level.Info, error) {
+	levelName, err := h.Client.backup.LevelName(ctx, utils.ToString(server.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	key := backup.WorldPrefix(utils.ToString(server.Name)) + levelDat(levelName)
+	body, err := h.Client.storage.Client.Get(ctx, utils.ToString(h.Client.storage.Bucket), key)
+	if errors.Is(err, types.ErrObjectNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return level.Read(body)
+}
+
+// worldVersion is the game version the server's stored world was last saved
+// with, falling back to the version in its profile. It is empty when neither
+// is known, since a backup is still worth making without it.
+func (h *Handler) worldVersion(ctx context.Context, server *types.Server) string {
+	info, err := h.storedWorldInfo(ctx, server)
+	if err == nil && info != nil && info.Version != "" {
+		return info.Version
+	}
+
+	profile, err := h.Client.db.Client.GetServerProfile(ctx, utils.ToString(h.Client.db.Table), utils.ToString(server.ID))
+	if err != nil {
+		return ""
+	}
+	return utils.ToString(profile.Version)
+}
+
+// liveWorldInfo reads level.dat from the running server's disk.
+func (h *Handler) liveWorldInfo(ctx context.Context, server *types.Server) (*level.Info, error) {
+	levelName, err := h.Client.backup.LevelName(ctx, utils.ToString(server.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := h.readInstanceFile(ctx, server, levelDat(levelName))
+	if err != nil {
+		return nil, err
+	}
+	return level.Read(bytes.NewReader(b))
+}
+
+// worldInfo reads the stored world, logging rather than failing when it
+// cannot.
+func (h *Handler) worldInfo(ctx context.Context, server *types.Server) *level.Info {
+	info, err := h.storedWorldInfo(ctx, server)
+	if err != nil {
+		log.Println("failed to read stored world for", utils.ToString(server.ID), err)
+		return nil
+	}
+	return info
+}
+
+// errNoStoredWorld is returned when a file would be stored for a server that
+// has no world in the bucket. The boot sync only replaces the data directory
+// when the server has something stored, so storing a lone file would have
+// the next boot wipe the world on disk.
+var errNoStoredWorld = errors.New("server has no stored world, start it to change its files")
+
+// readDataFile reads a file from the server's data directory, from the
+// instance when it is running, since that copy is synced over the stored one
+// when it stops, and from the stored world otherwise. A missing file reads
+// as empty. live reports which copy was read.
+func (h *Handler) readDataFile(ctx context.Context, server *types.Server, name string) ([]byte, bool, error) {
+	if utils.ToBool(server.IsRunning) {
+		b, err := h.readInstanceFile(ctx, server, name)
+		if errors.Is(err, types.ErrObjectNotFound) {
+			return []byte{}, true, nil
+		}
+		return b, true, err
+	}
+
+	key := backup.WorldPrefix(utils.ToString(server.Name)) + name
+	body, err := h.Client.storage.Client.Get(ctx, utils.ToString(h.Client.storage.Bucket), key)
+	if errors.Is(err, types.ErrObjectNotFound) {
+		return []byte{}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer body.Close()
+
+	b, err := io.ReadAll(body)
+	return b, false, err
+}
+
+// writeDataFile puts a file in the server's data directory on the instance
+// when live, or in its stored world otherwise.
+func (h *Handler) writeDataFile(ctx context.Context, server *types.Server, name string, b []byte, live bool) error {
+	if live {
+		return h.writeInstanceFile(ctx, server, name, b)
+	}
+
+	bucket := utils.ToString(h.Client.storage.Bucket)
+	worldPrefix := backup.WorldPrefix(utils.ToString(server.Name))
+	objects, err := h.Client.storage.Client.List(ctx, bucket, worldPrefix)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		return errNoStoredWorld
+	}
+
+	return h.Client.storage.Client.Put(ctx, bucket, worldPrefix+name, bytes.NewReader(b))
+}
+
+// transferKey is where a file is staged while it is copied between the
+// lambda and an instance. SSM truncates command output and limits the size
+// of commands, so files go through the bucket instead. Transfers left behind
+// are expired by a lifecycle rule on the bucket.
+func transferKey(serverID string) string {
+	return utils.Concat(transferPrefix, serverID, "/", strconv.FormatUint(rand.Uint64(), 36))
+}
+
+// readInstanceFile reads a file from the running server's data directory by
+// having the instance copy it to the bucket. A missing file is
+// types.ErrObjectNotFound.
+func (h *Handler) readInstanceFile(ctx context.Context, server *types.Server, name string) ([]byte, error) {
+	bucket := utils.ToString(h.Client.storage.Bucket)
+	key := transferKey(utils.ToString(server.ID))
+	path := utils.ShellQuote("data/" + name)
+
+	err := h.run(ctx, utils.ToString(server.ID), []string{
+		utils.Concat("if [ -f ", path, " ]; then sudo aws s3 cp --quiet ", path, " s3://", bucket, "/", key, "; fi"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := h.Client.storage.Client.Get(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	defer h.deleteTransfer(ctx, bucket, key)
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return b, nil
+}
+
+// writeInstanceFile puts a file in the running server's data directory by
+// staging it in the bucket for the instance to copy.
+func (h *Handler) writeInstanceFile(ctx context.Context, server *types.Server, name string, b []byte) error {
+	bucket := utils.ToString(h.Client.storage.Bucket)
+	key := transferKey(utils.ToString(server.ID))
+	path := utils.ShellQuote("data/" + name)
+
+	err := h.Client.storage.Client.Put(ctx, bucket, key, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer h.deleteTransfer(ctx, bucket, key)
+
+	return h.run(ctx, utils.ToString(server.ID), []string{
+		utils.Concat("sudo aws s3 cp --quiet s3://", bucket, "/", key, " ", path),
+		// The container runs as the owner of the data directory
+		utils.Concat("sudo chown --reference=data ", path),
+	})
+}
+
+func (h *Handler) deleteTransfer(ctx context.Context, bucket string, key string) {
+	err := h.Client.storage.Client.Delete(ctx, bucket, key)
+	if err != nil {
+		log.Println("failed to delete transfer", key, err)
+	}
+}
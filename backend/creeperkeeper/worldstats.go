@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hnucamendi/creeper-keeper/backup"
+	"github.com/hnucamendi/creeper-keeper/minecraft/anvil"
+	"github.com/hnucamendi/creeper-keeper/types"
+	"github.com/hnucamendi/creeper-keeper/utils"
+)
+
+const (
+	statsJobRoute string = "/creeperkeeper/jobs/world-stats"
+
+	// leastRecentlyTouchedAreas is how many of the stalest regions are
+	// reported.
+	leastRecentlyTouchedAreas int = 20
+)
+
+func worldStatsKey(serverID string) string {
+	return "stats/" + serverID + "/world.json"
+}
+
+// GetWorldStats returns the last region file analysis of the server's
+// stored world.
+func (h *Handler) GetWorldStats(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	body, err := h.Client.storage.Client.Get(r.Context(), utils.ToString(h.Client.storage.Bucket), worldStatsKey(serverID))
+	if errors.Is(err, types.ErrObjectNotFound) {
+		writeResponse(w, r, http.StatusNotFound, "no world stats for "+serverID+", analyze the world first")
+		return
+	}
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer body.Close()
+
+	stats := &types.WorldStats{}
+	err = json.NewDecoder(body).Decode(stats)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, stats)
+}
+
+// AnalyzeWorld starts analyzing the region files of the server's stored
+// world. Reading every region file can take minutes, so it runs as a job.
+func (h *Handler) AnalyzeWorld(w http.ResponseWriter, r *http.Request) {
+	serverID := r.PathValue("serverID")
+	if serverID == "" {
+		writeResponse(w, r, http.StatusBadRequest, "serverID must be provided")
+		return
+	}
+
+	server, err := h.Client.db.Client.ListServer(r.Context(), utils.ToString(h.Client.db.Table), serverID)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if server.Name == nil {
+		writeResponse(w, r, http.StatusNotFound, "server not found: "+serverID)
+		return
+	}
+
+	h.startTask(w, r, serverID, types.WorldTask(types.OperationStats), statsJobRoute, types.NewOperation(types.OperationStats, nil))
+}
+
+func (h *Handler) WorldStatsJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.loadOperation(r)
+	if err != nil {
+		writeResponse(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var stats *types.WorldStats
+	err = run.step(r.Context(), "analyze regions", func() error {
+		stats, err = h.analyzeWorld(r.Context(), run.server)
+		return err
+	})
+	if err == nil {
+		err = run.step(r.Context(), "store stats", func() error {
+			return h.putWorldStats(r.Context(), stats)
+		})
+	}
+
+	run.finish(r.Context(), err)
+	if err != nil {
+		writeResponse(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, stats)
+}
+
+func (h *Handler) analyzeWorld(ctx context.Context, server *types.Server) (*types.WorldStats, error) {
+	bucket := utils.ToString(h.Client.storage.Bucket)
+	levelName, err := h.Client.backup.LevelName(ctx, utils.ToString(server.Name))
+	if err != nil {
+		return nil, err
+	}
+	worldPrefix := backup.WorldPrefix(utils.ToString(server.Name)) + levelName + "/"
+
+	objects, err := h.Client.storage.Client.List(ctx, bucket, worldPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list world: %w", err)
+	}
+
+	stats := &types.WorldStats{
+		ServerID:    server.ID,
+		GeneratedAt: utils.String(time.Now().UTC().Format(time.RFC3339)),
+	}
+
+	acc := anvil.NewStats()
+	for _, o := range objects {
+		key := utils.ToString(o.Key)
+		f, ok := anvil.ParsePath(strings.TrimPrefix(key, worldPrefix))
+		if !ok {
+			continue
+		}
+
+		if f.Folder != anvil.FolderRegion {
+			acc.AddFile(f, o.Size)
+			continue
+		}
+
+		region, err := h.readRegion(ctx, bucket, key)
+		if err != nil {
+			stats.Errors = append(stats.Errors, fmt.Sprintf("%s: %v", key, err))
+			acc.AddFile(f, o.Size)
+			continue
+		}
+		acc.AddRegion(f, region)
+	}
+
+	stats.Dimensions = acc.Dimensions()
+	stats.LeastRecentlyTouched = acc.LeastRecentlyTouched(leastRecentlyTouchedAreas)
+	return stats, nil
+}
+
+func (h *Handler) readRegion(ctx context.Context, bucket string, key string) (*anvil.Region, error) {
+	body, err := h.Client.storage.Client.Get(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	return anvil.ReadRegion(bytes.NewReader(b), int64(len(b)))
+}
+
+func (h *Handler) putWorldStats(ctx context.Context, stats *types.WorldStats) error {
+	b, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	return h.Client.storage.Client.Put(ctx, utils.ToString(h.Client.storage.Bucket), worldStatsKey(utils.ToString(stats.ServerID)), bytes.NewReader(b))
+}
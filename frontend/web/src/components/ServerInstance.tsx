@@ -61,9 +61,11 @@ export default function ServerInstance({
                 Status:{" "}
                 {serverStatus
                   ? serverStatus
-                  : v.isRunning
-                    ? "RUNNING"
-                    : "STOPPED"}
+                  : v.state
+                    ? v.state
+                    : v.isRunning
+                      ? "RUNNING"
+                      : "STOPPED"}
               </p>
             </div>
           </div>
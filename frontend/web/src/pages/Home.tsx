@@ -6,6 +6,19 @@ import ServerInstance from "../components/ServerInstance";
 import { HTMLFormMethod } from "react-router-dom";
 import "../styles/pages/home.css";
 
+export interface WorldInfo {
+  name: string;
+  seed: string;
+  version?: string;
+  dataVersion: number;
+  spawn: { x: number; y: number; z: number };
+  difficulty: string;
+  hardcore: boolean;
+  gameRules: Record<string, string>;
+  timePlayed: number;
+  dayTime: number;
+}
+
 export interface Server {
   serverID: string;
   row: string;
@@ -13,6 +26,26 @@ export interface Server {
   serverName: string;
   lastUpdated: string;
   isRunning: boolean;
+  state?: string;
+  readyAt?: string;
+  ports?: { game: number; rcon: number };
+  world?: WorldInfo;
+}
+
+export interface ServerPing {
+  state: string;
+  status?: {
+    version: { name: string; protocol: number };
+    players: {
+      max: number;
+      online: number;
+      sample?: Array<{ name: string; id: string }>;
+    };
+    motd: string;
+    latencyMs: number;
+  };
+  statusError?: string;
+  world?: WorldInfo;
 }
 
 export default function Home(): React.ReactNode {
@@ -57,8 +90,12 @@ export default function Home(): React.ReactNode {
         throw new Error(
           `Error refreshing sever status; response: ${res.status}`,
         );
-      const resJson: string = await res.json();
-      setServerStatus(resJson);
+      const resJson: ServerPing = await res.json();
+      setServerStatus(
+        resJson.status
+          ? `${resJson.state} (${resJson.status.players.online}/${resJson.status.players.max} players)`
+          : resJson.state,
+      );
     } catch (error: unknown) {
       console.error(error);
     }
@@ -16,7 +16,7 @@ resource "aws_apigatewayv2_api" "main" {
   name          = var.ck_app_name
   protocol_type = "HTTP"
   cors_configuration {
-    allow_methods  = ["POST", "GET", "OPTIONS"]
+    allow_methods  = ["POST", "GET", "PUT", "PATCH", "DELETE", "OPTIONS"]
     allow_origins  = ["http://localhost:5173", "https://${local.ck_host_name}", "https://${local.ck_web_host_name}"]
     allow_headers  = ["authorization", "content-type", "if-none-match"]
     expose_headers = ["etag"]
@@ -81,6 +81,303 @@ resource "aws_apigatewayv2_route" "ping" {
   authorization_type   = "JWT"
 }
 
+resource "aws_apigatewayv2_route" "state" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/state"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "settings" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "PATCH /servers/{serverID}/settings"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "command" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/commands/{commandID}"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["read:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "backups_list" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/backups"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["read:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "backups_create" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/backups"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "backups_get" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/backups/{backupID}"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["read:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "backups_delete" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "DELETE /servers/{serverID}/backups/{backupID}"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "backups_prune" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/backups/prune"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "download" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/download"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["download:world", "write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "downloads_list" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/downloads"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "downloads_get" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/downloads/{downloadID}"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["download:world", "write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "uploads_create" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/uploads"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "uploads_import" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/uploads/{uploadID}/import"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "tasks_list" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/tasks"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["read:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "world_stats_get" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/world/stats"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["read:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "world_stats_analyze" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/world/stats"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "backups_prune_chunks" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/backups/{backupID}/prune-chunks"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "backups_map_get" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/backups/{backupID}/map"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["read:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "backups_map_render" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/backups/{backupID}/map"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "profile_get" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/profile"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["read:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "profile_create" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/profile"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "profile_update" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "PUT /servers/{serverID}/profile"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "profile_delete" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "DELETE /servers/{serverID}/profile"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "container_get" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/container"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["read:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "upgrade" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/upgrade"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "properties_get" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/properties"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["read:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "properties_update" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "PATCH /servers/{serverID}/properties"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "access_get" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/access/{list}"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["read:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "access_update" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "PATCH /servers/{serverID}/access/{list}"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "players_list" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/players"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["read:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "players_get" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "GET /servers/{serverID}/players/{playerID}"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["read:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "restore" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/restore"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
+resource "aws_apigatewayv2_route" "rollback" {
+  api_id               = aws_apigatewayv2_api.main.id
+  route_key            = "POST /servers/{serverID}/rollback"
+  target               = "integrations/${aws_apigatewayv2_integration.main.id}"
+  authorization_scopes = ["write:all"]
+  authorizer_id        = aws_apigatewayv2_authorizer.main.id
+  authorization_type   = "JWT"
+}
+
 resource "aws_apigatewayv2_stage" "main" {
   api_id      = aws_apigatewayv2_api.main.id
   name        = var.ck_app_name
@@ -23,3 +23,89 @@ resource "aws_cloudwatch_event_target" "ec2_monitor" {
   target_id = aws_lambda_function.ec2_monitor.function_name
   arn       = aws_lambda_function.ec2_monitor.arn
 }
+
+# Scheduled jobs invoke the controller with an API Gateway shaped event so
+# they are routed like any other request.
+resource "aws_scheduler_schedule" "idle_shutdown" {
+  name       = "idle-shutdown"
+  group_name = aws_scheduler_schedule_group.main.name
+
+  schedule_expression = "rate(5 minutes)"
+
+  flexible_time_window {
+    mode = "OFF"
+  }
+
+  target {
+    arn      = aws_lambda_function.controller.arn
+    role_arn = aws_iam_role.main.arn
+    input = jsonencode({
+      version  = "2.0"
+      routeKey = "POST /creeperkeeper/jobs/idle-shutdown"
+      rawPath  = "/creeperkeeper/jobs/idle-shutdown"
+      requestContext = {
+        http = {
+          method = "POST"
+          path   = "/creeperkeeper/jobs/idle-shutdown"
+        }
+      }
+      isBase64Encoded = false
+    })
+  }
+}
+
+resource "aws_scheduler_schedule" "prune_backups" {
+  name       = "prune-backups"
+  group_name = aws_scheduler_schedule_group.main.name
+
+  schedule_expression = "rate(1 hour)"
+
+  flexible_time_window {
+    mode = "OFF"
+  }
+
+  target {
+    arn      = aws_lambda_function.controller.arn
+    role_arn = aws_iam_role.main.arn
+    input = jsonencode({
+      version  = "2.0"
+      routeKey = "POST /creeperkeeper/jobs/prune-backups"
+      rawPath  = "/creeperkeeper/jobs/prune-backups"
+      requestContext = {
+        http = {
+          method = "POST"
+          path   = "/creeperkeeper/jobs/prune-backups"
+        }
+      }
+      isBase64Encoded = false
+    })
+  }
+}
+
+resource "aws_scheduler_schedule" "scheduled_backups" {
+  name       = "scheduled-backups"
+  group_name = aws_scheduler_schedule_group.main.name
+
+  schedule_expression = "rate(5 minutes)"
+
+  flexible_time_window {
+    mode = "OFF"
+  }
+
+  target {
+    arn      = aws_lambda_function.controller.arn
+    role_arn = aws_iam_role.main.arn
+    input = jsonencode({
+      version  = "2.0"
+      routeKey = "POST /creeperkeeper/jobs/scheduled-backups"
+      rawPath  = "/creeperkeeper/jobs/scheduled-backups"
+      requestContext = {
+        http = {
+          method = "POST"
+          path   = "/creeperkeeper/jobs/scheduled-backups"
+        }
+      }
+      isBase64Encoded = false
+    })
+  }
+}
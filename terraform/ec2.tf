@@ -1,3 +1,19 @@
+# RCON is only reachable from the CIDRs in rcon_allowed_cidrs. Without any,
+# the port stays closed and the backend sends console commands through SSM.
+locals {
+  rcon_ingress_rules = {
+    for name, rule in {
+      "allow-rcon" = {
+        description = "Allow RCON from the backend"
+        from_port   = 25575
+        to_port     = 25575
+        protocol    = "tcp"
+        cidr_blocks = var.rcon_allowed_cidrs
+      }
+    } : name => rule if length(var.rcon_allowed_cidrs) > 0
+  }
+}
+
 module "vanilla" {
   source  = "hnucamendi/minecraft-server-module/aws"
   version = "1.0.6"
@@ -8,7 +24,7 @@ module "vanilla" {
   minecraft_ops_list = "Oldjimmy_"
   minecraft_memory_G = 1
 
-  security_group_ingress_rules = {
+  security_group_ingress_rules = merge(local.rcon_ingress_rules, {
     "allow-all-mc" = {
       description = "Allow Minecraft TCP"
       from_port   = 25565
@@ -23,7 +39,7 @@ module "vanilla" {
       protocol    = "tcp"
       cidr_blocks = ["${var.home_ip}/32"] # Restrict to your IP address
     }
-  }
+  })
 }
 
 resource "aws_iam_policy" "s3_policy" {
@@ -57,6 +73,50 @@ resource "aws_iam_role_policy_attachment" "s3_policy_attachment" {
   policy_arn = aws_iam_policy.s3_policy.arn
 }
 
+# Profile containers read the RCON password the backend's console uses, and
+# the CurseForge API key for CurseForge modpacks, when they are created.
+resource "aws_iam_policy" "rcon_password_policy" {
+  name        = "${var.ck_app_name}-rcon-password-policy"
+  description = "Policy granting the Minecraft server instances read access to the RCON password"
+  policy = jsonencode({
+    Version = "2012-10-17",
+    Statement = [
+      {
+        Sid      = "AllowGetRCONPassword",
+        Effect   = "Allow",
+        Action   = ["ssm:GetParameter"],
+        Resource = aws_ssm_parameter.rcon_password.arn
+      },
+      {
+        Sid      = "AllowGetCurseForgeAPIKey",
+        Effect   = "Allow",
+        Action   = ["ssm:GetParameter"],
+        Resource = aws_ssm_parameter.curseforge_api_key.arn
+      }
+    ]
+  })
+}
+
+# The servers' roles are looked up from their instances' profiles.
+data "aws_instance" "minecraft" {
+  for_each = {
+    vanilla    = module.vanilla.instance_id
+    ftb_server = module.ftb_server.instance_id
+  }
+  instance_id = each.value
+}
+
+data "aws_iam_instance_profile" "minecraft" {
+  for_each = data.aws_instance.minecraft
+  name     = each.value.iam_instance_profile
+}
+
+resource "aws_iam_role_policy_attachment" "rcon_password_policy_attachment" {
+  for_each   = data.aws_iam_instance_profile.minecraft
+  role       = each.value.role_name
+  policy_arn = aws_iam_policy.rcon_password_policy.arn
+}
+
 ## Direwolf modpack ##
 module "ftb_server" {
   source  = "hnucamendi/minecraft-server-module/aws"
@@ -77,7 +137,7 @@ module "ftb_server" {
   ftb_modpack_version_id              = 100027
   ftb_modpack_id                      = 126
 
-  security_group_ingress_rules = {
+  security_group_ingress_rules = merge(local.rcon_ingress_rules, {
     "allow-all-mc" = {
       description = "Allow Minecraft TCP"
       from_port   = 25565
@@ -92,5 +152,5 @@ module "ftb_server" {
       protocol    = "tcp"
       cidr_blocks = ["${var.home_ip}/32"] # Restrict to your IP address
     }
-  }
+  })
 }
@@ -6,6 +6,25 @@ resource "aws_lambda_function" "controller" {
   filename      = "./bootstrap.zip"
   handler       = "bootstrap"
   runtime       = "provided.al2023"
+  timeout       = 900
+
+  # Backups are staged in /tmp before upload
+  ephemeral_storage {
+    size = 10240
+  }
+
+  environment {
+    variables = {
+      RCON_DIRECT = length(var.rcon_allowed_cidrs) > 0 ? "true" : "false"
+    }
+  }
+}
+
+# Jobs are self-invoked asynchronously and record their own failures, so a
+# retry would only repeat a half finished operation.
+resource "aws_lambda_function_event_invoke_config" "controller" {
+  function_name          = aws_lambda_function.controller.function_name
+  maximum_retry_attempts = 0
 }
 
 # IAM Role
@@ -66,6 +85,7 @@ resource "aws_iam_role_policy" "main" {
           "ssm:GetParameter",
           "ssm:SendCommand",
           "ssm:ListCommandInvocations",
+          "ssm:GetCommandInvocation",
         ],
         Resource = [
           "*",
@@ -77,11 +97,36 @@ resource "aws_iam_role_policy" "main" {
           "dynamodb:PutItem",
           "dynamodb:GetItem",
           "dynamodb:Scan",
+          "dynamodb:UpdateItem",
+          "dynamodb:Query",
+          "dynamodb:DeleteItem",
         ],
         Resource = [
           aws_dynamodb_table.main.arn,
         ]
       },
+      {
+        Effect = "Allow",
+        Action = [
+          "s3:ListBucket",
+          "s3:ListBucketVersions",
+        ],
+        Resource = [
+          aws_s3_bucket.world_data.arn,
+        ]
+      },
+      {
+        Effect = "Allow",
+        Action = [
+          "s3:GetObject",
+          "s3:GetObjectVersion",
+          "s3:PutObject",
+          "s3:DeleteObject",
+        ],
+        Resource = [
+          "${aws_s3_bucket.world_data.arn}/*",
+        ]
+      },
       {
         Effect = "Allow",
         Action = [
@@ -119,6 +164,7 @@ resource "aws_lambda_function" "ec2_monitor" {
   filename      = "./bootstrap.zip"
   handler       = "bootstrap"
   runtime       = "provided.al2023"
+  timeout       = 900
 }
 
 resource "aws_lambda_permission" "ec2_monitor" {
@@ -4,6 +4,10 @@ terraform {
 			source = "hashicorp/aws"
 			version = "~> 5.0"
 		}
+		random = {
+			source = "hashicorp/random"
+			version = "~> 3.6"
+		}
 	}
 }
 
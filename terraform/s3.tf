@@ -2,6 +2,97 @@ resource "aws_s3_bucket" "world_data" {
   bucket = "${var.ck_app_name}-world-data"
 }
 
+# Versioning lets a world be restored from an earlier object version
+resource "aws_s3_bucket_versioning" "world_data" {
+  bucket = aws_s3_bucket.world_data.id
+
+  versioning_configuration {
+    status = "Enabled"
+  }
+}
+
+# World downloads are only needed until their presigned links expire
+resource "aws_s3_bucket_lifecycle_configuration" "world_data" {
+  bucket = aws_s3_bucket.world_data.id
+
+  # Older world versions are kept as long as regions can be rolled back to
+  # them, types.RollbackWindow in the backend
+  rule {
+    id     = "expire-world-versions"
+    status = "Enabled"
+
+    filter {}
+
+    noncurrent_version_expiration {
+      noncurrent_days = 30
+    }
+  }
+
+  rule {
+    id     = "expire-downloads"
+    status = "Enabled"
+
+    filter {
+      prefix = "downloads/"
+    }
+
+    expiration {
+      days = 1
+    }
+
+    noncurrent_version_expiration {
+      noncurrent_days = 1
+    }
+  }
+
+  rule {
+    id     = "expire-uploads"
+    status = "Enabled"
+
+    filter {
+      prefix = "uploads/"
+    }
+
+    expiration {
+      days = 1
+    }
+
+    noncurrent_version_expiration {
+      noncurrent_days = 1
+    }
+  }
+
+  rule {
+    id     = "expire-transfers"
+    status = "Enabled"
+
+    filter {
+      prefix = "transfers/"
+    }
+
+    expiration {
+      days = 1
+    }
+
+    noncurrent_version_expiration {
+      noncurrent_days = 1
+    }
+  }
+}
+
+# Browsers upload world zips straight to the bucket with presigned URLs
+resource "aws_s3_bucket_cors_configuration" "world_data" {
+  bucket = aws_s3_bucket.world_data.id
+
+  cors_rule {
+    allowed_methods = ["PUT", "GET"]
+    allowed_origins = ["http://localhost:5173", "https://${local.ck_host_name}", "https://${local.ck_web_host_name}"]
+    allowed_headers = ["*"]
+    expose_headers  = ["etag"]
+    max_age_seconds = 3600
+  }
+}
+
 ## cloudfront bucket
 resource "aws_s3_bucket" "web" {
   bucket = local.ck_host_name
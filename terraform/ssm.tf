@@ -37,3 +37,26 @@ resource "aws_ssm_parameter" "statemanager_jwt_client_url" {
     ignore_changes = [value]
   }
 }
+
+resource "random_password" "rcon" {
+  length  = 32
+  special = false
+}
+
+resource "aws_ssm_parameter" "rcon_password" {
+  name  = "/${var.ck_app_name}/rcon/password"
+  type  = "SecureString"
+  value = random_password.rcon.result
+}
+
+# Set by hand to a CurseForge API key, which containers of CurseForge
+# modpack servers need to download their modpack.
+resource "aws_ssm_parameter" "curseforge_api_key" {
+  name  = "/${var.ck_app_name}/curseforge/api-key"
+  type  = "SecureString"
+  value = "changeme"
+
+  lifecycle {
+    ignore_changes = [value]
+  }
+}
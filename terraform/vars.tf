@@ -13,3 +13,10 @@ variable "ck_app_name" {
   sensitive = false
   default   = "creeperkeeper"
 }
+
+# CIDRs allowed to reach the servers' RCON port. Leave empty unless the
+# backend has a fixed address. Without any, it sends commands through SSM.
+variable "rcon_allowed_cidrs" {
+  type    = list(string)
+  default = []
+}